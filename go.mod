@@ -6,4 +6,6 @@ require (
 	github.com/appleboy/gofight/v2 v2.1.2
 	github.com/gorilla/mux v1.7.4
 	go.mongodb.org/mongo-driver v1.3.0
+	golang.org/x/crypto v0.0.0-20190530122614-20be4c3c3ed5
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -1,10 +1,17 @@
 package main
 
 import (
+	"flag"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/aakordas/creature_manager/pkg/archive"
+	"github.com/aakordas/creature_manager/pkg/importer"
 	"github.com/aakordas/creature_manager/pkg/server"
 )
 
@@ -27,6 +34,20 @@ func main() {
 	r := server.Connect(databaseAddress)
 	defer server.Disconnect()
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			importMonsters(os.Args[2:])
+			return
+		case "export":
+			exportArchive(os.Args[2:])
+			return
+		case "import-archive":
+			importArchive(os.Args[2:])
+			return
+		}
+	}
+
 	srv := &http.Server{
 		Handler:      r,
 		Addr:         serverAddress,
@@ -37,3 +58,112 @@ func main() {
 	log.Println("Starting server...")
 	log.Fatal(srv.ListenAndServe())
 }
+
+// importMonsters imports the monsters of the provided stat block files, e.g.
+// creature_manager import -campaign "Lost Mine" -overwrite goblin.json
+func importMonsters(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	overwrite := flags.Bool("overwrite", false, "replace monsters that already exist")
+	campaign := flags.String("campaign", "", "the campaign the monsters belong to")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		log.Println("Usage: creature_manager import [-campaign name] [-overwrite] file...")
+		return
+	}
+
+	for _, file := range flags.Args() {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		monsters, err := importer.Monsters(data)
+		if err != nil {
+			log.Println(file+":", err)
+			continue
+		}
+
+		imported, skipped, err := server.StoreMonsters(monsters, *campaign, "", *overwrite)
+		if err != nil {
+			log.Println(file+":", err)
+			continue
+		}
+		log.Printf("%s: imported %v, skipped %v\n", file, imported, skipped)
+	}
+}
+
+// exportArchive writes the archive of a campaign, or of the whole database, to
+// the provided file or to the standard output, e.g.
+// creature_manager export -campaign "Lost Mine" -format yaml lost_mine.yaml
+func exportArchive(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	campaign := flags.String("campaign", "", "the campaign to export, instead of the whole database")
+	format := flags.String("format", archive.JSON, "the format of the archive, json or yaml")
+	flags.Parse(args)
+
+	if !archive.ValidFormat(*format) || flags.NArg() > 1 {
+		log.Println("Usage: creature_manager export [-campaign name] [-format json|yaml] [file]")
+		return
+	}
+
+	a, err := server.CollectArchive(*campaign)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	data, err := archive.Encode(a, *format)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if flags.NArg() == 0 {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := ioutil.WriteFile(flags.Arg(0), data, 0644); err != nil {
+		log.Println(err)
+	}
+}
+
+// importArchive imports the provided archive files, as YAML if their extension
+// is .yaml or .yml and as JSON otherwise, e.g.
+// creature_manager import-archive -conflict rename lost_mine.yaml
+func importArchive(args []string) {
+	flags := flag.NewFlagSet("import-archive", flag.ExitOnError)
+	conflict := flags.String("conflict", archive.Skip, "what happens to entries whose name is taken: skip, overwrite or rename")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 || !archive.ValidConflict(*conflict) {
+		log.Println("Usage: creature_manager import-archive [-conflict skip|overwrite|rename] file...")
+		return
+	}
+
+	for _, file := range flags.Args() {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		format := archive.JSON
+		if ext := strings.ToLower(filepath.Ext(file)); ext == ".yaml" || ext == ".yml" {
+			format = archive.YAML
+		}
+
+		a, err := archive.Decode(data, format)
+		if err != nil {
+			log.Println(file+":", err)
+			continue
+		}
+
+		report, err := server.StoreArchive(a, "", *conflict)
+		if err != nil {
+			log.Println(file+":", err)
+			continue
+		}
+		log.Printf("%s: imported %v, skipped %v, renamed %v\n", file, report.Imported, report.Skipped, report.Renamed)
+	}
+}
@@ -72,6 +72,69 @@ const (
 	Charisma = "charisma"
 )
 
+// Modifier returns the modifier of the provided ability. An unknown ability has
+// no modifier.
+func (a Abilities) Modifier(ability string) int {
+	switch ability {
+	case Strength:
+		return a.StrengthModifier
+	case Dexterity:
+		return a.DexterityModifier
+	case Constitution:
+		return a.ConstitutionModifier
+	case Intelligence:
+		return a.IntelligenceModifier
+	case Wisdom:
+		return a.WisdomModifier
+	case Charisma:
+		return a.CharismaModifier
+	default:
+		return 0
+	}
+}
+
+// Score returns the score of the provided ability. An unknown ability has no
+// score.
+func (a Abilities) Score(ability string) int {
+	switch ability {
+	case Strength:
+		return a.Strength
+	case Dexterity:
+		return a.Dexterity
+	case Constitution:
+		return a.Constitution
+	case Intelligence:
+		return a.Intelligence
+	case Wisdom:
+		return a.Wisdom
+	case Charisma:
+		return a.Charisma
+	default:
+		return 0
+	}
+}
+
+// Set sets the provided ability to the provided value, along with its
+// modifier. The value is expected to be within range.
+func (a *Abilities) Set(ability string, v int) {
+	modifier := AbilityScoresAndModifiers[v]
+
+	switch ability {
+	case Strength:
+		a.Strength, a.StrengthModifier = v, modifier
+	case Dexterity:
+		a.Dexterity, a.DexterityModifier = v, modifier
+	case Constitution:
+		a.Constitution, a.ConstitutionModifier = v, modifier
+	case Intelligence:
+		a.Intelligence, a.IntelligenceModifier = v, modifier
+	case Wisdom:
+		a.Wisdom, a.WisdomModifier = v, modifier
+	case Charisma:
+		a.Charisma, a.CharismaModifier = v, modifier
+	}
+}
+
 // OutOfRange checks whether the provided value is withing the acceptable range.
 func OutOfRange(v int) bool {
 	if v >= minimumAbilityScore && v <= maximumAbilityScore {
@@ -0,0 +1,223 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aakordas/creature_manager/pkg/campaign"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"gopkg.in/yaml.v2"
+)
+
+// Version is the version of the archive format. Archives of later versions are
+// refused.
+const Version = 1
+
+const (
+	// JSON means an archive encoded as JSON.
+	JSON = "json"
+	// YAML means an archive encoded as YAML, with the same keys as in JSON.
+	YAML = "yaml"
+)
+
+const (
+	// Skip means that whatever exists already is kept and the archived copy
+	// is left out.
+	Skip = "skip"
+	// Overwrite means that the archived copy replaces whatever exists already.
+	Overwrite = "overwrite"
+	// Rename means that the archived copy is stored under a free name.
+	Rename = "rename"
+)
+
+// Archive models a snapshot of creatures, along with their campaigns and
+// parties, that can be moved between servers.
+type Archive struct {
+	Version   int                 `json:"version"`
+	Created   time.Time           `json:"created"`
+	Campaigns []campaign.Campaign `json:"campaigns,omitempty"`
+	Parties   []campaign.Party    `json:"parties,omitempty"`
+	Creatures []creature.Creature `json:"creatures,omitempty"`
+}
+
+// Report tells what happened to each entry of an archive when it was imported.
+// Entries are named like "campaigns/Middle Earth", "parties/Middle
+// Earth/Fellowship" or "creatures/Middle Earth/Frodo", or "creatures/Frodo"
+// outside of any campaign.
+type Report struct {
+	Imported []string          `json:"imported"`
+	Skipped  []string          `json:"skipped"`
+	Renamed  map[string]string `json:"renamed,omitempty"` // The new name of each renamed entry.
+}
+
+// Error is the error that gets returned when an archive cannot be read.
+type Error struct {
+	Message string
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
+
+// New returns an archive of the provided campaigns, parties and creatures,
+// created at the provided time.
+func New(campaigns []campaign.Campaign, parties []campaign.Party, creatures []creature.Creature, now time.Time) Archive {
+	return Archive{
+		Version:   Version,
+		Created:   now,
+		Campaigns: campaigns,
+		Parties:   parties,
+		Creatures: creatures,
+	}
+}
+
+// ValidFormat checks whether the provided format is valid.
+func ValidFormat(format string) bool {
+	return format == JSON || format == YAML
+}
+
+// ValidConflict checks whether the provided way to handle conflicts is valid.
+func ValidConflict(conflict string) bool {
+	switch conflict {
+	case Skip, Overwrite, Rename:
+		return true
+	default:
+		return false
+	}
+}
+
+// Encode returns the archive encoded in the provided format.
+func Encode(a Archive, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(a, "", "\t")
+	if err != nil || format == JSON {
+		return data, err
+	}
+
+	// YAML goes through JSON, so that both have the same keys.
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(v)
+}
+
+// Decode returns the archive encoded in the provided format.
+func Decode(data []byte, format string) (*Archive, error) {
+	if format == YAML {
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, Error{"The archive is not valid YAML."}
+		}
+
+		var err error
+		data, err = json.Marshal(jsonValue(v))
+		if err != nil {
+			return nil, Error{"The archive is not valid YAML."}
+		}
+	}
+
+	var a Archive
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, Error{"The archive is not valid " + format + "."}
+	}
+
+	switch {
+	case a.Version < 1:
+		return nil, Error{"The archive has no version."}
+	case a.Version > Version:
+		return nil, Error{"The archive is of version " + strconv.Itoa(a.Version) + ", while only up to version " + strconv.Itoa(Version) + " is supported."}
+	}
+
+	return &a, nil
+}
+
+// jsonValue converts the maps that YAML decodes into the ones JSON encodes.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = jsonValue(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range v {
+			v[i] = jsonValue(e)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// FreeName returns the provided name, or the first of "name 2", "name 3"
+// and so on, that is not taken.
+func FreeName(name string, taken func(string) bool) string {
+	free := name
+	for i := 2; taken(free); i++ {
+		free = name + " " + strconv.Itoa(i)
+	}
+
+	return free
+}
+
+// RenameCampaign moves everything of the archive that belongs to the campaign
+// with the old name to the new one.
+func (a *Archive) RenameCampaign(old, new string) {
+	for i := range a.Campaigns {
+		if a.Campaigns[i].Name == old {
+			a.Campaigns[i].Name = new
+		}
+	}
+	for i := range a.Parties {
+		if a.Parties[i].Campaign == old {
+			a.Parties[i].Campaign = new
+		}
+	}
+	for i := range a.Creatures {
+		if a.Creatures[i].Campaign == old {
+			a.Creatures[i].Campaign = new
+		}
+	}
+}
+
+// RenameCreature renames the creature of the provided campaign with the old
+// name, along with its mentions in the parties of the campaign and in the
+// instances spawned from it.
+func (a *Archive) RenameCreature(campaign, old, new string) {
+	for i := range a.Creatures {
+		c := &a.Creatures[i]
+		if c.Campaign != campaign {
+			continue
+		}
+		if c.Name == old {
+			c.Name = new
+		}
+		if c.Template == old {
+			c.Template = new
+		}
+	}
+	for i := range a.Parties {
+		if a.Parties[i].Campaign != campaign {
+			continue
+		}
+		for j, m := range a.Parties[i].Members {
+			if m == old {
+				a.Parties[i].Members[j] = new
+			}
+		}
+	}
+}
+
+// Entry returns the name of the entry of a report for the provided kind of
+// entry, like campaigns, parties or creatures, of the provided campaign.
+func Entry(kind, campaign, name string) string {
+	if campaign == "" {
+		return kind + "/" + name
+	}
+
+	return kind + "/" + campaign + "/" + name
+}
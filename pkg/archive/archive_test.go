@@ -0,0 +1,111 @@
+package archive
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aakordas/creature_manager/pkg/abilities"
+	"github.com/aakordas/creature_manager/pkg/campaign"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func sample() Archive {
+	id, _ := primitive.ObjectIDFromHex("5f0c1a2b3c4d5e6f7a8b9c0d")
+
+	return New(
+		[]campaign.Campaign{{Name: "Middle Earth", DungeonMaster: "tolkien", Users: map[string]string{"frodo": campaign.Player}}},
+		[]campaign.Party{{Name: "Fellowship", Campaign: "Middle Earth", Members: []string{"Frodo", "Sam"}}},
+		[]creature.Creature{
+			{ID: id, Name: "Frodo", Kind: creature.Player, Campaign: "Middle Earth", Level: 3, Version: 7,
+				Abilities: abilities.Abilities{Dexterity: 16, DexterityModifier: 3}},
+			{Name: "Sam", Kind: creature.Player, Campaign: "Middle Earth", Level: 3},
+			{Name: "Goblin 1", Kind: creature.Monster, Campaign: "Middle Earth", Template: "Goblin"},
+		},
+		time.Date(2020, 7, 13, 12, 0, 0, 0, time.UTC),
+	)
+}
+
+func TestEncodeDecode(t *testing.T) {
+	for _, format := range []string{JSON, YAML} {
+		t.Run(format, func(t *testing.T) {
+			want := sample()
+
+			data, err := Encode(want, format)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			got, err := Decode(data, format)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(*got, want) {
+				t.Errorf("Decode(Encode()) = %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestDecode_Version(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format string
+	}{
+		{"Missing version", `{"creatures": []}`, JSON},
+		{"Later version", `{"version": 2}`, JSON},
+		{"Later version in YAML", "version: 2\n", YAML},
+		{"Invalid JSON", `{"version": 1`, JSON},
+		{"Invalid YAML", "version: [1\n", YAML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode([]byte(tt.data), tt.format); err == nil {
+				t.Errorf("Decode() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestFreeName(t *testing.T) {
+	taken := map[string]bool{"Frodo": true, "Frodo 2": true}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Sam", "Sam"},
+		{"Frodo", "Frodo 3"},
+	}
+	for _, tt := range tests {
+		got := FreeName(tt.name, func(name string) bool { return taken[name] })
+		if got != tt.want {
+			t.Errorf("FreeName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestArchive_Rename(t *testing.T) {
+	a := sample()
+	a.RenameCampaign("Middle Earth", "Arda")
+	a.RenameCreature("Arda", "Frodo", "Frodo 2")
+	a.RenameCreature("Arda", "Goblin", "Orc")
+
+	if got := a.Campaigns[0].Name; got != "Arda" {
+		t.Errorf("Campaigns[0].Name = %q, want %q", got, "Arda")
+	}
+	if want := (campaign.Party{Name: "Fellowship", Campaign: "Arda", Members: []string{"Frodo 2", "Sam"}}); !reflect.DeepEqual(a.Parties[0], want) {
+		t.Errorf("Parties[0] = %+v, want %+v", a.Parties[0], want)
+	}
+	for i, want := range []struct{ name, template string }{
+		{"Frodo 2", ""},
+		{"Sam", ""},
+		{"Goblin 1", "Orc"},
+	} {
+		c := a.Creatures[i]
+		if c.Campaign != "Arda" || c.Name != want.name || c.Template != want.template {
+			t.Errorf("Creatures[%d] = %q, %q, %q, want %q, %q, %q", i, c.Campaign, c.Name, c.Template, "Arda", want.name, want.template)
+		}
+	}
+}
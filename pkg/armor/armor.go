@@ -0,0 +1,108 @@
+package armor
+
+import (
+	"github.com/aakordas/creature_manager/pkg/abilities"
+	"github.com/aakordas/creature_manager/pkg/classes"
+)
+
+const (
+	// Light means light armor, which adds the full Dexterity modifier.
+	Light = "light"
+	// Medium means medium armor, which adds up to +2 of the Dexterity
+	// modifier.
+	Medium = "medium"
+	// Heavy means heavy armor, which ignores the Dexterity modifier.
+	Heavy = "heavy"
+	// Shield means a shield, which adds its armor class to any other armor.
+	Shield = "shield"
+)
+
+// Armor models the armor part of an item.
+type Armor struct {
+	Type                string `json:"type" bson:"type"`
+	BaseClass           int    `json:"base_class" bson:"base_class"` // The armor class of the armor, or the bonus of a shield.
+	StrengthRequirement int    `json:"strength_requirement,omitempty" bson:"strength_requirement,omitempty"`
+	StealthDisadvantage bool   `json:"stealth_disadvantage,omitempty" bson:"stealth_disadvantage,omitempty"`
+}
+
+// Defense holds everything besides armor that affects the armor class of a
+// creature.
+type Defense struct {
+	Manual    bool `json:"manual" bson:"manual"`         // The armor class is set by hand, like a monster's, and never calculated.
+	MageArmor bool `json:"mage_armor" bson:"mage_armor"` // A Mage Armor style effect sets the base armor class to 13.
+	Bonus     int  `json:"bonus" bson:"bonus"`           // Flat bonuses, like a Ring of Protection.
+}
+
+// unarmoredClass is the armor class of a creature without armor.
+const unarmoredClass = 10
+
+// mageArmorClass is the base armor class of a creature under Mage Armor.
+const mageArmorClass = 13
+
+// mediumDexterityCap indicates the maximum Dexterity modifier medium armor
+// allows.
+const mediumDexterityCap = 2
+
+// ValidType checks if the provided value is a valid armor type.
+func ValidType(t string) bool {
+	switch t {
+	case Light, Medium, Heavy, Shield:
+		return true
+	default:
+		return false
+	}
+}
+
+// Class calculates the armor class of a creature wearing the provided armor.
+// Without body armor, the best of Mage Armor and the unarmored defense of the
+// Barbarian and the Monk is used.
+func Class(worn []Armor, a abilities.Abilities, c classes.Classes, d Defense) int {
+	var body *Armor
+	shield := 0
+	for i := range worn {
+		switch worn[i].Type {
+		case Shield:
+			if worn[i].BaseClass > shield {
+				shield = worn[i].BaseClass
+			}
+		case Light, Medium, Heavy:
+			if body == nil || worn[i].BaseClass > body.BaseClass {
+				body = &worn[i]
+			}
+		}
+	}
+
+	dexterity := a.DexterityModifier
+
+	var base int
+	if body != nil {
+		switch body.Type {
+		case Light:
+			base = body.BaseClass + dexterity
+		case Medium:
+			if dexterity > mediumDexterityCap {
+				dexterity = mediumDexterityCap
+			}
+			base = body.BaseClass + dexterity
+		case Heavy:
+			base = body.BaseClass
+		}
+	} else {
+		base = unarmoredClass + dexterity
+		if d.MageArmor && mageArmorClass+dexterity > base {
+			base = mageArmorClass + dexterity
+		}
+		if c[classes.Barbarian] > 0 {
+			if v := unarmoredClass + dexterity + a.ConstitutionModifier; v > base {
+				base = v
+			}
+		}
+		if c[classes.Monk] > 0 && shield == 0 {
+			if v := unarmoredClass + dexterity + a.WisdomModifier; v > base {
+				base = v
+			}
+		}
+	}
+
+	return base + shield + d.Bonus
+}
@@ -0,0 +1,47 @@
+package armor
+
+import (
+	"testing"
+
+	"github.com/aakordas/creature_manager/pkg/abilities"
+	"github.com/aakordas/creature_manager/pkg/classes"
+)
+
+func TestClass(t *testing.T) {
+	var (
+		leather   = Armor{Type: Light, BaseClass: 11}
+		halfPlate = Armor{Type: Medium, BaseClass: 15}
+		plate     = Armor{Type: Heavy, BaseClass: 18}
+		shield    = Armor{Type: Shield, BaseClass: 2}
+		nimble    = abilities.Abilities{DexterityModifier: 4, ConstitutionModifier: 3, WisdomModifier: 2}
+	)
+
+	tests := []struct {
+		name    string
+		worn    []Armor
+		classes classes.Classes
+		defense Defense
+		want    int
+	}{
+		{"Unarmored", nil, nil, Defense{}, 14},
+		{"Light armor", []Armor{leather}, nil, Defense{}, 15},
+		{"Medium armor caps dexterity", []Armor{halfPlate}, nil, Defense{}, 17},
+		{"Heavy armor ignores dexterity", []Armor{plate}, nil, Defense{}, 18},
+		{"Shield", []Armor{plate, shield}, nil, Defense{}, 20},
+		{"Flat bonus", []Armor{plate}, nil, Defense{Bonus: 1}, 19},
+		{"Mage armor", nil, nil, Defense{MageArmor: true}, 17},
+		{"Mage armor under armor", []Armor{leather}, nil, Defense{MageArmor: true}, 15},
+		{"Barbarian", nil, classes.Classes{classes.Barbarian: 1}, Defense{}, 17},
+		{"Barbarian with shield", []Armor{shield}, classes.Classes{classes.Barbarian: 1}, Defense{}, 19},
+		{"Monk", nil, classes.Classes{classes.Monk: 1}, Defense{}, 16},
+		{"Monk with shield", []Armor{shield}, classes.Classes{classes.Monk: 1}, Defense{}, 16},
+		{"Armored barbarian", []Armor{leather}, classes.Classes{classes.Barbarian: 1}, Defense{}, 15},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Class(tt.worn, nimble, tt.classes, tt.defense); got != tt.want {
+				t.Errorf("Class() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
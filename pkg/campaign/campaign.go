@@ -0,0 +1,157 @@
+package campaign
+
+import (
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/creature"
+)
+
+const (
+	// DungeonMaster means the user who runs the campaign. They can see and
+	// change everything in it.
+	DungeonMaster = "dungeon_master"
+	// Player means a user who plays in the campaign. They can see the
+	// creatures of the campaign, apart from the hidden statistics of the
+	// monsters and NPCs, and change only their own characters.
+	Player = "player"
+	// Spectator means a user who watches the campaign, without changing
+	// anything in it.
+	Spectator = "spectator"
+)
+
+// Campaign models a campaign, which groups creatures and parties. The names of
+// the creatures are unique only within their campaign.
+type Campaign struct {
+	Name          string            `json:"name" bson:"name"`
+	Description   string            `json:"description" bson:"description"`
+	DungeonMaster string            `json:"dungeon_master" bson:"dungeon_master"`   // The user who runs the campaign and can change everything in it.
+	Users         map[string]string `json:"users,omitempty" bson:"users,omitempty"` // Maps the other users of the campaign to their role.
+}
+
+// Party models a group of creatures of a campaign.
+type Party struct {
+	Name     string   `json:"name" bson:"name"`
+	Campaign string   `json:"campaign" bson:"campaign"`
+	Members  []string `json:"members" bson:"members"` // The names of the creatures in the party.
+}
+
+// Member holds the statistics of a party member that matter to the party as a
+// whole.
+type Member struct {
+	Name              string `json:"name" bson:"name"`
+	Level             int    `json:"level" bson:"level"`
+	PassivePerception int    `json:"passive_perception" bson:"passive_perception"`
+	ExperiencePoints  int    `json:"experience_points" bson:"experience_points"`
+}
+
+// Summary holds the statistics of a party.
+type Summary struct {
+	Name    string   `json:"name" bson:"name"`
+	Members []Member `json:"members" bson:"members"`
+
+	// The highest passive Perception notices hidden threats for the whole
+	// party, while the lowest is the one to surprise.
+	HighestPassivePerception int `json:"highest_passive_perception" bson:"highest_passive_perception"`
+	LowestPassivePerception  int `json:"lowest_passive_perception" bson:"lowest_passive_perception"`
+
+	ExperiencePoints int `json:"experience_points" bson:"experience_points"` // The total experience points of the members.
+	AverageLevel     int `json:"average_level" bson:"average_level"`         // Rounded down.
+}
+
+// Error is the error that gets returned when a party operation is invalid.
+type Error struct {
+	Message string
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
+
+var (
+	errMemberExists  = Error{"The creature is already a member of the party."}
+	errMissingMember = Error{"The creature is not a member of the party."}
+)
+
+// ValidRole checks whether the provided role can be given to a user of a
+// campaign. There is only one dungeon master per campaign.
+func ValidRole(role string) bool {
+	return role == Player || role == Spectator
+}
+
+// Role returns the role of the user in the campaign, or an empty one if they
+// take no part in it.
+func (c Campaign) Role(user string) string {
+	if user == "" {
+		return ""
+	}
+	if user == c.DungeonMaster {
+		return DungeonMaster
+	}
+
+	return c.Users[user]
+}
+
+// find returns the index of the member with the provided name, or -1 if there
+// is no such member.
+func (p *Party) find(name string) int {
+	for i, m := range p.Members {
+		if strings.EqualFold(m, name) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Add adds the creature with the provided name to the party.
+func (p *Party) Add(name string) error {
+	if p.find(name) >= 0 {
+		return errMemberExists
+	}
+
+	p.Members = append(p.Members, name)
+	return nil
+}
+
+// Remove removes the creature with the provided name from the party.
+func (p *Party) Remove(name string) error {
+	i := p.find(name)
+	if i < 0 {
+		return errMissingMember
+	}
+
+	p.Members = append(p.Members[:i], p.Members[i+1:]...)
+	return nil
+}
+
+// Summarize returns the statistics of the party, given its members.
+func Summarize(name string, members []creature.Creature) Summary {
+	s := Summary{
+		Name:    name,
+		Members: make([]Member, 0, len(members)),
+	}
+
+	var levels int
+	for i, c := range members {
+		s.Members = append(s.Members, Member{
+			Name:              c.Name,
+			Level:             c.Level,
+			PassivePerception: c.PassivePerception,
+			ExperiencePoints:  c.ExperiencePoints,
+		})
+
+		if i == 0 || c.PassivePerception > s.HighestPassivePerception {
+			s.HighestPassivePerception = c.PassivePerception
+		}
+		if i == 0 || c.PassivePerception < s.LowestPassivePerception {
+			s.LowestPassivePerception = c.PassivePerception
+		}
+		s.ExperiencePoints += c.ExperiencePoints
+		levels += c.Level
+	}
+	if len(members) > 0 {
+		s.AverageLevel = levels / len(members)
+	}
+
+	return s
+}
@@ -0,0 +1,101 @@
+package campaign
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aakordas/creature_manager/pkg/creature"
+)
+
+func TestParty_AddRemove(t *testing.T) {
+	p := Party{Name: "Fellowship", Campaign: "Middle Earth"}
+
+	if err := p.Add("Frodo"); err != nil {
+		t.Fatalf("Party.Add() error = %v", err)
+	}
+	if err := p.Add("Sam"); err != nil {
+		t.Fatalf("Party.Add() error = %v", err)
+	}
+	if err := p.Add("frodo"); err != errMemberExists {
+		t.Errorf("Party.Add() error = %v, want %v", err, errMemberExists)
+	}
+
+	if err := p.Remove("FRODO"); err != nil {
+		t.Fatalf("Party.Remove() error = %v", err)
+	}
+	if err := p.Remove("Frodo"); err != errMissingMember {
+		t.Errorf("Party.Remove() error = %v, want %v", err, errMissingMember)
+	}
+
+	if want := []string{"Sam"}; !reflect.DeepEqual(p.Members, want) {
+		t.Errorf("Party.Members = %v, want %v", p.Members, want)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name    string
+		members []creature.Creature
+		want    Summary
+	}{
+		{
+			name:    "empty party",
+			members: nil,
+			want:    Summary{Name: "empty party", Members: []Member{}},
+		},
+		{
+			name: "three members",
+			members: []creature.Creature{
+				{Name: "Aragorn", Level: 5, PassivePerception: 15, ExperiencePoints: 6500},
+				{Name: "Gimli", Level: 4, PassivePerception: 11, ExperiencePoints: 2700},
+				{Name: "Legolas", Level: 5, PassivePerception: 17, ExperiencePoints: 6800},
+			},
+			want: Summary{
+				Name: "three members",
+				Members: []Member{
+					{"Aragorn", 5, 15, 6500},
+					{"Gimli", 4, 11, 2700},
+					{"Legolas", 5, 17, 6800},
+				},
+				HighestPassivePerception: 17,
+				LowestPassivePerception:  11,
+				ExperiencePoints:         16000,
+				AverageLevel:             4,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Summarize(tt.name, tt.members); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Summarize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCampaign_Role(t *testing.T) {
+	c := Campaign{
+		Name:          "Middle Earth",
+		DungeonMaster: "tolkien",
+		Users: map[string]string{
+			"frodo": Player,
+			"bilbo": Spectator,
+		},
+	}
+
+	tests := []struct {
+		user string
+		want string
+	}{
+		{"tolkien", DungeonMaster},
+		{"frodo", Player},
+		{"bilbo", Spectator},
+		{"sauron", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := c.Role(tt.user); got != tt.want {
+			t.Errorf("Campaign.Role(%q) = %q, want %q", tt.user, got, tt.want)
+		}
+	}
+}
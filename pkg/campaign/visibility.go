@@ -0,0 +1,72 @@
+package campaign
+
+import (
+	"github.com/aakordas/creature_manager/pkg/conditions"
+	"github.com/aakordas/creature_manager/pkg/creature"
+)
+
+const (
+	// Unharmed means a creature at its maximum hit points.
+	Unharmed = "unharmed"
+	// Injured means a creature that lost less than half of its hit points.
+	Injured = "injured"
+	// Bloodied means a creature at half of its hit points or below.
+	Bloodied = "bloodied"
+	// Down means a creature at zero hit points.
+	Down = "down"
+)
+
+// Concealed models what the players of a campaign see of a monster or an NPC.
+// Its statistics are hidden and its hit points are only described.
+type Concealed struct {
+	Name       string                 `json:"name" bson:"name"`
+	Kind       string                 `json:"kind" bson:"kind"`
+	Campaign   string                 `json:"campaign" bson:"campaign"`
+	Template   string                 `json:"template,omitempty" bson:"template,omitempty"`
+	Size       string                 `json:"size,omitempty" bson:"size,omitempty"`
+	Type       string                 `json:"type,omitempty" bson:"type,omitempty"`
+	Conditions []conditions.Condition `json:"conditions,omitempty" bson:"conditions,omitempty"`
+	Health     string                 `json:"health" bson:"health"`
+}
+
+// Health describes how hurt the creature looks, without revealing its hit
+// points.
+func Health(c creature.Creature) string {
+	switch {
+	case c.MaximumHitPoints <= 0:
+		return Unharmed
+	case c.CurrentHitPoints <= 0:
+		return Down
+	case 2*c.CurrentHitPoints <= c.MaximumHitPoints:
+		return Bloodied
+	case c.CurrentHitPoints < c.MaximumHitPoints:
+		return Injured
+	default:
+		return Unharmed
+	}
+}
+
+// Conceals checks whether the statistics of the creature are hidden from a
+// user with the provided role. Only the dungeon master sees the statistics of
+// the monsters and NPCs of a campaign.
+func Conceals(role string, c creature.Creature) bool {
+	if c.Campaign == "" || role == DungeonMaster {
+		return false
+	}
+
+	return c.Kind == creature.Monster || c.Kind == creature.NPC
+}
+
+// Conceal returns what the players of a campaign see of the creature.
+func Conceal(c creature.Creature) Concealed {
+	return Concealed{
+		Name:       c.Name,
+		Kind:       c.Kind,
+		Campaign:   c.Campaign,
+		Template:   c.Template,
+		Size:       c.Size,
+		Type:       c.Type,
+		Conditions: c.Conditions,
+		Health:     Health(c),
+	}
+}
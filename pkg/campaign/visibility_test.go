@@ -0,0 +1,59 @@
+package campaign
+
+import (
+	"testing"
+
+	"github.com/aakordas/creature_manager/pkg/creature"
+)
+
+func TestHealth(t *testing.T) {
+	tests := []struct {
+		name    string
+		current int
+		maximum int
+		want    string
+	}{
+		{"Full hit points", 7, 7, Unharmed},
+		{"Scratched", 6, 7, Injured},
+		{"Just above half", 4, 7, Injured},
+		{"Half", 5, 10, Bloodied},
+		{"One hit point", 1, 7, Bloodied},
+		{"Zero hit points", 0, 7, Down},
+		{"No maximum", 0, 0, Unharmed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := creature.Creature{CurrentHitPoints: tt.current, MaximumHitPoints: tt.maximum}
+			if got := Health(c); got != tt.want {
+				t.Errorf("Health() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConceals(t *testing.T) {
+	goblin := creature.Creature{Name: "Goblin", Kind: creature.Monster, Campaign: "Phandelver"}
+	sildar := creature.Creature{Name: "Sildar", Kind: creature.NPC, Campaign: "Phandelver"}
+	wizard := creature.Creature{Name: "Wizard", Kind: creature.Player, Campaign: "Phandelver"}
+	stray := creature.Creature{Name: "Wolf", Kind: creature.Monster}
+
+	tests := []struct {
+		name string
+		role string
+		c    creature.Creature
+		want bool
+	}{
+		{"Monster for the dungeon master", DungeonMaster, goblin, false},
+		{"Monster for a player", Player, goblin, true},
+		{"NPC for a spectator", Spectator, sildar, true},
+		{"Player for a player", Player, wizard, false},
+		{"Monster outside of any campaign", "", stray, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Conceals(tt.role, tt.c); got != tt.want {
+				t.Errorf("Conceals() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
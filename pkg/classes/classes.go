@@ -0,0 +1,53 @@
+package classes
+
+const (
+	// Barbarian means the Barbarian class.
+	Barbarian = "barbarian"
+	// Bard means the Bard class.
+	Bard = "bard"
+	// Cleric means the Cleric class.
+	Cleric = "cleric"
+	// Druid means the Druid class.
+	Druid = "druid"
+	// Fighter means the Fighter class.
+	Fighter = "fighter"
+	// Monk means the Monk class.
+	Monk = "monk"
+	// Paladin means the Paladin class.
+	Paladin = "paladin"
+	// Ranger means the Ranger class.
+	Ranger = "ranger"
+	// Rogue means the Rogue class.
+	Rogue = "rogue"
+	// Sorcerer means the Sorcerer class.
+	Sorcerer = "sorcerer"
+	// Warlock means the Warlock class.
+	Warlock = "warlock"
+	// Wizard means the Wizard class.
+	Wizard = "wizard"
+)
+
+// HitDie maps a class to the number of sides of its hit die.
+var HitDie = map[string]int{
+	Barbarian: 12,
+	Bard:      8,
+	Cleric:    8,
+	Druid:     8,
+	Fighter:   10,
+	Monk:      8,
+	Paladin:   10,
+	Ranger:    10,
+	Rogue:     8,
+	Sorcerer:  6,
+	Warlock:   8,
+	Wizard:    6,
+}
+
+// Classes maps the classes a creature has levels in to the number of levels.
+type Classes map[string]int
+
+// Valid checks whether the provided value is a known class.
+func Valid(c string) bool {
+	_, ok := HitDie[c]
+	return ok
+}
@@ -0,0 +1,78 @@
+package conditions
+
+import "strings"
+
+const (
+	// Blinded means the creature cannot see.
+	Blinded = "blinded"
+	// Charmed means the creature cannot attack its charmer.
+	Charmed = "charmed"
+	// Deafened means the creature cannot hear.
+	Deafened = "deafened"
+	// Exhaustion means the creature suffers from exhaustion.
+	Exhaustion = "exhaustion"
+	// Frightened means the creature has disadvantage while it can see the
+	// source of its fear.
+	Frightened = "frightened"
+	// Grappled means the speed of the creature becomes 0.
+	Grappled = "grappled"
+	// Incapacitated means the creature cannot take actions or reactions.
+	Incapacitated = "incapacitated"
+	// Invisible means the creature cannot be seen without magic.
+	Invisible = "invisible"
+	// Paralyzed means the creature is incapacitated and cannot move or speak.
+	Paralyzed = "paralyzed"
+	// Petrified means the creature is turned into stone.
+	Petrified = "petrified"
+	// Poisoned means the creature has disadvantage on attacks and ability
+	// checks.
+	Poisoned = "poisoned"
+	// Prone means the creature is lying on the ground.
+	Prone = "prone"
+	// Restrained means the speed of the creature becomes 0 and it has
+	// disadvantage on attacks.
+	Restrained = "restrained"
+	// Stunned means the creature is incapacitated and can barely speak.
+	Stunned = "stunned"
+	// Unconscious means the creature is incapacitated and unaware of its
+	// surroundings.
+	Unconscious = "unconscious"
+)
+
+// Valid checks if the provided value is a valid condition.
+func Valid(c string) bool {
+	switch strings.ToLower(c) {
+	case Blinded, Charmed, Deafened, Exhaustion, Frightened, Grappled,
+		Incapacitated, Invisible, Paralyzed, Petrified, Poisoned, Prone,
+		Restrained, Stunned, Unconscious:
+		return true
+	default:
+		return false
+	}
+}
+
+// Condition is a condition a creature suffers from.
+type Condition struct {
+	Name     string `json:"name" bson:"name"`
+	Duration int    `json:"duration,omitempty" bson:"duration,omitempty"` // The turns of the creature it lasts. Zero means until removed.
+}
+
+// Tick counts down the duration of the provided conditions by one turn. It
+// returns the conditions that still last and the ones that expired.
+func Tick(cs []Condition) (remaining, expired []Condition) {
+	for _, c := range cs {
+		if c.Duration == 0 {
+			remaining = append(remaining, c)
+			continue
+		}
+
+		c.Duration--
+		if c.Duration == 0 {
+			expired = append(expired, c)
+		} else {
+			remaining = append(remaining, c)
+		}
+	}
+
+	return remaining, expired
+}
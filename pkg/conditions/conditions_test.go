@@ -0,0 +1,28 @@
+package conditions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTick(t *testing.T) {
+	cs := []Condition{
+		{Name: Prone},
+		{Name: Stunned, Duration: 1},
+		{Name: Frightened, Duration: 3},
+	}
+
+	remaining, expired := Tick(cs)
+
+	wantRemaining := []Condition{{Name: Prone}, {Name: Frightened, Duration: 2}}
+	wantExpired := []Condition{{Name: Stunned}}
+	if !reflect.DeepEqual(remaining, wantRemaining) {
+		t.Errorf("Tick() remaining = %v, want %v", remaining, wantRemaining)
+	}
+	if !reflect.DeepEqual(expired, wantExpired) {
+		t.Errorf("Tick() expired = %v, want %v", expired, wantExpired)
+	}
+	if cs[2].Duration != 3 {
+		t.Errorf("Tick() changed the provided conditions: %v", cs)
+	}
+}
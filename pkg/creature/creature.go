@@ -2,17 +2,38 @@ package creature
 
 import (
 	"github.com/aakordas/creature_manager/pkg/abilities"
+	"github.com/aakordas/creature_manager/pkg/armor"
+	"github.com/aakordas/creature_manager/pkg/classes"
+	"github.com/aakordas/creature_manager/pkg/conditions"
+	"github.com/aakordas/creature_manager/pkg/damage"
+	"github.com/aakordas/creature_manager/pkg/inventory"
 	"github.com/aakordas/creature_manager/pkg/saves"
 	"github.com/aakordas/creature_manager/pkg/skills"
+	"github.com/aakordas/creature_manager/pkg/spellcasting"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Creature models a creature of the game, let that be a player, a monster or
 // some non playable character.
 type Creature struct {
-	Name string `json:"name" bson:"name"`
+	ID      primitive.ObjectID `json:"id" bson:"_id,omitempty"` // Stays the same when the creature gets renamed.
+	Name    string             `json:"name" bson:"name"`        // Unique in the campaign of the creature.
+	Version int                `json:"version" bson:"version"`  // Incremented on every change.
+	Kind    string             `json:"kind" bson:"kind"`        // Whether the creature is a player, a monster or an NPC.
 
-	CurrentHitPoints int `json:"hit_points" bson:"hit_points"`
-	Level            int `json:"level" bson:"level"`
+	Campaign string `json:"campaign,omitempty" bson:"campaign,omitempty"` // The campaign the creature belongs to, if any.
+	Owner    string `json:"owner,omitempty" bson:"owner,omitempty"`       // The user who created the creature, if any.
+
+	Template string `json:"template,omitempty" bson:"template,omitempty"` // The name of the creature this one was spawned from.
+
+	CurrentHitPoints int  `json:"hit_points" bson:"hit_points"`
+	MaximumHitPoints int  `json:"maximum_hit_points" bson:"maximum_hit_points"`
+	Level            int  `json:"level" bson:"level"`
+	ExperiencePoints int  `json:"experience_points" bson:"experience_points"`
+	Milestone        bool `json:"milestone" bson:"milestone"` // Whether the creature levels up by milestones instead of experience.
+
+	classes.Classes `json:"classes,omitempty" bson:"classes,omitempty"`
+	HitDice         map[string]int `json:"hit_dice,omitempty" bson:"hit_dice,omitempty"` // The unspent hit dice of each class.
 
 	abilities.Abilities `json:"abilities" bson:"abilities"`
 	skills.Skills       `json:"skills,omitempty" bson:"skills,omitempty"`
@@ -21,9 +42,58 @@ type Creature struct {
 	ProficiencyBonus int `json:"proficiency_bonus" bson:"proficiency_bonus"`
 	ArmorClass       int `json:"armor_class" bson:"armor_class"`
 
+	armor.Defense `json:"defense" bson:"defense"`
+
+	damage.Defenses `json:"damage_defenses" bson:"damage_defenses"`
+
+	Conditions []conditions.Condition `json:"conditions,omitempty" bson:"conditions,omitempty"`
+
 	PassivePerception int `json:"passive_perception" bson:"passive_perception"` // FIXME: Include any other bonuses.
+
+	WeaponProficiencies []string `json:"weapon_proficiencies,omitempty" bson:"weapon_proficiencies,omitempty"` // Weapon categories or names.
+
+	Exhaustion int                  `json:"exhaustion" bson:"exhaustion"`
+	Resources  map[string]*Resource `json:"resources,omitempty" bson:"resources,omitempty"`
+
+	Spellcasting *spellcasting.Spellcasting `json:"spellcasting,omitempty" bson:"spellcasting,omitempty"`
+
+	inventory.Inventory `json:"inventory" bson:"inventory"`
+
+	Size          string         `json:"size,omitempty" bson:"size,omitempty"`
+	Speed         map[string]int `json:"speed,omitempty" bson:"speed,omitempty"` // The speed of each movement mode, in feet.
+	Senses        string         `json:"senses,omitempty" bson:"senses,omitempty"`
+	Languages     string         `json:"languages,omitempty" bson:"languages,omitempty"`
+	HitPointsDice string         `json:"hit_points_dice,omitempty" bson:"hit_points_dice,omitempty"` // The dice the hit points of a monster are rolled with, like 2d6.
+	Traits        []Action       `json:"traits,omitempty" bson:"traits,omitempty"`
+	Actions       []Action       `json:"actions,omitempty" bson:"actions,omitempty"`
+	Reactions     []Action       `json:"reactions,omitempty" bson:"reactions,omitempty"`
+
+	ChallengeRating  string   `json:"challenge_rating,omitempty" bson:"challenge_rating,omitempty"`
+	ExperienceValue  int      `json:"experience_value,omitempty" bson:"experience_value,omitempty"` // The experience points the creature is worth.
+	Type             string   `json:"type,omitempty" bson:"type,omitempty"`                         // Like beast, humanoid or undead.
+	Alignment        string   `json:"alignment,omitempty" bson:"alignment,omitempty"`
+	Multiattack      string   `json:"multiattack,omitempty" bson:"multiattack,omitempty"` // The attacks the creature makes with its Multiattack action.
+	LegendaryActions []Action `json:"legendary_actions,omitempty" bson:"legendary_actions,omitempty"`
 }
 
+// Resource is a limited use feature of a creature, like Rage or Channel
+// Divinity, that gets recharged after resting.
+type Resource struct {
+	Current  int    `json:"current" bson:"current"`
+	Maximum  int    `json:"maximum" bson:"maximum"`
+	Recharge string `json:"recharge" bson:"recharge"` // The kind of rest that recharges the resource.
+}
+
+const (
+	// ShortRest means the resource recharges after a short or a long rest.
+	ShortRest = "short"
+	// LongRest means the resource recharges only after a long rest.
+	LongRest = "long"
+)
+
+// maximumExhaustion indicates the maximum exhaustion level of a creature.
+const maximumExhaustion = 6
+
 // minimumLevel indicates the minimum level a creature can have.
 const minimumLevel = 1
 
@@ -56,9 +126,19 @@ var ProficiencyBonusPerLevel = map[int]int{
 
 // OutOfRange checks whether the provided value is within the acceptable level range.
 func OutOfRange(v int) bool {
-        if v >= minimumLevel && v <= maximumLevel {
-                return false
-        }
+	if v >= minimumLevel && v <= maximumLevel {
+		return false
+	}
+
+	return true
+}
+
+// ExhaustionOutOfRange checks whether the provided value is within the
+// acceptable exhaustion range.
+func ExhaustionOutOfRange(v int) bool {
+	if v >= 0 && v <= maximumExhaustion {
+		return false
+	}
 
-        return true
+	return true
 }
@@ -0,0 +1,87 @@
+package creature
+
+import "strconv"
+
+const (
+	// Player means a creature controlled by a player.
+	Player = "player"
+	// Monster means a creature controlled by the game master, usually hostile.
+	Monster = "monster"
+	// NPC means a non playable character controlled by the game master.
+	NPC = "npc"
+)
+
+// Action is an action a creature can take, like a legendary action.
+type Action struct {
+	Name        string `json:"name" bson:"name"`
+	Description string `json:"description" bson:"description"`
+	Cost        int    `json:"cost,omitempty" bson:"cost,omitempty"` // The legendary actions it takes, if more than one.
+}
+
+// ExperiencePerChallengeRating maps a challenge rating to the experience points
+// a creature of that rating is worth.
+var ExperiencePerChallengeRating = map[string]int{
+	"0":   10,
+	"1/8": 25,
+	"1/4": 50,
+	"1/2": 100,
+	"1":   200,
+	"2":   450,
+	"3":   700,
+	"4":   1100,
+	"5":   1800,
+	"6":   2300,
+	"7":   2900,
+	"8":   3900,
+	"9":   5000,
+	"10":  5900,
+	"11":  7200,
+	"12":  8400,
+	"13":  10000,
+	"14":  11500,
+	"15":  13000,
+	"16":  15000,
+	"17":  18000,
+	"18":  20000,
+	"19":  22000,
+	"20":  25000,
+	"21":  33000,
+	"22":  41000,
+	"23":  50000,
+	"24":  62000,
+	"25":  75000,
+	"26":  90000,
+	"27":  105000,
+	"28":  120000,
+	"29":  135000,
+	"30":  155000,
+}
+
+// ValidKind checks if the provided value is a valid creature kind.
+func ValidKind(k string) bool {
+	switch k {
+	case Player, Monster, NPC:
+		return true
+	default:
+		return false
+	}
+}
+
+// ValidChallengeRating checks if the provided value is a valid challenge
+// rating.
+func ValidChallengeRating(cr string) bool {
+	_, ok := ExperiencePerChallengeRating[cr]
+	return ok
+}
+
+// ProficiencyBonusForChallengeRating returns the proficiency bonus of a
+// creature of the provided challenge rating. Ratings below 1 use the bonus of
+// rating 0.
+func ProficiencyBonusForChallengeRating(cr string) int {
+	rating, err := strconv.Atoi(cr)
+	if err != nil || rating < 1 {
+		return ProficiencyBonusPerLevel[minimumLevel]
+	}
+
+	return 2 + (rating-1)/4
+}
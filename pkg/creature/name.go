@@ -0,0 +1,30 @@
+package creature
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaximumNameLength indicates the maximum number of characters in the name of a
+// creature.
+const MaximumNameLength = 64
+
+// ValidName checks whether the provided name can be the name of a creature.
+// Names can have letters of any script, digits, punctuation and inner spaces,
+// like "Drizzt Do'Urden", "Zoë" or "Goblin 2", but no slashes, since they are
+// part of the paths of the creatures.
+func ValidName(name string) bool {
+	if name == "" || name != strings.TrimSpace(name) ||
+		!utf8.ValidString(name) || utf8.RuneCountInString(name) > MaximumNameLength {
+		return false
+	}
+
+	for _, r := range name {
+		if r == '/' || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,32 @@
+package creature
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"Merry", true},
+		{"Drizzt Do'Urden", true},
+		{"Zoë", true},
+		{"Goblin 2", true},
+		{"Ser Gregor, the Mountain", true},
+		{"", false},
+		{" Merry", false},
+		{"Merry ", false},
+		{"Merry/Pippin", false},
+		{"Merry\tPippin", false},
+		{"\xff", false},
+		{strings.Repeat("a", MaximumNameLength), true},
+		{strings.Repeat("a", MaximumNameLength+1), false},
+	}
+	for _, tt := range tests {
+		if got := ValidName(tt.name); got != tt.want {
+			t.Errorf("ValidName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package creature
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/spellcasting"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Instance returns a new creature of the template with the provided name and
+// hit points. The instance keeps the statistics of the template, but starts
+// with a fresh state of its own: no conditions or exhaustion, every resource
+// and spell slot available and a version of its own. The instance shares the
+// rest of its maps and slices with the template, so it is meant to be stored
+// right away.
+func (c Creature) Instance(name string, hitPoints int) Creature {
+	instance := c
+	instance.ID = primitive.NilObjectID
+	instance.Name = name
+	instance.Version = 0
+	instance.Template = c.Name
+	instance.CurrentHitPoints = hitPoints
+	instance.MaximumHitPoints = hitPoints
+	instance.Conditions = nil
+	instance.Exhaustion = 0
+
+	if c.Resources != nil {
+		instance.Resources = make(map[string]*Resource, len(c.Resources))
+		for name, r := range c.Resources {
+			fresh := *r
+			fresh.Current = fresh.Maximum
+			instance.Resources[name] = &fresh
+		}
+	}
+
+	if c.Spellcasting != nil {
+		s := *c.Spellcasting
+		s.Slots = append([]spellcasting.Slots(nil), s.Slots...)
+		if s.PactSlots != nil {
+			pact := *s.PactSlots
+			s.PactSlots = &pact
+		}
+		s.Restore(true)
+		s.Concentration = ""
+		instance.Spellcasting = &s
+	}
+
+	return instance
+}
+
+// InstanceNumber returns the number of the instance of the template with the
+// provided name, like 3 for "Goblin 3", or 0 if the name is not of this form.
+func InstanceNumber(template, name string) int {
+	suffix := strings.TrimPrefix(name, template+" ")
+	if suffix == name {
+		return 0
+	}
+
+	n, err := strconv.Atoi(suffix)
+	if err != nil || n < 1 {
+		return 0
+	}
+
+	return n
+}
+
+// InstanceNames returns the names of count new instances of the template,
+// numbered after any existing ones, like "Goblin 3" and "Goblin 4" when
+// "Goblin 1" and "Goblin 2" exist.
+func InstanceNames(template string, existing []string, count int) []string {
+	last := 0
+	for _, name := range existing {
+		if n := InstanceNumber(template, name); n > last {
+			last = n
+		}
+	}
+
+	names := make([]string, 0, count)
+	for i := 1; i <= count; i++ {
+		names = append(names, template+" "+strconv.Itoa(last+i))
+	}
+
+	return names
+}
@@ -0,0 +1,101 @@
+package creature
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aakordas/creature_manager/pkg/conditions"
+	"github.com/aakordas/creature_manager/pkg/spellcasting"
+)
+
+func TestInstanceNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		count    int
+		want     []string
+	}{
+		{"First instances", nil, 3, []string{"Goblin 1", "Goblin 2", "Goblin 3"}},
+		{"Continue numbering", []string{"Goblin 1", "Goblin 2"}, 2, []string{"Goblin 3", "Goblin 4"}},
+		{"Continue after gaps", []string{"Goblin 4", "Goblin 2"}, 1, []string{"Goblin 5"}},
+		{"Ignore other names", []string{"Goblin Boss", "Goblin", "Goblin 1b"}, 1, []string{"Goblin 1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InstanceNames("Goblin", tt.existing, tt.count); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InstanceNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreature_Instance(t *testing.T) {
+	template := Creature{
+		Name:             "Goblin",
+		Kind:             Monster,
+		CurrentHitPoints: 3,
+		MaximumHitPoints: 7,
+		ArmorClass:       15,
+		Exhaustion:       2,
+	}
+
+	got := template.Instance("Goblin 1", 9)
+	want := Creature{
+		Name:             "Goblin 1",
+		Kind:             Monster,
+		Template:         "Goblin",
+		CurrentHitPoints: 9,
+		MaximumHitPoints: 9,
+		ArmorClass:       15,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Creature.Instance() = %+v, want %+v", got, want)
+	}
+	if template.Name != "Goblin" || template.CurrentHitPoints != 3 {
+		t.Errorf("Creature.Instance() changed the template: %+v", template)
+	}
+}
+
+func TestCreature_InstanceState(t *testing.T) {
+	template := Creature{
+		Name:       "Goblin Shaman",
+		Version:    4,
+		Conditions: []conditions.Condition{{Name: conditions.Poisoned, Duration: 2}},
+		Resources: map[string]*Resource{
+			"nimble_escape": {Current: 0, Maximum: 2, Recharge: ShortRest},
+		},
+		Spellcasting: &spellcasting.Spellcasting{
+			Ability:       "wisdom",
+			Slots:         []spellcasting.Slots{{Level: 1, Maximum: 2, Expended: 2}},
+			PactSlots:     &spellcasting.Slots{Level: 1, Maximum: 1, Expended: 1},
+			Known:         []string{"shield"},
+			Concentration: "bless",
+		},
+	}
+
+	got := template.Instance("Goblin Shaman 1", 5)
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"Version", got.Version, 0},
+		{"Conditions", got.Conditions, []conditions.Condition(nil)},
+		{"Resources", *got.Resources["nimble_escape"], Resource{Current: 2, Maximum: 2, Recharge: ShortRest}},
+		{"Slots", got.Spellcasting.Slots, []spellcasting.Slots{{Level: 1, Maximum: 2}}},
+		{"Pact slots", *got.Spellcasting.PactSlots, spellcasting.Slots{Level: 1, Maximum: 1}},
+		{"Concentration", got.Spellcasting.Concentration, ""},
+		{"Known spells", got.Spellcasting.Known, []string{"shield"}},
+		{"Template resources", template.Resources["nimble_escape"].Current, 0},
+		{"Template slots", template.Spellcasting.Slots[0].Expended, 2},
+		{"Template pact slots", template.Spellcasting.PactSlots.Expended, 1},
+		{"Template concentration", template.Spellcasting.Concentration, "bless"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("Creature.Instance() %v = %+v, want %+v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package damage
+
+import "strings"
+
+const (
+	// Acid means acid damage.
+	Acid = "acid"
+	// Bludgeoning means bludgeoning damage.
+	Bludgeoning = "bludgeoning"
+	// Cold means cold damage.
+	Cold = "cold"
+	// Fire means fire damage.
+	Fire = "fire"
+	// Force means force damage.
+	Force = "force"
+	// Lightning means lightning damage.
+	Lightning = "lightning"
+	// Necrotic means necrotic damage.
+	Necrotic = "necrotic"
+	// Piercing means piercing damage.
+	Piercing = "piercing"
+	// Poison means poison damage.
+	Poison = "poison"
+	// Psychic means psychic damage.
+	Psychic = "psychic"
+	// Radiant means radiant damage.
+	Radiant = "radiant"
+	// Slashing means slashing damage.
+	Slashing = "slashing"
+	// Thunder means thunder damage.
+	Thunder = "thunder"
+)
+
+// ValidType checks if the provided value is a valid damage type.
+func ValidType(t string) bool {
+	switch strings.ToLower(t) {
+	case Acid, Bludgeoning, Cold, Fire, Force, Lightning, Necrotic,
+		Piercing, Poison, Psychic, Radiant, Slashing, Thunder:
+		return true
+	default:
+		return false
+	}
+}
+
+// Defenses holds the damage types and conditions a creature is resistant,
+// vulnerable or immune to.
+type Defenses struct {
+	Resistances         []string `json:"resistances,omitempty" bson:"resistances,omitempty"`
+	Vulnerabilities     []string `json:"vulnerabilities,omitempty" bson:"vulnerabilities,omitempty"`
+	Immunities          []string `json:"immunities,omitempty" bson:"immunities,omitempty"`
+	ConditionImmunities []string `json:"condition_immunities,omitempty" bson:"condition_immunities,omitempty"`
+}
+
+// Breakdown explains how much of some damage a creature took and why.
+type Breakdown struct {
+	Type       string `json:"type,omitempty" bson:"type,omitempty"`
+	Amount     int    `json:"amount" bson:"amount"` // The damage before any defenses.
+	Resisted   bool   `json:"resisted,omitempty" bson:"resisted,omitempty"`
+	Vulnerable bool   `json:"vulnerable,omitempty" bson:"vulnerable,omitempty"`
+	Immune     bool   `json:"immune,omitempty" bson:"immune,omitempty"`
+	Taken      int    `json:"taken" bson:"taken"` // The damage after any defenses.
+}
+
+// contains checks if the list contains the provided value, ignoring case.
+func contains(list []string, v string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Resistant checks if the defenses include resistance to the damage type.
+func (d Defenses) Resistant(t string) bool {
+	return contains(d.Resistances, t)
+}
+
+// Vulnerable checks if the defenses include vulnerability to the damage type.
+func (d Defenses) Vulnerable(t string) bool {
+	return contains(d.Vulnerabilities, t)
+}
+
+// Immune checks if the defenses include immunity to the damage type.
+func (d Defenses) Immune(t string) bool {
+	return contains(d.Immunities, t)
+}
+
+// ImmuneToCondition checks if the defenses include immunity to the condition.
+func (d Defenses) ImmuneToCondition(c string) bool {
+	return contains(d.ConditionImmunities, c)
+}
+
+// Apply calculates how much of the provided damage of the provided type gets
+// through the defenses. Immunity negates the damage, resistance halves it,
+// rounding down, and vulnerability doubles it. Damage without a type is taken
+// in full.
+func (d Defenses) Apply(amount int, t string) Breakdown {
+	b := Breakdown{
+		Type:   strings.ToLower(t),
+		Amount: amount,
+		Taken:  amount,
+	}
+	if t == "" {
+		return b
+	}
+
+	if d.Immune(t) {
+		b.Immune = true
+		b.Taken = 0
+		return b
+	}
+	if d.Resistant(t) {
+		b.Resisted = true
+		b.Taken /= 2
+	}
+	if d.Vulnerable(t) {
+		b.Vulnerable = true
+		b.Taken *= 2
+	}
+
+	return b
+}
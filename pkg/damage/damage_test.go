@@ -0,0 +1,37 @@
+package damage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefenses_Apply(t *testing.T) {
+	var (
+		fireElemental = Defenses{Immunities: []string{Fire, Poison}, Vulnerabilities: []string{Cold}}
+		tiefling      = Defenses{Resistances: []string{Fire}}
+		petrified     = Defenses{Resistances: []string{Cold}, Vulnerabilities: []string{Cold}}
+	)
+
+	tests := []struct {
+		name     string
+		defenses Defenses
+		amount   int
+		damage   string
+		want     Breakdown
+	}{
+		{"Untyped", tiefling, 12, "", Breakdown{Amount: 12, Taken: 12}},
+		{"No defense", tiefling, 12, Slashing, Breakdown{Type: Slashing, Amount: 12, Taken: 12}},
+		{"Resisted", tiefling, 12, Fire, Breakdown{Type: Fire, Amount: 12, Resisted: true, Taken: 6}},
+		{"Resisted rounds down", tiefling, 7, "FIRE", Breakdown{Type: Fire, Amount: 7, Resisted: true, Taken: 3}},
+		{"Vulnerable", fireElemental, 5, Cold, Breakdown{Type: Cold, Amount: 5, Vulnerable: true, Taken: 10}},
+		{"Immune", fireElemental, 30, Fire, Breakdown{Type: Fire, Amount: 30, Immune: true, Taken: 0}},
+		{"Resisted and vulnerable", petrified, 7, Cold, Breakdown{Type: Cold, Amount: 7, Resisted: true, Vulnerable: true, Taken: 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.defenses.Apply(tt.amount, tt.damage); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Defenses.Apply() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
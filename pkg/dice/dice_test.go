@@ -90,3 +90,60 @@ func TestD100(t *testing.T) {
 		}
 	}
 }
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    string
+		want    Expression
+		wantErr bool
+	}{
+		{"Single die", "d20", Expression{1, 20, 0}, false},
+		{"Many dice", "2d6", Expression{2, 6, 0}, false},
+		{"Positive modifier", "2d6+3", Expression{2, 6, 3}, false},
+		{"Negative modifier", "1d8 - 1", Expression{1, 8, -1}, false},
+		{"Upper case", "3D10", Expression{3, 10, 0}, false},
+		{"Flat number", "5", Expression{0, 0, 5}, false},
+		{"Empty", "", Expression{}, true},
+		{"Invalid dice", "2d7", Expression{}, true},
+		{"No dice", "0d6", Expression{}, true},
+		{"Maximum dice", "100d6", Expression{100, 6, 0}, false},
+		{"Too many dice", "101d6", Expression{}, true},
+		{"Overflowing dice", "99999999999999999999d6", Expression{}, true},
+		{"Garbage", "fireball", Expression{}, true},
+		{"Sign only", "+3", Expression{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpression_Roll(t *testing.T) {
+	e := Expression{2, 6, 3}
+
+	for i := 0; i <= *iterations; i++ {
+		got, rolls := e.Roll()
+
+		if got < 5 || got > 15 || len(rolls) != 2 {
+			t.Errorf("Expression rolled out of range.")
+		}
+	}
+
+	if got := e.Critical().Count; got != 4 {
+		t.Errorf("Critical() count = %v, want 4", got)
+	}
+	if got := e.Average(); got != 10 {
+		t.Errorf("Average() = %v, want 10", got)
+	}
+	if got := e.String(); got != "2d6+3" {
+		t.Errorf("String() = %v, want 2d6+3", got)
+	}
+}
@@ -0,0 +1,130 @@
+package dice
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// WithSides returns the dice with the provided number of sides, or nil if there
+// is no such dice.
+func WithSides(sides int) Dice {
+	switch sides {
+	case 4:
+		return D4
+	case 6:
+		return D6
+	case 8:
+		return D8
+	case 10:
+		return D10
+	case 12:
+		return D12
+	case 20:
+		return D20
+	case 100:
+		return D100
+	default:
+		return nil
+	}
+}
+
+// Expression models a dice expression, like 2d6+3.
+type Expression struct {
+	Count    int // The number of dice to roll.
+	Sides    int // The number of sides each dice has.
+	Modifier int // A flat number added to the result.
+}
+
+// MaximumCount indicates the maximum number of dice an expression can roll.
+const MaximumCount = 100
+
+// expressionPattern matches dice expressions like d20, 2d6, 2d6+3 or a flat 5.
+var expressionPattern = regexp.MustCompile(`^(?:(\d*)[dD](\d+))?\s*(?:([+-])?\s*(\d+))?$`)
+
+// InvalidExpressionError is the error that gets returned when a dice expression
+// cannot be parsed.
+type InvalidExpressionError struct {
+	Expression string
+}
+
+func (e InvalidExpressionError) Error() string {
+	return "Invalid dice expression: " + e.Expression
+}
+
+// Parse parses a dice expression. Expressions of more than MaximumCount dice
+// are invalid.
+func Parse(s string) (Expression, error) {
+	s = strings.TrimSpace(s)
+	m := expressionPattern.FindStringSubmatch(s)
+	if s == "" || m == nil {
+		return Expression{}, InvalidExpressionError{s}
+	}
+
+	var e Expression
+	if m[2] != "" {
+		e.Count = 1
+		if m[1] != "" {
+			e.Count, _ = strconv.Atoi(m[1])
+		}
+		e.Sides, _ = strconv.Atoi(m[2])
+		if WithSides(e.Sides) == nil || e.Count == 0 || e.Count > MaximumCount {
+			return Expression{}, InvalidExpressionError{s}
+		}
+	} else if m[3] != "" {
+		// A sign without any dice before it.
+		return Expression{}, InvalidExpressionError{s}
+	}
+	if m[4] != "" {
+		e.Modifier, _ = strconv.Atoi(m[4])
+		if m[3] == "-" {
+			e.Modifier = -e.Modifier
+		}
+	}
+
+	return e, nil
+}
+
+// String returns the expression in its usual form.
+func (e Expression) String() string {
+	var b strings.Builder
+	if e.Count > 0 {
+		b.WriteString(strconv.Itoa(e.Count) + "d" + strconv.Itoa(e.Sides))
+	}
+	switch {
+	case e.Modifier > 0 && e.Count > 0:
+		b.WriteString("+" + strconv.Itoa(e.Modifier))
+	case e.Modifier != 0 || e.Count == 0:
+		b.WriteString(strconv.Itoa(e.Modifier))
+	}
+
+	return b.String()
+}
+
+// Roll rolls the expression and returns the total along with every dice
+// rolled.
+func (e Expression) Roll() (int, []int) {
+	total := e.Modifier
+	rolls := make([]int, 0, e.Count)
+
+	d := WithSides(e.Sides)
+	for i := 0; i < e.Count && d != nil; i++ {
+		r := d()
+		rolls = append(rolls, r)
+		total += r
+	}
+
+	return total, rolls
+}
+
+// Critical returns the expression with its number of dice doubled, as used for
+// critical hits.
+func (e Expression) Critical() Expression {
+	e.Count *= 2
+	return e
+}
+
+// Average returns the average result of the expression, rounded down.
+func (e Expression) Average() int {
+	return e.Count*(e.Sides+1)/2 + e.Modifier
+}
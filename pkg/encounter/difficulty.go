@@ -0,0 +1,143 @@
+package encounter
+
+const (
+	// Trivial means an encounter below the easy threshold of the party.
+	Trivial = "trivial"
+	// Easy means an easy encounter.
+	Easy = "easy"
+	// Medium means a medium encounter.
+	Medium = "medium"
+	// Hard means a hard encounter.
+	Hard = "hard"
+	// Deadly means a deadly encounter.
+	Deadly = "deadly"
+)
+
+// Thresholds holds the experience points an encounter needs to reach each
+// difficulty.
+type Thresholds struct {
+	Easy   int `json:"easy" bson:"easy"`
+	Medium int `json:"medium" bson:"medium"`
+	Hard   int `json:"hard" bson:"hard"`
+	Deadly int `json:"deadly" bson:"deadly"`
+}
+
+// ThresholdsPerLevel maps a character level to the experience thresholds of a
+// single character of that level.
+var ThresholdsPerLevel = map[int]Thresholds{
+	1:  {25, 50, 75, 100},
+	2:  {50, 100, 150, 200},
+	3:  {75, 150, 225, 400},
+	4:  {125, 250, 375, 500},
+	5:  {250, 500, 750, 1100},
+	6:  {300, 600, 900, 1400},
+	7:  {350, 750, 1100, 1700},
+	8:  {450, 900, 1400, 2100},
+	9:  {550, 1100, 1600, 2400},
+	10: {600, 1200, 1900, 2800},
+	11: {800, 1600, 2400, 3600},
+	12: {1000, 2000, 3000, 4500},
+	13: {1100, 2200, 3400, 5100},
+	14: {1250, 2500, 3800, 5700},
+	15: {1400, 2800, 4300, 6400},
+	16: {1600, 3200, 4800, 7200},
+	17: {2000, 3900, 5900, 8800},
+	18: {2100, 4200, 6300, 9500},
+	19: {2400, 4900, 7300, 10900},
+	20: {2800, 5700, 8500, 12700},
+}
+
+// multipliers holds the encounter multipliers, from the one for a single
+// monster against a large party to the one for many monsters against a small
+// party.
+var multipliers = []float64{0.5, 1, 1.5, 2, 2.5, 3, 4, 5}
+
+// smallParty and largeParty indicate the party sizes that shift the encounter
+// multiplier.
+const (
+	smallParty = 3
+	largeParty = 6
+)
+
+// Difficulty is the difficulty of an encounter for a party.
+type Difficulty struct {
+	Thresholds         Thresholds `json:"thresholds" bson:"thresholds"` // The thresholds of the whole party.
+	Monsters           int        `json:"monsters" bson:"monsters"`
+	ExperiencePoints   int        `json:"experience_points" bson:"experience_points"` // The experience points the monsters are worth.
+	Multiplier         float64    `json:"multiplier" bson:"multiplier"`
+	AdjustedExperience int        `json:"adjusted_experience" bson:"adjusted_experience"` // The experience points used to rate the encounter.
+	Rating             string     `json:"rating" bson:"rating"`
+}
+
+// Group is a number of monsters of an encounter, each worth the same
+// experience points.
+type Group struct {
+	ExperiencePoints int
+	Count            int
+}
+
+// Multiplier returns the encounter multiplier for the provided number of
+// monsters against a party of the provided size. Parties of less than three
+// characters use the next higher multiplier and parties of six or more use the
+// next lower one.
+func Multiplier(monsters, party int) float64 {
+	var i int
+	switch {
+	case monsters <= 1:
+		i = 1
+	case monsters == 2:
+		i = 2
+	case monsters <= 6:
+		i = 3
+	case monsters <= 10:
+		i = 4
+	case monsters <= 14:
+		i = 5
+	default:
+		i = 6
+	}
+
+	switch {
+	case party < smallParty:
+		i++
+	case party >= largeParty:
+		i--
+	}
+
+	return multipliers[i]
+}
+
+// Rate calculates the difficulty of an encounter against the provided groups
+// of monsters, for a party of characters of the provided levels.
+func Rate(levels []int, monsters []Group) Difficulty {
+	var d Difficulty
+	for _, l := range levels {
+		t := ThresholdsPerLevel[l]
+		d.Thresholds.Easy += t.Easy
+		d.Thresholds.Medium += t.Medium
+		d.Thresholds.Hard += t.Hard
+		d.Thresholds.Deadly += t.Deadly
+	}
+
+	for _, g := range monsters {
+		d.Monsters += g.Count
+		d.ExperiencePoints += g.ExperiencePoints * g.Count
+	}
+	d.Multiplier = Multiplier(d.Monsters, len(levels))
+	d.AdjustedExperience = int(float64(d.ExperiencePoints) * d.Multiplier)
+
+	switch adjusted := d.AdjustedExperience; {
+	case adjusted >= d.Thresholds.Deadly:
+		d.Rating = Deadly
+	case adjusted >= d.Thresholds.Hard:
+		d.Rating = Hard
+	case adjusted >= d.Thresholds.Medium:
+		d.Rating = Medium
+	case adjusted >= d.Thresholds.Easy:
+		d.Rating = Easy
+	default:
+		d.Rating = Trivial
+	}
+
+	return d
+}
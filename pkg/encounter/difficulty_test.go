@@ -0,0 +1,59 @@
+package encounter
+
+import (
+	"testing"
+)
+
+func TestMultiplier(t *testing.T) {
+	tests := []struct {
+		name     string
+		monsters int
+		party    int
+		want     float64
+	}{
+		{"Single monster", 1, 4, 1},
+		{"Pair", 2, 4, 1.5},
+		{"Group", 6, 4, 2},
+		{"Gang", 7, 4, 2.5},
+		{"Mob", 11, 4, 3},
+		{"Horde", 15, 4, 4},
+		{"Small party", 1, 2, 1.5},
+		{"Small party against a horde", 20, 1, 5},
+		{"Large party", 1, 6, 0.5},
+		{"Large party against a group", 4, 7, 1.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Multiplier(tt.monsters, tt.party); got != tt.want {
+				t.Errorf("Multiplier() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		levels   []int
+		monsters []Group
+		adjusted int
+		want     string
+	}{
+		// The example of the Dungeon Master's Guide: a party of four 3rd
+		// level characters against a bugbear and three hobgoblins.
+		{"Hard", []int{3, 3, 3, 3}, []Group{{200, 1}, {100, 3}}, 1000, Hard},
+		{"Trivial", []int{5, 5, 5, 5}, []Group{{50, 1}}, 50, Trivial},
+		{"Easy", []int{1, 1, 1, 1}, []Group{{50, 2}}, 150, Easy},
+		{"Medium", []int{1, 1, 1, 1}, []Group{{100, 1}, {50, 1}}, 225, Medium},
+		{"Deadly", []int{1, 1, 1, 1}, []Group{{50, 6}}, 600, Deadly},
+		{"No monsters", []int{1, 1, 1, 1}, nil, 0, Trivial},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Rate(tt.levels, tt.monsters)
+			if got.AdjustedExperience != tt.adjusted || got.Rating != tt.want {
+				t.Errorf("Rate() = %v adjusted and %v, want %v and %v", got.AdjustedExperience, got.Rating, tt.adjusted, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package encounter
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/dice"
+)
+
+// Combatant is a creature taking part in an encounter.
+type Combatant struct {
+	Name              string `json:"name" bson:"name"`
+	Kind              string `json:"kind" bson:"kind"`
+	DexterityModifier int    `json:"dexterity_modifier" bson:"dexterity_modifier"`
+	Initiative        int    `json:"initiative" bson:"initiative"`               // Rolled when the combat starts, unless provided.
+	Rolled            bool   `json:"rolled" bson:"rolled"`                       // Whether the initiative was rolled or provided.
+	TieBreaker        int    `json:"tie_breaker" bson:"tie_breaker"`             // Settles ties between equal initiatives and Dexterity modifiers.
+	Delayed           bool   `json:"delayed" bson:"delayed"`                     // Whether the combatant delays its turn.
+	Readied           string `json:"readied,omitempty" bson:"readied,omitempty"` // The trigger of a readied action.
+}
+
+// Encounter models a combat between creatures.
+type Encounter struct {
+	Name       string      `json:"name" bson:"name"`
+	Campaign   string      `json:"campaign,omitempty" bson:"campaign,omitempty"` // The campaign of the combatants, if any.
+	Owner      string      `json:"owner,omitempty" bson:"owner,omitempty"`       // The user who created the encounter, if any.
+	Combatants []Combatant `json:"combatants" bson:"combatants"`                 // In turn order, once the combat starts.
+	Round      int         `json:"round" bson:"round"`                           // Zero until the combat starts.
+	Turn       int         `json:"turn" bson:"turn"`                             // The index of the combatant whose turn it is.
+	Active     bool        `json:"active" bson:"active"`
+	Version    int         `json:"version" bson:"version"` // Raised by every change, so that concurrent ones do not overwrite each other.
+}
+
+// Error is the error that gets returned when an encounter operation is
+// invalid.
+type Error struct {
+	Message string
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
+
+var (
+	errCombatantExists  = Error{"The creature already takes part in the encounter."}
+	errMissingCombatant = Error{"The creature does not take part in the encounter."}
+	errNoCombatants     = Error{"The encounter has no combatants."}
+	errNotActive        = Error{"The combat has not started."}
+	errAlreadyActive    = Error{"The combat has already started."}
+	errNotDelayed       = Error{"The creature does not delay its turn."}
+	errEveryoneDelays   = Error{"Every combatant delays its turn."}
+	errMissingTrigger   = Error{"A readied action needs a trigger."}
+)
+
+// find returns the index of the combatant with the provided name, or -1 if
+// there is no such combatant.
+func (e *Encounter) find(name string) int {
+	for i, c := range e.Combatants {
+		if strings.EqualFold(c.Name, name) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Current returns the combatant whose turn it is, or nil if the combat has not
+// started.
+func (e *Encounter) Current() *Combatant {
+	if !e.Active || e.Turn < 0 || e.Turn >= len(e.Combatants) {
+		return nil
+	}
+
+	return &e.Combatants[e.Turn]
+}
+
+// Add adds the combatant to the encounter. A combatant that joins an active
+// combat rolls its initiative, unless provided, and takes its place in the
+// turn order.
+func (e *Encounter) Add(c Combatant, roll dice.Dice) error {
+	if e.find(c.Name) >= 0 {
+		return errCombatantExists
+	}
+
+	if !e.Active {
+		e.Combatants = append(e.Combatants, c)
+		return nil
+	}
+
+	c.roll(roll)
+	i := sort.Search(len(e.Combatants), func(i int) bool {
+		return before(c, e.Combatants[i])
+	})
+	e.Combatants = append(e.Combatants, Combatant{})
+	copy(e.Combatants[i+1:], e.Combatants[i:])
+	e.Combatants[i] = c
+	if i <= e.Turn && len(e.Combatants) > 1 {
+		e.Turn++
+	}
+
+	return nil
+}
+
+// Remove removes the combatant with the provided name from the encounter.
+func (e *Encounter) Remove(name string) error {
+	i := e.find(name)
+	if i < 0 {
+		return errMissingCombatant
+	}
+
+	e.Combatants = append(e.Combatants[:i], e.Combatants[i+1:]...)
+	if !e.Active {
+		return nil
+	}
+	if len(e.Combatants) == 0 {
+		e.End()
+		return nil
+	}
+
+	if i < e.Turn {
+		e.Turn--
+	}
+	if e.Turn >= len(e.Combatants) {
+		e.Turn = 0
+		e.Round++
+	}
+
+	return nil
+}
+
+// roll rolls the initiative of the combatant, unless it was provided, along
+// with its tie breaker.
+func (c *Combatant) roll(roll dice.Dice) {
+	if roll == nil {
+		roll = dice.D20
+	}
+
+	if c.Initiative == 0 {
+		c.Initiative = roll() + c.DexterityModifier
+		c.Rolled = true
+	}
+	c.TieBreaker = roll()
+}
+
+// before checks whether a acts before b. Higher initiatives act first, then
+// higher Dexterity modifiers and then higher tie breakers.
+func before(a, b Combatant) bool {
+	if a.Initiative != b.Initiative {
+		return a.Initiative > b.Initiative
+	}
+	if a.DexterityModifier != b.DexterityModifier {
+		return a.DexterityModifier > b.DexterityModifier
+	}
+	if a.TieBreaker != b.TieBreaker {
+		return a.TieBreaker > b.TieBreaker
+	}
+
+	return a.Name < b.Name
+}
+
+// Start rolls initiative for every combatant whose initiative was not
+// provided, sorts them in turn order and starts the first round.
+func (e *Encounter) Start(roll dice.Dice) error {
+	if e.Active {
+		return errAlreadyActive
+	}
+	if len(e.Combatants) == 0 {
+		return errNoCombatants
+	}
+
+	for i := range e.Combatants {
+		e.Combatants[i].roll(roll)
+	}
+	sort.SliceStable(e.Combatants, func(i, j int) bool {
+		return before(e.Combatants[i], e.Combatants[j])
+	})
+
+	e.Active = true
+	e.Round = 1
+	e.Turn = 0
+	e.Combatants[0].Readied = ""
+
+	return nil
+}
+
+// Next ends the current turn and returns the combatant whose turn begins.
+// Delayed combatants are skipped, and a readied action lasts only until the
+// next turn of its combatant.
+func (e *Encounter) Next() (*Combatant, error) {
+	if !e.Active {
+		return nil, errNotActive
+	}
+
+	for range e.Combatants {
+		e.Turn++
+		if e.Turn >= len(e.Combatants) {
+			e.Turn = 0
+			e.Round++
+		}
+
+		if c := &e.Combatants[e.Turn]; !c.Delayed {
+			c.Readied = ""
+			return c, nil
+		}
+	}
+
+	return nil, errEveryoneDelays
+}
+
+// Delay makes the current combatant delay its turn, until it resumes it, and
+// returns the combatant whose turn begins.
+func (e *Encounter) Delay() (*Combatant, error) {
+	c := e.Current()
+	if c == nil {
+		return nil, errNotActive
+	}
+
+	c.Delayed = true
+	return e.Next()
+}
+
+// Resume makes the delayed combatant with the provided name take its turn right
+// away. It keeps its new place in the turn order for the following rounds.
+func (e *Encounter) Resume(name string) (*Combatant, error) {
+	if !e.Active {
+		return nil, errNotActive
+	}
+	i := e.find(name)
+	if i < 0 {
+		return nil, errMissingCombatant
+	}
+	if !e.Combatants[i].Delayed {
+		return nil, errNotDelayed
+	}
+
+	c := e.Combatants[i]
+	c.Delayed = false
+	e.Combatants = append(e.Combatants[:i], e.Combatants[i+1:]...)
+	if i < e.Turn {
+		e.Turn--
+	}
+
+	// The delayed combatant acts before the one whose turn it was.
+	if e.Turn < len(e.Combatants) {
+		c.Initiative = e.Combatants[e.Turn].Initiative
+	} else {
+		e.Turn = len(e.Combatants)
+	}
+	e.Combatants = append(e.Combatants, Combatant{})
+	copy(e.Combatants[e.Turn+1:], e.Combatants[e.Turn:])
+	e.Combatants[e.Turn] = c
+
+	return &e.Combatants[e.Turn], nil
+}
+
+// Ready makes the current combatant ready an action for the provided trigger
+// and returns the combatant whose turn begins.
+func (e *Encounter) Ready(trigger string) (*Combatant, error) {
+	c := e.Current()
+	if c == nil {
+		return nil, errNotActive
+	}
+	if trigger == "" {
+		return nil, errMissingTrigger
+	}
+
+	c.Readied = trigger
+	return e.Next()
+}
+
+// End ends the combat, keeping the combatants for another one.
+func (e *Encounter) End() {
+	e.Active = false
+	e.Round = 0
+	e.Turn = 0
+	for i := range e.Combatants {
+		c := &e.Combatants[i]
+		if c.Rolled {
+			c.Initiative = 0
+			c.Rolled = false
+		}
+		c.Delayed = false
+		c.Readied = ""
+	}
+}
@@ -0,0 +1,201 @@
+package encounter
+
+import (
+	"testing"
+
+	"github.com/aakordas/creature_manager/pkg/dice"
+)
+
+// sequence returns a d20 that rolls the provided values in order.
+func sequence(values ...int) dice.Dice {
+	i := 0
+	return func() int {
+		v := values[i%len(values)]
+		i++
+		return v
+	}
+}
+
+// order returns the names of the combatants in turn order.
+func order(e *Encounter) []string {
+	names := make([]string, 0, len(e.Combatants))
+	for _, c := range e.Combatants {
+		names = append(names, c.Name)
+	}
+
+	return names
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newEncounter returns an encounter with a fighter, a rogue and two goblins.
+func newEncounter() *Encounter {
+	e := &Encounter{Name: "Cragmaw Hideout"}
+	e.Add(Combatant{Name: "Fighter", DexterityModifier: 1}, nil)
+	e.Add(Combatant{Name: "Rogue", DexterityModifier: 4}, nil)
+	e.Add(Combatant{Name: "Goblin 1", DexterityModifier: 2}, nil)
+	e.Add(Combatant{Name: "Goblin 2", DexterityModifier: 2, Initiative: 20}, nil)
+
+	return e
+}
+
+func TestEncounter_Start(t *testing.T) {
+	e := newEncounter()
+	// Initiative and tie breaker rolls, in the order of the combatants.
+	// Fighter 14+1, Rogue 11+4, Goblin 1 13+2 and Goblin 2 keeps its 20.
+	if err := e.Start(sequence(14, 5, 11, 8, 13, 1, 2)); err != nil {
+		t.Fatalf("Encounter.Start() error = %v", err)
+	}
+
+	want := []string{"Goblin 2", "Rogue", "Goblin 1", "Fighter"}
+	if got := order(e); !equal(got, want) {
+		t.Errorf("Encounter.Start() order = %v, want %v", got, want)
+	}
+	if e.Round != 1 || e.Current().Name != "Goblin 2" {
+		t.Errorf("Encounter.Start() round %v, current %v", e.Round, e.Current().Name)
+	}
+	if err := e.Start(nil); err == nil {
+		t.Errorf("Encounter.Start() started twice")
+	}
+}
+
+func TestEncounter_TieBreaker(t *testing.T) {
+	e := &Encounter{}
+	e.Add(Combatant{Name: "A", DexterityModifier: 2, Initiative: 15}, nil)
+	e.Add(Combatant{Name: "B", DexterityModifier: 2, Initiative: 15}, nil)
+	e.Start(sequence(3, 17))
+
+	if got, want := order(e), []string{"B", "A"}; !equal(got, want) {
+		t.Errorf("Encounter.Start() order = %v, want %v", got, want)
+	}
+}
+
+func TestEncounter_Next(t *testing.T) {
+	e := newEncounter()
+	e.Start(sequence(14, 5, 11, 8, 13, 1, 2))
+
+	var names []string
+	for i := 0; i < 5; i++ {
+		c, err := e.Next()
+		if err != nil {
+			t.Fatalf("Encounter.Next() error = %v", err)
+		}
+		names = append(names, c.Name)
+	}
+
+	want := []string{"Rogue", "Goblin 1", "Fighter", "Goblin 2", "Rogue"}
+	if !equal(names, want) {
+		t.Errorf("Encounter.Next() turns = %v, want %v", names, want)
+	}
+	if e.Round != 2 {
+		t.Errorf("Encounter.Next() round = %v, want 2", e.Round)
+	}
+}
+
+func TestEncounter_DelayAndResume(t *testing.T) {
+	e := newEncounter()
+	e.Start(sequence(14, 5, 11, 8, 13, 1, 2))
+
+	// Goblin 2 delays, so the rogue acts.
+	c, err := e.Delay()
+	if err != nil || c.Name != "Rogue" {
+		t.Fatalf("Encounter.Delay() = %v, %v, want Rogue", c, err)
+	}
+	// Goblin 1 acts, then Goblin 2 resumes before the fighter.
+	e.Next()
+	e.Next()
+	c, err = e.Resume("Goblin 2")
+	if err != nil || c.Name != "Goblin 2" {
+		t.Fatalf("Encounter.Resume() = %v, %v, want Goblin 2", c, err)
+	}
+
+	want := []string{"Rogue", "Goblin 1", "Goblin 2", "Fighter"}
+	if got := order(e); !equal(got, want) {
+		t.Errorf("Encounter.Resume() order = %v, want %v", got, want)
+	}
+	if c, _ := e.Next(); c.Name != "Fighter" {
+		t.Errorf("Encounter.Next() = %v, want Fighter", c.Name)
+	}
+	if _, err := e.Resume("Fighter"); err == nil {
+		t.Errorf("Encounter.Resume() resumed a combatant that does not delay")
+	}
+}
+
+func TestEncounter_Ready(t *testing.T) {
+	e := newEncounter()
+	e.Start(sequence(14, 5, 11, 8, 13, 1, 2))
+
+	e.Ready("A goblin comes through the door")
+	if e.Combatants[0].Readied == "" {
+		t.Errorf("Encounter.Ready() did not ready the action")
+	}
+	for i := 0; i < 3; i++ {
+		e.Next()
+	}
+	if e.Current().Name != "Goblin 2" || e.Current().Readied != "" {
+		t.Errorf("The readied action lasted past the next turn: %v", e.Current())
+	}
+}
+
+func TestEncounter_AddAndRemove(t *testing.T) {
+	e := newEncounter()
+	e.Start(sequence(14, 5, 11, 8, 13, 1, 2))
+	e.Next() // Rogue
+
+	if err := e.Add(Combatant{Name: "Rogue"}, nil); err == nil {
+		t.Errorf("Encounter.Add() added a combatant twice")
+	}
+	if err := e.Add(Combatant{Name: "Wolf", Initiative: 18}, sequence(1)); err != nil {
+		t.Fatalf("Encounter.Add() error = %v", err)
+	}
+	if got, want := order(e), []string{"Goblin 2", "Wolf", "Rogue", "Goblin 1", "Fighter"}; !equal(got, want) {
+		t.Errorf("Encounter.Add() order = %v, want %v", got, want)
+	}
+	if e.Current().Name != "Rogue" {
+		t.Errorf("Encounter.Add() changed the current turn to %v", e.Current().Name)
+	}
+
+	e.Remove("Goblin 2")
+	if e.Current().Name != "Rogue" {
+		t.Errorf("Encounter.Remove() changed the current turn to %v", e.Current().Name)
+	}
+	e.Remove("Rogue")
+	if e.Current().Name != "Goblin 1" {
+		t.Errorf("Encounter.Remove() passed the turn to %v, want Goblin 1", e.Current().Name)
+	}
+	if err := e.Remove("Rogue"); err == nil {
+		t.Errorf("Encounter.Remove() removed a missing combatant")
+	}
+}
+
+func TestEncounter_End(t *testing.T) {
+	e := newEncounter()
+	e.Start(sequence(14, 5, 11, 8, 13, 1, 2))
+	e.End()
+
+	if e.Active || e.Round != 0 || e.Current() != nil {
+		t.Errorf("Encounter.End() did not end the combat: %+v", e)
+	}
+	for _, c := range e.Combatants {
+		if c.Name == "Goblin 2" && c.Initiative != 20 {
+			t.Errorf("Encounter.End() cleared a provided initiative")
+		}
+		if c.Name != "Goblin 2" && c.Initiative != 0 {
+			t.Errorf("Encounter.End() kept the rolled initiative of %v", c.Name)
+		}
+	}
+	if _, err := e.Next(); err == nil {
+		t.Errorf("Encounter.Next() advanced an ended combat")
+	}
+}
@@ -0,0 +1,232 @@
+package experience
+
+import (
+	"github.com/aakordas/creature_manager/pkg/classes"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/skills"
+)
+
+// ExperiencePerLevel maps a character level to the experience points needed to
+// reach it.
+var ExperiencePerLevel = map[int]int{
+	1:  0,
+	2:  300,
+	3:  900,
+	4:  2700,
+	5:  6500,
+	6:  14000,
+	7:  23000,
+	8:  34000,
+	9:  48000,
+	10: 64000,
+	11: 85000,
+	12: 100000,
+	13: 120000,
+	14: 140000,
+	15: 165000,
+	16: 195000,
+	17: 225000,
+	18: 265000,
+	19: 305000,
+	20: 355000,
+}
+
+const (
+	// Experience means the creature levels up when it gathers enough
+	// experience points.
+	Experience = "experience"
+	// Milestone means the creature levels up whenever the DM decides so.
+	Milestone = "milestone"
+)
+
+// Roller rolls a die with the provided number of sides. A nil Roller takes the
+// average of the die instead.
+type Roller func(sides int) int
+
+// Change reports what changed on a creature after it gained a level.
+type Change struct {
+	Class               string `json:"class,omitempty" bson:"class,omitempty"`
+	ClassLevel          int    `json:"class_level,omitempty" bson:"class_level,omitempty"`
+	OldLevel            int    `json:"old_level" bson:"old_level"`
+	NewLevel            int    `json:"new_level" bson:"new_level"`
+	OldProficiencyBonus int    `json:"old_proficiency_bonus" bson:"old_proficiency_bonus"`
+	NewProficiencyBonus int    `json:"new_proficiency_bonus" bson:"new_proficiency_bonus"`
+	HitDie              int    `json:"hit_die,omitempty" bson:"hit_die,omitempty"`   // The result of the hit die, or its average.
+	HitPointsGained     int    `json:"hit_points_gained" bson:"hit_points_gained"`   // The hit die plus the constitution modifier.
+	MaximumHitPoints    int    `json:"maximum_hit_points" bson:"maximum_hit_points"` // The new maximum hit points.
+}
+
+// LevelFor returns the level a creature with the provided experience points
+// should be.
+func LevelFor(xp int) int {
+	level := 1
+	for l, needed := range ExperiencePerLevel {
+		if xp >= needed && l > level {
+			level = l
+		}
+	}
+
+	return level
+}
+
+// Error is the error that gets returned when a creature cannot level up.
+type Error struct {
+	Message string
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
+
+var (
+	errMaximumLevel   = Error{"The creature is already at the maximum level."}
+	errNotEnoughXP    = Error{"The creature does not have enough experience points to level up."}
+	errInvalidClass   = Error{"Please provide a valid class name."}
+	errAmbiguousClass = Error{"The creature has multiple classes, please provide the one to level up."}
+)
+
+// CanLevelUp checks whether the creature is allowed to gain a level, given its
+// way of advancement.
+func CanLevelUp(c *creature.Creature) error {
+	if creature.OutOfRange(c.Level + 1) {
+		return errMaximumLevel
+	}
+	if !c.Milestone && c.ExperiencePoints < ExperiencePerLevel[c.Level+1] {
+		return errNotEnoughXP
+	}
+
+	return nil
+}
+
+// levelingClass decides which class gains the level. A creature with a single
+// class does not need to name it.
+func levelingClass(c *creature.Creature, class string) (string, error) {
+	if class != "" {
+		if !classes.Valid(class) {
+			return "", errInvalidClass
+		}
+		return class, nil
+	}
+
+	switch len(c.Classes) {
+	case 0:
+		return "", nil
+	case 1:
+		for k := range c.Classes {
+			return k, nil
+		}
+	}
+
+	return "", errAmbiguousClass
+}
+
+// LevelUp raises the level of the creature by one in the provided class,
+// updating its proficiency bonus and everything that depends on it, its hit
+// dice and its hit points. Hit points are rolled with roll, or the average of
+// the hit die is taken if roll is nil.
+func LevelUp(c *creature.Creature, class string, roll Roller) (Change, error) {
+	if err := CanLevelUp(c); err != nil {
+		return Change{}, err
+	}
+	class, err := levelingClass(c, class)
+	if err != nil {
+		return Change{}, err
+	}
+
+	change := Change{
+		Class:               class,
+		OldLevel:            c.Level,
+		NewLevel:            c.Level + 1,
+		OldProficiencyBonus: c.ProficiencyBonus,
+		NewProficiencyBonus: creature.ProficiencyBonusPerLevel[c.Level+1],
+	}
+
+	if class != "" {
+		if c.Classes == nil {
+			// The levels gained so far are assumed to be of the first
+			// class the creature names.
+			c.Classes = classes.Classes{class: c.Level}
+		}
+		if c.HitDice == nil {
+			c.HitDice = make(map[string]int)
+		}
+		c.Classes[class]++
+		c.HitDice[class]++
+		change.ClassLevel = c.Classes[class]
+
+		sides := classes.HitDie[class]
+		if roll == nil {
+			change.HitDie = sides/2 + 1
+		} else {
+			change.HitDie = roll(sides)
+		}
+
+		// A creature gains at least one hit point per level.
+		change.HitPointsGained = change.HitDie + c.ConstitutionModifier
+		if change.HitPointsGained < 1 {
+			change.HitPointsGained = 1
+		}
+	}
+
+	c.Level = change.NewLevel
+	c.MaximumHitPoints += change.HitPointsGained
+	c.CurrentHitPoints += change.HitPointsGained
+	change.MaximumHitPoints = c.MaximumHitPoints
+
+	updateProficiency(c, change.NewProficiencyBonus-change.OldProficiencyBonus)
+	if c.Spellcasting != nil {
+		c.Spellcasting.Recalculate(c.Classes)
+	}
+
+	return change, nil
+}
+
+// updateProficiency adds the difference of the proficiency bonus to
+// everything the creature is proficient in.
+func updateProficiency(c *creature.Creature, delta int) {
+	c.ProficiencyBonus += delta
+	if delta == 0 {
+		return
+	}
+
+	for _, s := range c.Skills {
+		s.Value += delta
+	}
+	for s := range c.SavingThrows {
+		c.SavingThrows[s] += delta
+	}
+	if _, ok := c.Skills[skills.Perception]; ok {
+		c.PassivePerception += delta
+	}
+}
+
+// Award adds the provided experience points to the creature. A creature that
+// advances by experience and has no more than one class levels up on its own,
+// as many times as its experience allows.
+func Award(c *creature.Creature, xp int, roll Roller) []Change {
+	c.ExperiencePoints += xp
+	if c.Milestone || len(c.Classes) > 1 {
+		return nil
+	}
+
+	var changes []Change
+	for CanLevelUp(c) == nil {
+		change, err := LevelUp(c, "", roll)
+		if err != nil {
+			break
+		}
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// Split splits the provided experience points evenly among count creatures.
+// Any remainder is lost.
+func Split(xp, count int) int {
+	if count < 1 {
+		return 0
+	}
+
+	return xp / count
+}
@@ -0,0 +1,105 @@
+package experience
+
+import (
+	"testing"
+
+	"github.com/aakordas/creature_manager/pkg/abilities"
+	"github.com/aakordas/creature_manager/pkg/classes"
+	"github.com/aakordas/creature_manager/pkg/creature"
+)
+
+func TestLevelFor(t *testing.T) {
+	tests := []struct {
+		name string
+		xp   int
+		want int
+	}{
+		{"No experience", 0, 1},
+		{"Just below second level", 299, 1},
+		{"Second level", 300, 2},
+		{"Fifth level", 7000, 5},
+		{"Maximum level", 1000000, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LevelFor(tt.xp); got != tt.want {
+				t.Errorf("LevelFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newFighter(level, xp int) *creature.Creature {
+	return &creature.Creature{
+		Level:            level,
+		ExperiencePoints: xp,
+		ProficiencyBonus: creature.ProficiencyBonusPerLevel[level],
+		MaximumHitPoints: 10 * level,
+		CurrentHitPoints: 10 * level,
+		Abilities:        abilities.Abilities{ConstitutionModifier: 2},
+		Classes:          classes.Classes{classes.Fighter: level},
+		HitDice:          map[string]int{classes.Fighter: level},
+	}
+}
+
+func TestLevelUp(t *testing.T) {
+	tests := []struct {
+		name      string
+		creature  *creature.Creature
+		class     string
+		roll      Roller
+		wantErr   bool
+		wantLevel int
+		wantHP    int
+	}{
+		{"Average hit points", newFighter(1, 300), "", nil, false, 2, 18},
+		{"Rolled hit points", newFighter(1, 300), "", func(int) int { return 1 }, false, 2, 13},
+		{"Not enough experience", newFighter(1, 299), "", nil, true, 1, 10},
+		{"Maximum level", newFighter(20, 400000), "", nil, true, 20, 200},
+		{"Invalid class", newFighter(1, 300), "peasant", nil, true, 1, 10},
+		{"Multiclass", newFighter(1, 300), classes.Wizard, nil, false, 2, 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LevelUp(tt.creature, tt.class, tt.roll)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LevelUp() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.creature.Level != tt.wantLevel {
+				t.Errorf("LevelUp() level = %v, want %v", tt.creature.Level, tt.wantLevel)
+			}
+			if tt.creature.MaximumHitPoints != tt.wantHP {
+				t.Errorf("LevelUp() maximum hit points = %v, want %v", tt.creature.MaximumHitPoints, tt.wantHP)
+			}
+		})
+	}
+}
+
+func TestLevelUpProficiency(t *testing.T) {
+	c := newFighter(4, 6500)
+	c.SavingThrows = map[string]int{"strength": 5}
+
+	change, err := LevelUp(c, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change.NewProficiencyBonus != 3 || c.ProficiencyBonus != 3 {
+		t.Errorf("LevelUp() proficiency bonus = %v, want 3", c.ProficiencyBonus)
+	}
+	if c.SavingThrows["strength"] != 6 {
+		t.Errorf("LevelUp() strength save = %v, want 6", c.SavingThrows["strength"])
+	}
+}
+
+func TestAward(t *testing.T) {
+	c := newFighter(1, 0)
+	if changes := Award(c, 1000, nil); len(changes) != 2 || c.Level != 3 {
+		t.Errorf("Award() gave %v level ups, level = %v, want 2 and 3", len(changes), c.Level)
+	}
+
+	c = newFighter(1, 0)
+	c.Milestone = true
+	if changes := Award(c, 1000, nil); len(changes) != 0 || c.Level != 1 {
+		t.Errorf("Award() leveled up a creature using milestones.")
+	}
+}
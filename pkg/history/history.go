@@ -0,0 +1,121 @@
+package history
+
+import (
+	"reflect"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// Update means an event that changed some fields of a creature.
+	Update = "update"
+	// Undo means an event that reverted earlier updates of a creature.
+	Undo = "undo"
+	// Delete means an event that deleted a creature.
+	Delete = "delete"
+	// Restore means an event that brought back a deleted creature.
+	Restore = "restore"
+)
+
+// Event models a change to a creature. Old and New hold the values of the
+// changed fields before and after the change. A field missing from either did
+// not exist at the time.
+type Event struct {
+	ID       primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Creature primitive.ObjectID `json:"creature" bson:"creature"`
+	User     string             `json:"user" bson:"user"`
+	Time     time.Time          `json:"time" bson:"time"`
+	Action   string             `json:"action" bson:"action"`
+	Version  int                `json:"version" bson:"version"` // The version of the creature after the change.
+	Fields   []string           `json:"fields,omitempty" bson:"fields,omitempty"`
+	Old      bson.M             `json:"old,omitempty" bson:"old,omitempty"`
+	New      bson.M             `json:"new,omitempty" bson:"new,omitempty"`
+	Undone   bool               `json:"undone,omitempty" bson:"undone,omitempty"` // Whether a later undo reverted the change.
+}
+
+// ignored holds the fields that change on their own, instead of by request.
+var ignored = map[string]bool{
+	"_id":     true,
+	"version": true,
+}
+
+// Permanent holds the fields an undo leaves alone. Renaming a creature renames
+// its mentions in other documents too, which an undo would leave behind.
+var Permanent = []string{"name", "template"}
+
+// permanent reports whether the provided field is one of the permanent ones.
+func permanent(field string) bool {
+	for _, f := range Permanent {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Diff returns the event of the change of a creature from before to after,
+// with the changed fields sorted by name. The rest of the event is left to the
+// caller.
+func Diff(before, after bson.M) Event {
+	e := Event{Old: bson.M{}, New: bson.M{}}
+
+	for field, v := range before {
+		if ignored[field] {
+			continue
+		}
+		if w, ok := after[field]; !ok || !reflect.DeepEqual(v, w) {
+			e.Fields = append(e.Fields, field)
+			e.Old[field] = v
+			if ok {
+				e.New[field] = w
+			}
+		}
+	}
+	for field, w := range after {
+		if _, ok := before[field]; !ok && !ignored[field] {
+			e.Fields = append(e.Fields, field)
+			e.New[field] = w
+		}
+	}
+	sort.Strings(e.Fields)
+
+	return e
+}
+
+// Revert returns the update that reverts the provided events, newest first,
+// or nil if they changed nothing. The permanent fields are left as they are.
+func Revert(events []Event) bson.M {
+	set, unset := bson.M{}, bson.M{}
+	for _, e := range events {
+		// Older events override newer ones, down to the oldest value.
+		for _, field := range e.Fields {
+			if permanent(field) {
+				continue
+			}
+			if v, ok := e.Old[field]; ok {
+				set[field] = v
+				delete(unset, field)
+			} else {
+				unset[field] = ""
+				delete(set, field)
+			}
+		}
+	}
+
+	u := bson.M{}
+	if len(set) > 0 {
+		u["$set"] = set
+	}
+	if len(unset) > 0 {
+		u["$unset"] = unset
+	}
+	if len(u) == 0 {
+		return nil
+	}
+
+	return u
+}
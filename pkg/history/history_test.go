@@ -0,0 +1,95 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before bson.M
+		after  bson.M
+		want   Event
+	}{
+		{
+			name:   "No change",
+			before: bson.M{"_id": 1, "name": "Merry", "version": 3},
+			after:  bson.M{"_id": 1, "name": "Merry", "version": 4},
+			want:   Event{Old: bson.M{}, New: bson.M{}},
+		},
+		{
+			name:   "Changed, added and removed fields",
+			before: bson.M{"name": "Merry", "level": 1, "conditions": bson.A{"poisoned"}},
+			after:  bson.M{"name": "Merry", "level": 2, "armor_class": 12},
+			want: Event{
+				Fields: []string{"armor_class", "conditions", "level"},
+				Old:    bson.M{"conditions": bson.A{"poisoned"}, "level": 1},
+				New:    bson.M{"armor_class": 12, "level": 2},
+			},
+		},
+		{
+			name:   "Nested change",
+			before: bson.M{"abilities": bson.M{"strength": 10}},
+			after:  bson.M{"abilities": bson.M{"strength": 12}},
+			want: Event{
+				Fields: []string{"abilities"},
+				Old:    bson.M{"abilities": bson.M{"strength": 10}},
+				New:    bson.M{"abilities": bson.M{"strength": 12}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevert(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []Event
+		want   bson.M
+	}{
+		{"Nothing", nil, nil},
+		{
+			name: "Newest first, down to the oldest value",
+			events: []Event{
+				{Fields: []string{"level"}, Old: bson.M{"level": 2}, New: bson.M{"level": 3}},
+				{Fields: []string{"armor_class", "level"}, Old: bson.M{"level": 1}, New: bson.M{"armor_class": 12, "level": 2}},
+			},
+			want: bson.M{
+				"$set":   bson.M{"level": 1},
+				"$unset": bson.M{"armor_class": ""},
+			},
+		},
+		{
+			name: "Field added back",
+			events: []Event{
+				{Fields: []string{"conditions"}, Old: bson.M{}, New: bson.M{"conditions": bson.A{"prone"}}},
+				{Fields: []string{"conditions"}, Old: bson.M{"conditions": bson.A{"poisoned"}}, New: bson.M{}},
+			},
+			want: bson.M{"$set": bson.M{"conditions": bson.A{"poisoned"}}},
+		},
+		{
+			name: "Permanent fields left alone",
+			events: []Event{
+				{Fields: []string{"level", "name"}, Old: bson.M{"level": 1, "name": "Merry"}, New: bson.M{"level": 2, "name": "Meriadoc"}},
+				{Fields: []string{"template"}, Old: bson.M{"template": "Goblin"}, New: bson.M{"template": "Goblin Boss"}},
+			},
+			want: bson.M{"$set": bson.M{"level": 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Revert(tt.events); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Revert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
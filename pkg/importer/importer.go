@@ -0,0 +1,348 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/abilities"
+	"github.com/aakordas/creature_manager/pkg/armor"
+	"github.com/aakordas/creature_manager/pkg/conditions"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/damage"
+	"github.com/aakordas/creature_manager/pkg/saves"
+	"github.com/aakordas/creature_manager/pkg/skills"
+)
+
+// StatBlock models a monster in the shape of the SRD stat blocks that Open5e
+// serves.
+type StatBlock struct {
+	Name      string `json:"name"`
+	Size      string `json:"size"`
+	Type      string `json:"type"`
+	Alignment string `json:"alignment"`
+
+	ArmorClass int    `json:"armor_class"`
+	HitPoints  int    `json:"hit_points"`
+	HitDice    string `json:"hit_dice"`
+	Speed      speed  `json:"speed"`
+
+	Strength     int `json:"strength"`
+	Dexterity    int `json:"dexterity"`
+	Constitution int `json:"constitution"`
+	Intelligence int `json:"intelligence"`
+	Wisdom       int `json:"wisdom"`
+	Charisma     int `json:"charisma"`
+
+	StrengthSave     *int `json:"strength_save"`
+	DexteritySave    *int `json:"dexterity_save"`
+	ConstitutionSave *int `json:"constitution_save"`
+	IntelligenceSave *int `json:"intelligence_save"`
+	WisdomSave       *int `json:"wisdom_save"`
+	CharismaSave     *int `json:"charisma_save"`
+
+	Skills map[string]int `json:"skills"`
+
+	DamageVulnerabilities list `json:"damage_vulnerabilities"`
+	DamageResistances     list `json:"damage_resistances"`
+	DamageImmunities      list `json:"damage_immunities"`
+	ConditionImmunities   list `json:"condition_immunities"`
+
+	Senses          string  `json:"senses"`
+	Languages       string  `json:"languages"`
+	ChallengeRating rating  `json:"challenge_rating"`
+	Actions         actions `json:"actions"`
+	Reactions       actions `json:"reactions"`
+	Legendary       actions `json:"legendary_actions"`
+	Special         actions `json:"special_abilities"`
+}
+
+// Error is the error that gets returned when a stat block cannot be imported.
+type Error struct {
+	Message string
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
+
+var (
+	errInvalidJSON = Error{"The stat block is not valid JSON."}
+	errMissingName = Error{"The stat block has no name."}
+	errInvalidCR   = Error{"The stat block has an invalid challenge rating."}
+	errNoMonsters  = Error{"No stat blocks were provided."}
+)
+
+// list is a list of values that stat blocks provide either as an array or as a
+// single comma separated string.
+type list []string
+
+// UnmarshalJSON implements the json.Unmarshaler interface for list.
+func (l *list) UnmarshalJSON(b []byte) error {
+	var values []string
+	if err := json.Unmarshal(b, &values); err == nil {
+		*l = values
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*l = strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ';'
+	})
+
+	return nil
+}
+
+// rating is a challenge rating that stat blocks provide either as a string,
+// like "1/4", or as a number, like 0.25.
+type rating string
+
+// UnmarshalJSON implements the json.Unmarshaler interface for rating.
+func (r *rating) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*r = rating(s)
+		return nil
+	}
+
+	var f float64
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+	switch f {
+	case 0.125:
+		*r = "1/8"
+	case 0.25:
+		*r = "1/4"
+	case 0.5:
+		*r = "1/2"
+	default:
+		*r = rating(strconv.Itoa(int(f)))
+	}
+
+	return nil
+}
+
+// speed holds the speed of each movement mode, skipping anything that is not a
+// distance, like the hover flag.
+type speed map[string]int
+
+// UnmarshalJSON implements the json.Unmarshaler interface for speed.
+func (s *speed) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	*s = speed{}
+	for mode, v := range raw {
+		if feet, ok := v.(float64); ok {
+			(*s)[mode] = int(feet)
+		}
+	}
+
+	return nil
+}
+
+// actions is a list of actions that stat blocks leave as an empty string when
+// there are none.
+type actions []creature.Action
+
+// UnmarshalJSON implements the json.Unmarshaler interface for actions.
+func (a *actions) UnmarshalJSON(b []byte) error {
+	var raw []struct {
+		Name        string `json:"name"`
+		Description string `json:"desc"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		var s string
+		if json.Unmarshal(b, &s) == nil {
+			return nil
+		}
+		return err
+	}
+
+	for _, r := range raw {
+		*a = append(*a, creature.Action{
+			Name:        r.Name,
+			Description: r.Description,
+		})
+	}
+
+	return nil
+}
+
+// passivePerception finds the passive Perception in the senses of a stat
+// block.
+var passivePerception = regexp.MustCompile(`(?i)passive perception (\d+)`)
+
+// damageTypes returns the valid damage types mentioned in the provided values,
+// like the bludgeoning, piercing and slashing of "bludgeoning, piercing, and
+// slashing from nonmagical attacks".
+func damageTypes(values []string) []string {
+	var types []string
+	for _, v := range values {
+		for _, word := range strings.Fields(strings.ToLower(v)) {
+			if damage.ValidType(word) {
+				types = append(types, word)
+			}
+		}
+	}
+
+	return types
+}
+
+// conditionNames returns the valid conditions among the provided values.
+func conditionNames(values []string) []string {
+	var names []string
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if conditions.Valid(v) {
+			names = append(names, v)
+		}
+	}
+
+	return names
+}
+
+// Creature turns the stat block into a monster.
+func (s StatBlock) Creature() (creature.Creature, error) {
+	if strings.TrimSpace(s.Name) == "" {
+		return creature.Creature{}, errMissingName
+	}
+	cr := string(s.ChallengeRating)
+	if cr != "" && !creature.ValidChallengeRating(cr) {
+		return creature.Creature{}, errInvalidCR
+	}
+
+	c := creature.Creature{
+		Name:             s.Name,
+		Kind:             creature.Monster,
+		CurrentHitPoints: s.HitPoints,
+		MaximumHitPoints: s.HitPoints,
+		Level:            1,
+		ProficiencyBonus: creature.ProficiencyBonusForChallengeRating(cr),
+		ArmorClass:       s.ArmorClass,
+		Defense:          armor.Defense{Manual: true},
+		Size:             strings.ToLower(s.Size),
+		Speed:            map[string]int(s.Speed),
+		Senses:           s.Senses,
+		Languages:        s.Languages,
+		HitPointsDice:    strings.ReplaceAll(s.HitDice, " ", ""),
+		Traits:           s.Special,
+		Reactions:        s.Reactions,
+		ChallengeRating:  cr,
+		ExperienceValue:  creature.ExperiencePerChallengeRating[cr],
+		Type:             strings.ToLower(s.Type),
+		Alignment:        s.Alignment,
+		LegendaryActions: s.Legendary,
+		Defenses: damage.Defenses{
+			Resistances:         damageTypes(s.DamageResistances),
+			Vulnerabilities:     damageTypes(s.DamageVulnerabilities),
+			Immunities:          damageTypes(s.DamageImmunities),
+			ConditionImmunities: conditionNames(s.ConditionImmunities),
+		},
+	}
+
+	for ability, score := range map[string]int{
+		abilities.Strength:     s.Strength,
+		abilities.Dexterity:    s.Dexterity,
+		abilities.Constitution: s.Constitution,
+		abilities.Intelligence: s.Intelligence,
+		abilities.Wisdom:       s.Wisdom,
+		abilities.Charisma:     s.Charisma,
+	} {
+		if abilities.OutOfRange(score) {
+			score = 10
+		}
+		c.Abilities.Set(ability, score)
+	}
+
+	c.SavingThrows = saves.SavingThrows{}
+	for save, bonus := range map[string]*int{
+		saves.Strength:     s.StrengthSave,
+		saves.Dexterity:    s.DexteritySave,
+		saves.Constitution: s.ConstitutionSave,
+		saves.Intelligence: s.IntelligenceSave,
+		saves.Wisdom:       s.WisdomSave,
+		saves.Charisma:     s.CharismaSave,
+	} {
+		if bonus != nil {
+			c.SavingThrows[save] = *bonus
+		}
+	}
+
+	c.Skills = skills.Skills{}
+	for name, bonus := range s.Skills {
+		name = strings.ReplaceAll(strings.ToLower(name), " ", "_")
+		if _, ok := skills.SkillToAbility[name]; ok {
+			c.Skills.Set(name, bonus)
+		}
+	}
+
+	c.PassivePerception = 10 + c.WisdomModifier
+	if m := passivePerception.FindStringSubmatch(s.Senses); m != nil {
+		c.PassivePerception, _ = strconv.Atoi(m[1])
+	}
+
+	for _, a := range s.Actions {
+		if strings.EqualFold(a.Name, "Multiattack") {
+			c.Multiattack = a.Description
+			continue
+		}
+		c.Actions = append(c.Actions, a)
+	}
+
+	return c, nil
+}
+
+// Monsters turns the provided JSON into monsters. The JSON is either a single
+// stat block, an array of stat blocks or an Open5e page, with the stat blocks
+// in its results.
+func Monsters(data []byte) ([]creature.Creature, error) {
+	data = bytes.TrimSpace(data)
+
+	var blocks []StatBlock
+	switch {
+	case len(data) == 0:
+		return nil, errNoMonsters
+	case data[0] == '[':
+		if err := json.Unmarshal(data, &blocks); err != nil {
+			return nil, errInvalidJSON
+		}
+	default:
+		var page struct {
+			Results []StatBlock `json:"results"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, errInvalidJSON
+		}
+		blocks = page.Results
+		if page.Results == nil {
+			var block StatBlock
+			if err := json.Unmarshal(data, &block); err != nil {
+				return nil, errInvalidJSON
+			}
+			blocks = []StatBlock{block}
+		}
+	}
+	if len(blocks) == 0 {
+		return nil, errNoMonsters
+	}
+
+	monsters := make([]creature.Creature, 0, len(blocks))
+	for _, b := range blocks {
+		c, err := b.Creature()
+		if err != nil {
+			return nil, err
+		}
+		monsters = append(monsters, c)
+	}
+
+	return monsters, nil
+}
@@ -0,0 +1,174 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/damage"
+	"github.com/aakordas/creature_manager/pkg/saves"
+	"github.com/aakordas/creature_manager/pkg/skills"
+)
+
+const goblin = `{
+	"slug": "goblin",
+	"name": "Goblin",
+	"size": "Small",
+	"type": "humanoid",
+	"subtype": "goblinoid",
+	"alignment": "neutral evil",
+	"armor_class": 15,
+	"armor_desc": "leather armor, shield",
+	"hit_points": 7,
+	"hit_dice": "2d6",
+	"speed": {"walk": 30},
+	"strength": 8,
+	"dexterity": 14,
+	"constitution": 10,
+	"intelligence": 10,
+	"wisdom": 8,
+	"charisma": 8,
+	"strength_save": null,
+	"dexterity_save": null,
+	"constitution_save": null,
+	"intelligence_save": null,
+	"wisdom_save": null,
+	"charisma_save": null,
+	"skills": {"stealth": 6},
+	"damage_vulnerabilities": "",
+	"damage_resistances": "",
+	"damage_immunities": "",
+	"condition_immunities": "",
+	"senses": "darkvision 60 ft., passive Perception 9",
+	"languages": "Common, Goblin",
+	"challenge_rating": "1/4",
+	"actions": [
+		{"name": "Scimitar", "desc": "Melee Weapon Attack: +4 to hit, reach 5 ft., one target. Hit: 5 (1d6 + 2) slashing damage.", "attack_bonus": 4, "damage_dice": "1d6", "damage_bonus": 2},
+		{"name": "Shortbow", "desc": "Ranged Weapon Attack: +4 to hit, range 80/320 ft., one target. Hit: 5 (1d6 + 2) piercing damage.", "attack_bonus": 4, "damage_dice": "1d6", "damage_bonus": 2}
+	],
+	"reactions": "",
+	"legendary_actions": "",
+	"special_abilities": [
+		{"name": "Nimble Escape", "desc": "The goblin can take the Disengage or Hide action as a bonus action on each of its turns."}
+	]
+}`
+
+const vampire = `{
+	"name": "Vampire",
+	"size": "Medium",
+	"type": "Undead",
+	"alignment": "lawful evil",
+	"armor_class": 16,
+	"hit_points": 144,
+	"hit_dice": "17d8+68",
+	"speed": {"walk": 30, "hover": false},
+	"strength": 18,
+	"dexterity": 18,
+	"constitution": 18,
+	"intelligence": 17,
+	"wisdom": 15,
+	"charisma": 18,
+	"dexterity_save": 9,
+	"wisdom_save": 7,
+	"charisma_save": 9,
+	"skills": {"perception": 7, "stealth": 9},
+	"damage_resistances": "necrotic; bludgeoning, piercing, and slashing from nonmagical attacks",
+	"condition_immunities": "",
+	"senses": "darkvision 120 ft., passive Perception 17",
+	"challenge_rating": 13,
+	"actions": [
+		{"name": "Multiattack", "desc": "The vampire makes two attacks, only one of which can be a bite attack."},
+		{"name": "Bite", "desc": "Melee Weapon Attack: +9 to hit."}
+	],
+	"legendary_actions": [
+		{"name": "Move", "desc": "The vampire moves up to its speed without provoking opportunity attacks."}
+	]
+}`
+
+func TestMonsters_Goblin(t *testing.T) {
+	monsters, err := Monsters([]byte(goblin))
+	if err != nil {
+		t.Fatalf("Monsters() error = %v", err)
+	}
+	if len(monsters) != 1 {
+		t.Fatalf("Monsters() returned %v monsters, want 1", len(monsters))
+	}
+	g := monsters[0]
+
+	if g.Name != "Goblin" || g.Kind != creature.Monster || g.Type != "humanoid" || g.Size != "small" {
+		t.Errorf("Unexpected description: %v, %v, %v, %v", g.Name, g.Kind, g.Type, g.Size)
+	}
+	if g.ArmorClass != 15 || !g.Defense.Manual {
+		t.Errorf("Unexpected armor class: %v, manual %v", g.ArmorClass, g.Defense.Manual)
+	}
+	if g.CurrentHitPoints != 7 || g.MaximumHitPoints != 7 || g.HitPointsDice != "2d6" {
+		t.Errorf("Unexpected hit points: %v/%v, %v", g.CurrentHitPoints, g.MaximumHitPoints, g.HitPointsDice)
+	}
+	if g.DexterityModifier != 2 || g.WisdomModifier != -1 {
+		t.Errorf("Unexpected modifiers: dexterity %v, wisdom %v", g.DexterityModifier, g.WisdomModifier)
+	}
+	if s := g.Skills[skills.Stealth]; s == nil || s.Value != 6 {
+		t.Errorf("Unexpected stealth: %v", s)
+	}
+	if len(g.SavingThrows) != 0 {
+		t.Errorf("Unexpected saving throws: %v", g.SavingThrows)
+	}
+	if g.PassivePerception != 9 {
+		t.Errorf("Unexpected passive perception: %v", g.PassivePerception)
+	}
+	if g.ChallengeRating != "1/4" || g.ExperienceValue != 50 || g.ProficiencyBonus != 2 {
+		t.Errorf("Unexpected challenge: %v, %v XP, +%v", g.ChallengeRating, g.ExperienceValue, g.ProficiencyBonus)
+	}
+	if len(g.Actions) != 2 || len(g.Traits) != 1 || len(g.LegendaryActions) != 0 || len(g.Reactions) != 0 {
+		t.Errorf("Unexpected actions: %v, traits: %v", g.Actions, g.Traits)
+	}
+}
+
+func TestMonsters_Vampire(t *testing.T) {
+	monsters, err := Monsters([]byte("[" + vampire + "," + goblin + "]"))
+	if err != nil {
+		t.Fatalf("Monsters() error = %v", err)
+	}
+	if len(monsters) != 2 {
+		t.Fatalf("Monsters() returned %v monsters, want 2", len(monsters))
+	}
+	v := monsters[0]
+
+	want := saves.SavingThrows{saves.Dexterity: 9, saves.Wisdom: 7, saves.Charisma: 9}
+	if !reflect.DeepEqual(v.SavingThrows, want) {
+		t.Errorf("Unexpected saving throws: %v, want %v", v.SavingThrows, want)
+	}
+	resistances := []string{damage.Necrotic, damage.Bludgeoning, damage.Piercing, damage.Slashing}
+	if !reflect.DeepEqual(v.Resistances, resistances) {
+		t.Errorf("Unexpected resistances: %v, want %v", v.Resistances, resistances)
+	}
+	if v.ChallengeRating != "13" || v.ExperienceValue != 10000 || v.ProficiencyBonus != 5 {
+		t.Errorf("Unexpected challenge: %v, %v XP, +%v", v.ChallengeRating, v.ExperienceValue, v.ProficiencyBonus)
+	}
+	if v.Multiattack == "" || len(v.Actions) != 1 || len(v.LegendaryActions) != 1 {
+		t.Errorf("Unexpected actions: %v, multiattack %q, legendary %v", v.Actions, v.Multiattack, v.LegendaryActions)
+	}
+	if !reflect.DeepEqual(v.Speed, map[string]int{"walk": 30}) {
+		t.Errorf("Unexpected speed: %v", v.Speed)
+	}
+}
+
+func TestMonsters_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"Empty", ""},
+		{"Not JSON", "goblin"},
+		{"Empty array", "[]"},
+		{"No name", `{"hit_points": 7}`},
+		{"Invalid challenge rating", `{"name": "Goblin", "challenge_rating": "1/3"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Monsters([]byte(tt.data)); err == nil {
+				t.Errorf("Monsters() expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package inventory
+
+// Currency holds the coins of a creature.
+type Currency struct {
+	Copper   int `json:"cp" bson:"cp"`
+	Silver   int `json:"sp" bson:"sp"`
+	Electrum int `json:"ep" bson:"ep"`
+	Gold     int `json:"gp" bson:"gp"`
+	Platinum int `json:"pp" bson:"pp"`
+}
+
+const (
+	// Copper means copper pieces.
+	Copper = "cp"
+	// Silver means silver pieces.
+	Silver = "sp"
+	// Electrum means electrum pieces.
+	Electrum = "ep"
+	// Gold means gold pieces.
+	Gold = "gp"
+	// Platinum means platinum pieces.
+	Platinum = "pp"
+)
+
+// CopperPerCoin maps a coin to its value in copper pieces.
+var CopperPerCoin = map[string]int{
+	Copper:   1,
+	Silver:   10,
+	Electrum: 50,
+	Gold:     100,
+	Platinum: 1000,
+}
+
+// ValidCoin checks if the provided value is a valid coin.
+func ValidCoin(c string) bool {
+	_, ok := CopperPerCoin[c]
+	return ok
+}
+
+// coin returns the number of coins of the provided kind.
+func (c *Currency) coin(kind string) *int {
+	switch kind {
+	case Copper:
+		return &c.Copper
+	case Silver:
+		return &c.Silver
+	case Electrum:
+		return &c.Electrum
+	case Gold:
+		return &c.Gold
+	case Platinum:
+		return &c.Platinum
+	default:
+		return nil
+	}
+}
+
+// Set sets the number of coins of the provided kind.
+func (c *Currency) Set(kind string, n int) error {
+	coin := c.coin(kind)
+	if coin == nil {
+		return errInvalidCoin
+	}
+	if n < 0 {
+		return errInvalidQuantity
+	}
+
+	*coin = n
+
+	return nil
+}
+
+// Coins returns the number of coins, of every kind.
+func (c Currency) Coins() int {
+	return c.Copper + c.Silver + c.Electrum + c.Gold + c.Platinum
+}
+
+// Value returns the total value of the coins, in copper pieces.
+func (c Currency) Value() int {
+	return c.Copper*CopperPerCoin[Copper] +
+		c.Silver*CopperPerCoin[Silver] +
+		c.Electrum*CopperPerCoin[Electrum] +
+		c.Gold*CopperPerCoin[Gold] +
+		c.Platinum*CopperPerCoin[Platinum]
+}
+
+// Convert exchanges up to n coins of one kind for coins of another kind. Only
+// the coins needed for whole coins of the new kind are exchanged.
+func (c *Currency) Convert(from, to string, n int) error {
+	source, target := c.coin(from), c.coin(to)
+	if source == nil || target == nil {
+		return errInvalidCoin
+	}
+	if n <= 0 || *source < n {
+		return errNotEnoughCoins
+	}
+
+	got := n * CopperPerCoin[from] / CopperPerCoin[to]
+	if got == 0 {
+		return errConversionTooLow
+	}
+
+	*source -= got * CopperPerCoin[to] / CopperPerCoin[from]
+	*target += got
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package inventory
+
+import (
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/armor"
+	"github.com/aakordas/creature_manager/pkg/weapons"
+)
+
+// Item models something a creature carries.
+type Item struct {
+	Name               string  `json:"name" bson:"name"`
+	Weight             float64 `json:"weight" bson:"weight"` // The weight of a single item, in pounds.
+	Quantity           int     `json:"quantity" bson:"quantity"`
+	Value              int     `json:"value" bson:"value"` // The value of a single item, in copper pieces.
+	Equipped           bool    `json:"equipped" bson:"equipped"`
+	RequiresAttunement bool    `json:"requires_attunement" bson:"requires_attunement"`
+	Attuned            bool    `json:"attuned" bson:"attuned"`
+
+	Armor  *armor.Armor    `json:"armor,omitempty" bson:"armor,omitempty"`   // Set if the item is a piece of armor or a shield.
+	Weapon *weapons.Weapon `json:"weapon,omitempty" bson:"weapon,omitempty"` // Set if the item is a weapon.
+}
+
+// Inventory holds the items and the coins of a creature.
+type Inventory struct {
+	Items    []Item   `json:"items,omitempty" bson:"items,omitempty"`
+	Currency Currency `json:"currency" bson:"currency"`
+}
+
+// MaximumAttunements indicates the maximum number of items a creature can be
+// attuned to at the same time.
+const MaximumAttunements = 3
+
+// Error is the error that gets returned when an inventory operation cannot be
+// performed.
+type Error struct {
+	Message string
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
+
+var (
+	errMissingItem      = Error{"The item is not in the inventory."}
+	errNotEnoughItems   = Error{"There are not enough items in the inventory."}
+	errNoAttunement     = Error{"The item does not require attunement."}
+	errTooManyAttuned   = Error{"The creature is already attuned to the maximum number of items."}
+	errInvalidItemName  = Error{"Please provide a valid item name."}
+	errInvalidArmor     = Error{"Please provide a valid armor type."}
+	errInvalidQuantity  = Error{"Please provide a valid quantity."}
+	errInvalidCoin      = Error{"Please provide a valid coin."}
+	errNotEnoughCoins   = Error{"There are not enough coins to convert."}
+	errConversionTooLow = Error{"The coins are not enough for a single coin of the requested kind."}
+)
+
+// Find returns the index of the item with the provided name, or -1 if there is
+// no such item.
+func (inv *Inventory) Find(name string) int {
+	for i, item := range inv.Items {
+		if strings.EqualFold(item.Name, name) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Add puts the item in the inventory. If an item with the same name already
+// exists only its quantity increases.
+func (inv *Inventory) Add(item Item) error {
+	if strings.TrimSpace(item.Name) == "" {
+		return errInvalidItemName
+	}
+	if item.Quantity == 0 {
+		item.Quantity = 1
+	}
+	if item.Quantity < 0 || item.Weight < 0 || item.Value < 0 {
+		return errInvalidQuantity
+	}
+	if item.Armor != nil && !armor.ValidType(item.Armor.Type) {
+		return errInvalidArmor
+	}
+	if item.Weapon != nil {
+		if err := item.Weapon.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if i := inv.Find(item.Name); i >= 0 {
+		inv.Items[i].Quantity += item.Quantity
+		return nil
+	}
+
+	// A new item is never equipped or attuned.
+	item.Equipped = false
+	item.Attuned = false
+	inv.Items = append(inv.Items, item)
+
+	return nil
+}
+
+// Remove takes the provided quantity of an item out of the inventory and
+// returns what was removed. A quantity of 0 removes every such item.
+func (inv *Inventory) Remove(name string, quantity int) (Item, error) {
+	i := inv.Find(name)
+	if i < 0 {
+		return Item{}, errMissingItem
+	}
+	if quantity < 0 {
+		return Item{}, errInvalidQuantity
+	}
+
+	item := inv.Items[i]
+	if quantity == 0 || quantity == item.Quantity {
+		inv.Items = append(inv.Items[:i], inv.Items[i+1:]...)
+		return item, nil
+	}
+	if quantity > item.Quantity {
+		return Item{}, errNotEnoughItems
+	}
+
+	inv.Items[i].Quantity -= quantity
+	item.Quantity = quantity
+	item.Equipped = false
+	item.Attuned = false
+
+	return item, nil
+}
+
+// Equip equips or unequips the item with the provided name.
+func (inv *Inventory) Equip(name string, equipped bool) error {
+	i := inv.Find(name)
+	if i < 0 {
+		return errMissingItem
+	}
+
+	inv.Items[i].Equipped = equipped
+
+	return nil
+}
+
+// EquippedArmor returns the armor and shields that are equipped.
+func (inv *Inventory) EquippedArmor() []armor.Armor {
+	var worn []armor.Armor
+	for _, item := range inv.Items {
+		if item.Equipped && item.Armor != nil {
+			worn = append(worn, *item.Armor)
+		}
+	}
+
+	return worn
+}
+
+// Attunements returns the number of items the creature is attuned to.
+func (inv *Inventory) Attunements() int {
+	n := 0
+	for _, item := range inv.Items {
+		if item.Attuned {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Attune attunes the creature to the item with the provided name, or ends the
+// attunement.
+func (inv *Inventory) Attune(name string, attuned bool) error {
+	i := inv.Find(name)
+	if i < 0 {
+		return errMissingItem
+	}
+	if !attuned {
+		inv.Items[i].Attuned = false
+		return nil
+	}
+
+	if !inv.Items[i].RequiresAttunement {
+		return errNoAttunement
+	}
+	if !inv.Items[i].Attuned && inv.Attunements() >= MaximumAttunements {
+		return errTooManyAttuned
+	}
+
+	inv.Items[i].Attuned = true
+
+	return nil
+}
+
+// coinsPerPound indicates how many coins of any kind weigh a pound.
+const coinsPerPound = 50
+
+// Weight returns the total weight of the inventory, coins included, in pounds.
+func (inv *Inventory) Weight() float64 {
+	weight := 0.0
+	for _, item := range inv.Items {
+		weight += item.Weight * float64(item.Quantity)
+	}
+
+	return weight + float64(inv.Currency.Coins())/coinsPerPound
+}
+
+const (
+	// Unencumbered means the creature carries nothing that slows it down.
+	Unencumbered = "unencumbered"
+	// Encumbered means the speed of the creature drops by 10 feet.
+	Encumbered = "encumbered"
+	// HeavilyEncumbered means the speed of the creature drops by 20 feet and
+	// it has disadvantage on physical checks, attacks and saves.
+	HeavilyEncumbered = "heavily_encumbered"
+	// OverCapacity means the creature carries more than it can.
+	OverCapacity = "over_capacity"
+)
+
+// CarryingCapacity returns the weight, in pounds, a creature with the provided
+// Strength score can carry.
+func CarryingCapacity(strength int) float64 {
+	return float64(15 * strength)
+}
+
+// Encumbrance returns how encumbered a creature with the provided Strength
+// score is when carrying the provided weight.
+func Encumbrance(strength int, weight float64) string {
+	switch {
+	case weight > CarryingCapacity(strength):
+		return OverCapacity
+	case weight > float64(10*strength):
+		return HeavilyEncumbered
+	case weight > float64(5*strength):
+		return Encumbered
+	default:
+		return Unencumbered
+	}
+}
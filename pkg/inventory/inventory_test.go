@@ -0,0 +1,132 @@
+package inventory
+
+import "testing"
+
+func TestInventory_AddRemove(t *testing.T) {
+	inv := &Inventory{}
+
+	if err := inv.Add(Item{Name: "Torch", Weight: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.Add(Item{Name: "torch", Quantity: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if got := inv.Items[0].Quantity; len(inv.Items) != 1 || got != 5 {
+		t.Fatalf("Add() merged into %v items with quantity %v, want 1 and 5", len(inv.Items), got)
+	}
+	if err := inv.Add(Item{}); err == nil {
+		t.Errorf("Add() accepted an item without a name.")
+	}
+
+	tests := []struct {
+		name     string
+		item     string
+		quantity int
+		wantErr  bool
+		wantLeft int
+	}{
+		{"Missing item", "Rope", 1, true, 5},
+		{"Too many", "Torch", 6, true, 5},
+		{"Some", "Torch", 2, false, 3},
+		{"Negative", "Torch", -1, true, 3},
+		{"All", "Torch", 0, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := inv.Remove(tt.item, tt.quantity)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Remove() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			left := 0
+			if i := inv.Find("Torch"); i >= 0 {
+				left = inv.Items[i].Quantity
+			}
+			if left != tt.wantLeft {
+				t.Errorf("Remove() left %v torches, want %v", left, tt.wantLeft)
+			}
+		})
+	}
+}
+
+func TestInventory_Attune(t *testing.T) {
+	inv := &Inventory{}
+	for _, name := range []string{"Ring", "Cloak", "Amulet", "Boots"} {
+		inv.Add(Item{Name: name, RequiresAttunement: true})
+	}
+	inv.Add(Item{Name: "Rope"})
+
+	tests := []struct {
+		name    string
+		item    string
+		attuned bool
+		wantErr bool
+	}{
+		{"First", "Ring", true, false},
+		{"Again", "Ring", true, false},
+		{"Second", "Cloak", true, false},
+		{"Third", "Amulet", true, false},
+		{"Fourth", "Boots", true, true},
+		{"No attunement needed", "Rope", true, true},
+		{"Missing item", "Wand", true, true},
+		{"End attunement", "Ring", false, false},
+		{"Fourth after ending one", "Boots", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := inv.Attune(tt.item, tt.attuned); (err != nil) != tt.wantErr {
+				t.Errorf("Attune() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEncumbrance(t *testing.T) {
+	tests := []struct {
+		name     string
+		strength int
+		weight   float64
+		want     string
+	}{
+		{"Light load", 10, 50, Unencumbered},
+		{"Encumbered", 10, 51, Encumbered},
+		{"Heavily encumbered", 10, 101, HeavilyEncumbered},
+		{"At capacity", 10, 150, HeavilyEncumbered},
+		{"Over capacity", 10, 151, OverCapacity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Encumbrance(tt.strength, tt.weight); got != tt.want {
+				t.Errorf("Encumbrance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrency_Convert(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency Currency
+		from, to string
+		n        int
+		want     Currency
+		wantErr  bool
+	}{
+		{"Gold to copper", Currency{Gold: 2}, Gold, Copper, 1, Currency{Gold: 1, Copper: 100}, false},
+		{"Copper to gold", Currency{Copper: 250}, Copper, Gold, 250, Currency{Copper: 50, Gold: 2}, false},
+		{"Silver to electrum", Currency{Silver: 7}, Silver, Electrum, 7, Currency{Silver: 2, Electrum: 1}, false},
+		{"Not enough for a coin", Currency{Copper: 50}, Copper, Gold, 50, Currency{Copper: 50}, true},
+		{"Not enough coins", Currency{Gold: 1}, Gold, Silver, 2, Currency{Gold: 1}, true},
+		{"Invalid coin", Currency{Gold: 1}, Gold, "dp", 1, Currency{Gold: 1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.currency
+			if err := c.Convert(tt.from, tt.to, tt.n); (err != nil) != tt.wantErr {
+				t.Fatalf("Convert() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if c != tt.want {
+				t.Errorf("Convert() = %+v, want %+v", c, tt.want)
+			}
+		})
+	}
+}
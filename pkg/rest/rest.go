@@ -0,0 +1,143 @@
+package rest
+
+import (
+	"sort"
+
+	"github.com/aakordas/creature_manager/pkg/classes"
+	"github.com/aakordas/creature_manager/pkg/creature"
+)
+
+// Roller rolls a die with the provided number of sides.
+type Roller func(sides int) int
+
+// NotEnoughHitDiceError is the error that gets returned when a creature tries
+// to spend more hit dice of a class than it has left.
+type NotEnoughHitDiceError struct {
+	Class string // The class whose hit dice were requested.
+}
+
+func (e NotEnoughHitDiceError) Error() string {
+	return "Not enough hit dice left for class " + e.Class + "."
+}
+
+// ShortRest spends the requested number of hit dice per class, heals the
+// creature and recharges its pact slots and the resources that recharge on a
+// short rest. Classes with no hit dice to spend are skipped. It returns the
+// results of every hit die rolled.
+func ShortRest(c *creature.Creature, spend map[string]int, roll Roller) ([]int, error) {
+	for class, n := range spend {
+		if n < 0 || c.HitDice[class] < n {
+			return nil, NotEnoughHitDiceError{class}
+		}
+	}
+
+	if c.HitDice == nil {
+		c.HitDice = make(map[string]int)
+	}
+
+	var rolls []int
+	for _, class := range sortedClasses(spend) {
+		if spend[class] == 0 {
+			continue
+		}
+
+		sides := classes.HitDie[class]
+		for i := 0; i < spend[class]; i++ {
+			r := roll(sides)
+			rolls = append(rolls, r)
+
+			// A hit die never takes hit points away.
+			healed := r + c.ConstitutionModifier
+			if healed > 0 {
+				c.CurrentHitPoints += healed
+			}
+		}
+		c.HitDice[class] -= spend[class]
+	}
+
+	if c.MaximumHitPoints > 0 && c.CurrentHitPoints > c.MaximumHitPoints {
+		c.CurrentHitPoints = c.MaximumHitPoints
+	}
+
+	recharge(c, creature.ShortRest)
+	if c.Spellcasting != nil {
+		c.Spellcasting.Restore(false)
+	}
+
+	return rolls, nil
+}
+
+// LongRest restores the creature's hit points, half of its hit dice, its spell
+// slots and every resource, and reduces its exhaustion level by one.
+func LongRest(c *creature.Creature) {
+	if c.MaximumHitPoints > 0 {
+		c.CurrentHitPoints = c.MaximumHitPoints
+	}
+
+	regainHitDice(c)
+	recharge(c, creature.LongRest)
+	if c.Spellcasting != nil {
+		c.Spellcasting.Restore(true)
+	}
+
+	if c.Exhaustion > 0 {
+		c.Exhaustion--
+	}
+}
+
+// regainHitDice gives back up to half of the creature's total hit dice (minimum
+// one), larger dice first.
+func regainHitDice(c *creature.Creature) {
+	total := 0
+	for _, levels := range c.Classes {
+		total += levels
+	}
+	if total == 0 {
+		return
+	}
+
+	regain := total / 2
+	if regain < 1 {
+		regain = 1
+	}
+
+	if c.HitDice == nil {
+		c.HitDice = make(map[string]int)
+	}
+
+	order := sortedClasses(c.Classes)
+	sort.SliceStable(order, func(i, j int) bool {
+		return classes.HitDie[order[i]] > classes.HitDie[order[j]]
+	})
+
+	for _, class := range order {
+		missing := c.Classes[class] - c.HitDice[class]
+		if missing > regain {
+			missing = regain
+		}
+		c.HitDice[class] += missing
+		regain -= missing
+	}
+}
+
+// recharge recharges the resources of the creature that recharge on the given
+// kind of rest. A long rest recharges everything.
+func recharge(c *creature.Creature, kind string) {
+	for _, r := range c.Resources {
+		if kind == creature.LongRest || r.Recharge == kind {
+			r.Current = r.Maximum
+		}
+	}
+}
+
+// sortedClasses returns the keys of m sorted, so that the order hit dice get
+// rolled or regained in is stable.
+func sortedClasses(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
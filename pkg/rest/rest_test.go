@@ -0,0 +1,138 @@
+package rest
+
+import (
+	"testing"
+
+	"github.com/aakordas/creature_manager/pkg/abilities"
+	"github.com/aakordas/creature_manager/pkg/classes"
+	"github.com/aakordas/creature_manager/pkg/creature"
+)
+
+// maxRoll always rolls the highest value of the die.
+func maxRoll(sides int) int {
+	return sides
+}
+
+func TestShortRest(t *testing.T) {
+	tests := []struct {
+		name       string
+		hitPoints  int
+		modifier   int
+		spend      map[string]int
+		wantHP     int
+		wantLeft   int
+		wantErr    bool
+		wantRolled int
+	}{
+		{"No dice", 5, 2, map[string]int{}, 5, 3, false, 0},
+		{"One die", 5, 2, map[string]int{classes.Fighter: 1}, 17, 2, false, 1},
+		{"Capped at maximum", 25, 2, map[string]int{classes.Fighter: 2}, 30, 1, false, 2},
+		{"Negative modifier", 5, -20, map[string]int{classes.Fighter: 1}, 5, 2, false, 1},
+		{"Too many dice", 5, 2, map[string]int{classes.Fighter: 4}, 5, 3, true, 0},
+		{"Unknown class", 5, 2, map[string]int{classes.Wizard: 1}, 5, 3, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &creature.Creature{
+				CurrentHitPoints: tt.hitPoints,
+				MaximumHitPoints: 30,
+				Abilities:        abilities.Abilities{ConstitutionModifier: tt.modifier},
+				Classes:          classes.Classes{classes.Fighter: 3},
+				HitDice:          map[string]int{classes.Fighter: 3},
+			}
+
+			rolls, err := ShortRest(c, tt.spend, maxRoll)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ShortRest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if c.CurrentHitPoints != tt.wantHP {
+				t.Errorf("ShortRest() hit points = %v, want %v", c.CurrentHitPoints, tt.wantHP)
+			}
+			if c.HitDice[classes.Fighter] != tt.wantLeft {
+				t.Errorf("ShortRest() hit dice left = %v, want %v", c.HitDice[classes.Fighter], tt.wantLeft)
+			}
+			if len(rolls) != tt.wantRolled {
+				t.Errorf("ShortRest() rolled %v dice, want %v", len(rolls), tt.wantRolled)
+			}
+		})
+	}
+}
+
+func TestShortRestRecharge(t *testing.T) {
+	c := &creature.Creature{
+		Resources: map[string]*creature.Resource{
+			"action_surge": {Current: 0, Maximum: 1, Recharge: creature.ShortRest},
+			"indomitable":  {Current: 0, Maximum: 1, Recharge: creature.LongRest},
+		},
+	}
+
+	if _, err := ShortRest(c, nil, maxRoll); err != nil {
+		t.Fatal(err)
+	}
+	if c.Resources["action_surge"].Current != 1 {
+		t.Errorf("Short rest resource was not recharged.")
+	}
+	if c.Resources["indomitable"].Current != 0 {
+		t.Errorf("Long rest resource was recharged after a short rest.")
+	}
+}
+
+func TestShortRestWithoutHitDice(t *testing.T) {
+	c := &creature.Creature{CurrentHitPoints: 5}
+
+	rolls, err := ShortRest(c, map[string]int{classes.Fighter: 0}, maxRoll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rolls) != 0 || c.CurrentHitPoints != 5 {
+		t.Errorf("ShortRest() rolled %v, hit points = %v, want no rolls and 5", rolls, c.CurrentHitPoints)
+	}
+	if _, ok := c.HitDice[classes.Fighter]; ok {
+		t.Errorf("ShortRest() added hit dice of a class the creature lacks.")
+	}
+}
+
+func TestLongRest(t *testing.T) {
+	tests := []struct {
+		name           string
+		classes        classes.Classes
+		hitDice        map[string]int
+		exhaustion     int
+		wantHitDice    map[string]int
+		wantExhaustion int
+	}{
+		{"First level", classes.Classes{classes.Wizard: 1}, map[string]int{}, 0,
+			map[string]int{classes.Wizard: 1}, 0},
+		{"Half of the dice", classes.Classes{classes.Fighter: 6}, map[string]int{classes.Fighter: 0}, 2,
+			map[string]int{classes.Fighter: 3}, 1},
+		{"Never above the level", classes.Classes{classes.Fighter: 6}, map[string]int{classes.Fighter: 5}, 0,
+			map[string]int{classes.Fighter: 6}, 0},
+		{"Larger dice first", classes.Classes{classes.Barbarian: 2, classes.Wizard: 2}, map[string]int{}, 0,
+			map[string]int{classes.Barbarian: 2, classes.Wizard: 0}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &creature.Creature{
+				CurrentHitPoints: 1,
+				MaximumHitPoints: 20,
+				Classes:          tt.classes,
+				HitDice:          tt.hitDice,
+				Exhaustion:       tt.exhaustion,
+			}
+
+			LongRest(c)
+
+			if c.CurrentHitPoints != c.MaximumHitPoints {
+				t.Errorf("LongRest() hit points = %v, want %v", c.CurrentHitPoints, c.MaximumHitPoints)
+			}
+			for class, want := range tt.wantHitDice {
+				if c.HitDice[class] != want {
+					t.Errorf("LongRest() %v hit dice = %v, want %v", class, c.HitDice[class], want)
+				}
+			}
+			if c.Exhaustion != tt.wantExhaustion {
+				t.Errorf("LongRest() exhaustion = %v, want %v", c.Exhaustion, tt.wantExhaustion)
+			}
+		})
+	}
+}
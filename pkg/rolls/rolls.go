@@ -0,0 +1,93 @@
+package rolls
+
+import "time"
+
+const (
+	// Public means a roll everyone in the campaign sees.
+	Public = "public"
+	// GM means a roll only the roller and the dungeon master see.
+	GM = "gm"
+	// Blind means a roll only the dungeon master sees. The roller knows that
+	// they rolled, but not the result.
+	Blind = "blind"
+	// Self means a roll only the roller sees.
+	Self = "self"
+)
+
+// Roll models a roll made in a campaign.
+type Roll struct {
+	ID         string    `json:"id" bson:"_id"`
+	Campaign   string    `json:"campaign" bson:"campaign"`
+	User       string    `json:"user" bson:"user"`
+	Count      int       `json:"count" bson:"count"`
+	Sides      int       `json:"sides" bson:"sides"`
+	Result     int       `json:"result" bson:"result"`
+	Visibility string    `json:"visibility" bson:"visibility"`
+	Revealed   bool      `json:"revealed" bson:"revealed"` // Whether a hidden roll was later made public.
+	Time       time.Time `json:"time" bson:"time"`
+
+	Hidden bool `json:"hidden,omitempty" bson:"-"` // Whether the result is hidden from whoever sees the roll.
+}
+
+// Error is the error that gets returned when a roll cannot be revealed.
+type Error struct {
+	Message string
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
+
+var (
+	errAlreadyPublic = Error{"The roll is already public."}
+	errCannotReveal  = Error{"Only the dungeon master can reveal this roll."}
+)
+
+// ValidVisibility checks whether the provided visibility is valid.
+func ValidVisibility(visibility string) bool {
+	switch visibility {
+	case Public, GM, Blind, Self:
+		return true
+	default:
+		return false
+	}
+}
+
+// View returns the roll the way the provided user sees it, and whether they see
+// it at all. dm tells whether the user is the dungeon master of the campaign.
+func (r Roll) View(user string, dm bool) (Roll, bool) {
+	roller := user == r.User
+	if r.Revealed || r.Visibility == Public {
+		return r, true
+	}
+
+	switch r.Visibility {
+	case GM:
+		return r, roller || dm
+	case Blind:
+		if dm {
+			return r, true
+		}
+		r.Result = 0
+		r.Hidden = true
+		return r, roller
+	case Self:
+		return r, roller
+	default:
+		return r, false
+	}
+}
+
+// Reveal makes a hidden roll public. The dungeon master can reveal every roll,
+// while the roller can reveal only the rolls they have seen.
+func (r *Roll) Reveal(user string, dm bool) error {
+	if r.Revealed || r.Visibility == Public {
+		return errAlreadyPublic
+	}
+	if !dm && (user != r.User || r.Visibility == Blind) {
+		return errCannotReveal
+	}
+
+	r.Revealed = true
+	return nil
+}
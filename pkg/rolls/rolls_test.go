@@ -0,0 +1,78 @@
+package rolls
+
+import "testing"
+
+func TestRoll_View(t *testing.T) {
+	type view struct {
+		visible bool
+		hidden  bool
+	}
+	tests := []struct {
+		name       string
+		visibility string
+		revealed   bool
+		roller     view
+		dm         view
+		other      view
+	}{
+		{"Public", Public, false, view{true, false}, view{true, false}, view{true, false}},
+		{"GM only", GM, false, view{true, false}, view{true, false}, view{false, false}},
+		{"Blind", Blind, false, view{true, true}, view{true, false}, view{false, false}},
+		{"Self", Self, false, view{true, false}, view{false, false}, view{false, false}},
+		{"Revealed blind", Blind, true, view{true, false}, view{true, false}, view{true, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Roll{User: "frodo", Count: 1, Sides: 20, Result: 17, Visibility: tt.visibility, Revealed: tt.revealed}
+
+			for _, c := range []struct {
+				user string
+				dm   bool
+				want view
+			}{
+				{"frodo", false, tt.roller},
+				{"gandalf", true, tt.dm},
+				{"sam", false, tt.other},
+			} {
+				got, visible := r.View(c.user, c.dm)
+				if visible != c.want.visible || (visible && got.Hidden != c.want.hidden) {
+					t.Errorf("Roll.View(%q) = %v, %v, want %v, %v", c.user, got.Hidden, visible, c.want.hidden, c.want.visible)
+				}
+				if visible && !got.Hidden && got.Result != 17 {
+					t.Errorf("Roll.View(%q).Result = %v, want 17", c.user, got.Result)
+				}
+				if visible && got.Hidden && got.Result != 0 {
+					t.Errorf("Roll.View(%q).Result = %v, want it hidden", c.user, got.Result)
+				}
+			}
+		})
+	}
+}
+
+func TestRoll_Reveal(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility string
+		user       string
+		dm         bool
+		want       error
+	}{
+		{"Roller reveals a GM roll", GM, "frodo", false, nil},
+		{"Roller reveals a self roll", Self, "frodo", false, nil},
+		{"Roller reveals a blind roll", Blind, "frodo", false, errCannotReveal},
+		{"Dungeon master reveals a blind roll", Blind, "gandalf", true, nil},
+		{"Someone else reveals a GM roll", GM, "sam", false, errCannotReveal},
+		{"Public roll", Public, "gandalf", true, errAlreadyPublic},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Roll{User: "frodo", Visibility: tt.visibility}
+			if err := r.Reveal(tt.user, tt.dm); err != tt.want {
+				t.Fatalf("Roll.Reveal() error = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && !r.Revealed {
+				t.Errorf("Roll.Reveal() left the roll hidden")
+			}
+		})
+	}
+}
@@ -0,0 +1,451 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aakordas/creature_manager/pkg/archive"
+	"github.com/aakordas/creature_manager/pkg/campaign"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// archiveRoutes initializes the routes that export and import whole archives.
+// The archives of a single creature or campaign are served by creatureRoutes
+// and campaignRoutes.
+func archiveRoutes(r *mux.Router) *mux.Router {
+	api := r.PathPrefix("/api/v1/").Subrouter()
+	api.Use(requireUser)
+
+	api.HandleFunc("/export", ExportAll).Methods(http.MethodGet)
+	api.HandleFunc("/import", ImportArchive).Methods(http.MethodPost)
+
+	return r
+}
+
+// CollectArchive returns the archive of the campaign with the provided name,
+// along with its parties and creatures, or of the whole database if the name
+// is empty.
+func CollectArchive(name string) (archive.Archive, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 4*contextTimeout)
+	defer cancel()
+
+	if name == "" {
+		return collectArchive(ctx, bson.M{}, bson.M{})
+	}
+
+	return collectArchive(ctx, bson.M{"name": name}, bson.M{"campaign": name})
+}
+
+// collectArchive returns the archive of the campaigns and the creatures the
+// provided filters match, along with the parties of the campaigns.
+func collectArchive(ctx context.Context, campaignsFilter, creaturesFilter bson.M) (archive.Archive, error) {
+	var a archive.Archive
+
+	campaigns := []campaign.Campaign{}
+	if err := findAll(ctx, campaignsCollection, campaignsFilter, &campaigns); err != nil {
+		return a, err
+	}
+
+	names := bson.A{}
+	for _, c := range campaigns {
+		names = append(names, c.Name)
+	}
+	parties := []campaign.Party{}
+	if err := findAll(ctx, partiesCollection, bson.M{"campaign": bson.M{"$in": names}}, &parties); err != nil {
+		return a, err
+	}
+
+	creatures := []creature.Creature{}
+	if err := findAll(ctx, players, creaturesFilter, &creatures); err != nil {
+		return a, err
+	}
+
+	return archive.New(campaigns, parties, creatures, time.Now().UTC()), nil
+}
+
+// findAll decodes every document of the provided collection that the filter
+// matches in the provided slice.
+func findAll(ctx context.Context, collection string, filter bson.M, v interface{}) error {
+	cursor, err := playersDatabase.Collection(collection).Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	return cursor.All(ctx, v)
+}
+
+// StoreArchive stores the campaigns, creatures and parties of the provided
+// archive, handling the ones whose name is taken as the conflict tells. The
+// archive is changed along with the renamed entries, so that their mentions
+// follow them.
+//
+// The imports of a user, if any, are limited to the campaigns they run and to
+// their own creatures outside of any campaign. They become the dungeon master
+// of the new campaigns and the owner of the new creatures outside of any
+// campaign, and they can overwrite only what they could change anyway.
+func StoreArchive(a *archive.Archive, user, conflict string) (archive.Report, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 4*contextTimeout)
+	defer cancel()
+
+	report := archive.Report{
+		Imported: []string{},
+		Skipped:  []string{},
+		Renamed:  map[string]string{},
+	}
+	if err := storeCampaigns(ctx, a, user, conflict, &report); err != nil {
+		return report, err
+	}
+	if err := storeCreatures(ctx, a, user, conflict, &report); err != nil {
+		return report, err
+	}
+	if err := storeParties(ctx, a, user, conflict, &report); err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+// storeCampaigns stores the campaigns of the provided archive.
+func storeCampaigns(ctx context.Context, a *archive.Archive, user, conflict string, report *archive.Report) error {
+	col := playersDatabase.Collection(campaignsCollection)
+
+	for i := range a.Campaigns {
+		c := a.Campaigns[i]
+		entry := archive.Entry("campaigns", "", c.Name)
+
+		var existing campaign.Campaign
+		err := col.FindOne(ctx, bson.M{"name": c.Name}).Decode(&existing)
+		switch {
+		case err == mongo.ErrNoDocuments:
+		case err != nil:
+			return err
+		case conflict == archive.Rename:
+			old := c.Name
+			c.Name = archive.FreeName(old, func(name string) bool {
+				return campaignExists(ctx, name)
+			})
+			a.RenameCampaign(old, c.Name)
+			report.Renamed[entry] = c.Name
+		case conflict == archive.Overwrite && (user == "" || existing.DungeonMaster == user):
+		default:
+			report.Skipped = append(report.Skipped, entry)
+			continue
+		}
+
+		if user != "" {
+			c.DungeonMaster = user
+			delete(c.Users, user)
+		}
+		if _, err := col.ReplaceOne(ctx, bson.M{"name": c.Name}, c, options.Replace().SetUpsert(true)); err != nil {
+			return err
+		}
+
+		report.Imported = append(report.Imported, entry)
+	}
+
+	return nil
+}
+
+// storeCreatures stores the creatures of the provided archive. New creatures
+// keep their ID, unless another creature has it already, while the ones that
+// overwrite an existing creature take its ID and history.
+func storeCreatures(ctx context.Context, a *archive.Archive, user, conflict string, report *archive.Report) error {
+	col := playersDatabase.Collection(players)
+
+	for i := range a.Creatures {
+		c := a.Creatures[i]
+		entry := archive.Entry("creatures", c.Campaign, c.Name)
+
+		_, err := primitive.ObjectIDFromHex(c.Name)
+		if !creature.ValidName(c.Name) || err == nil || !mayImport(ctx, user, c.Campaign) {
+			report.Skipped = append(report.Skipped, entry)
+			continue
+		}
+		if user != "" && c.Campaign == "" {
+			c.Owner = user
+		}
+
+		var existing creature.Creature
+		err = findPlayer(ctx, col, c.Campaign, c.Name).Decode(&existing)
+		switch {
+		case err == mongo.ErrNoDocuments:
+			err = insertArchived(ctx, col, c)
+		case err != nil:
+		case conflict == archive.Rename:
+			name := archive.FreeName(c.Name, func(name string) bool {
+				return findPlayer(ctx, col, c.Campaign, name).Err() != mongo.ErrNoDocuments ||
+					archived(a, c.Campaign, name)
+			})
+			a.RenameCreature(c.Campaign, c.Name, name)
+			report.Renamed[entry] = name
+			c.Name = name
+			err = insertArchived(ctx, col, c)
+		case conflict == archive.Overwrite && (user == "" || canEdit(ctx, user, &existing)):
+			err = replaceCreature(ctx, user, &existing, c)
+		default:
+			report.Skipped = append(report.Skipped, entry)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		report.Imported = append(report.Imported, entry)
+	}
+
+	return nil
+}
+
+// insertArchived inserts the provided creature of an archive, with a new ID if
+// its own is missing or taken.
+func insertArchived(ctx context.Context, col *mongo.Collection, c creature.Creature) error {
+	if c.ID.IsZero() || col.FindOne(ctx, bson.M{"_id": c.ID}).Err() != mongo.ErrNoDocuments {
+		c.ID = primitive.NewObjectID()
+	}
+
+	_, err := col.InsertOne(ctx, c)
+	return err
+}
+
+// archived checks whether the provided archive has a creature with the
+// provided name in the provided campaign.
+func archived(a *archive.Archive, campaign, name string) bool {
+	for _, c := range a.Creatures {
+		if c.Campaign == campaign && c.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// storeParties stores the parties of the provided archive, as long as their
+// campaign exists.
+func storeParties(ctx context.Context, a *archive.Archive, user, conflict string, report *archive.Report) error {
+	col := playersDatabase.Collection(partiesCollection)
+
+	for _, p := range a.Parties {
+		entry := archive.Entry("parties", p.Campaign, p.Name)
+		if p.Campaign == "" || !campaignExists(ctx, p.Campaign) || !mayImport(ctx, user, p.Campaign) {
+			report.Skipped = append(report.Skipped, entry)
+			continue
+		}
+
+		f := bson.M{"name": p.Name, "campaign": p.Campaign}
+		err := col.FindOne(ctx, f).Err()
+		switch {
+		case err == mongo.ErrNoDocuments:
+		case err != nil:
+			return err
+		case conflict == archive.Rename:
+			p.Name = archive.FreeName(p.Name, func(name string) bool {
+				return col.FindOne(ctx, bson.M{"name": name, "campaign": p.Campaign}).Err() != mongo.ErrNoDocuments
+			})
+			report.Renamed[entry] = p.Name
+			f["name"] = p.Name
+		case conflict == archive.Overwrite:
+		default:
+			report.Skipped = append(report.Skipped, entry)
+			continue
+		}
+
+		if _, err := col.ReplaceOne(ctx, f, p, options.Replace().SetUpsert(true)); err != nil {
+			return err
+		}
+
+		report.Imported = append(report.Imported, entry)
+	}
+
+	return nil
+}
+
+// mayImport checks whether the provided user may import creatures and parties
+// in the provided campaign. Anything may be imported without a user.
+func mayImport(ctx context.Context, user, campaign string) bool {
+	return user == "" || campaign == "" || isDungeonMaster(ctx, user, campaign)
+}
+
+// requestFormat returns the format of the archive the request carries, from
+// its format query or its Content-Type, and the one it accepts in return,
+// from its format query or its Accept header. Either defaults to JSON.
+func requestFormat(r *http.Request, header string) string {
+	if format := r.FormValue("format"); format != "" {
+		return format
+	}
+	if strings.Contains(r.Header.Get(header), "yaml") {
+		return archive.YAML
+	}
+
+	return archive.JSON
+}
+
+// archiveResponse writes the provided archive in the format the request
+// accepts.
+func archiveResponse(w http.ResponseWriter, r *http.Request, a archive.Archive) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	format := requestFormat(r, "Accept")
+	if !archive.ValidFormat(format) {
+		sendErrorResponse(w, enc,
+			"invalid format",
+			"The format should be one of json or yaml.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	data, err := archive.Encode(a, format)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if format == archive.YAML {
+		w.Header().Set("Content-Type", "application/x-yaml")
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// archiveError writes the response to an archive that could not be collected.
+func archiveError(w http.ResponseWriter, err error) {
+	log.Println(err)
+	w.Header().Set("Content-Type", "application/json")
+	sendErrorResponse(w, json.NewEncoder(w), databaseError,
+		"An error was encountered while accessing the database.",
+		http.StatusInternalServerError,
+	)
+}
+
+// ExportCreature is the handler that returns the archive of the specified
+// creature alone.
+func ExportCreature(w http.ResponseWriter, r *http.Request) {
+	c, err := getPlayer(w, r)
+	if err != nil || !revealed(w, r, c) {
+		return
+	}
+
+	archiveResponse(w, r, archive.New(nil, nil, []creature.Creature{*c}, time.Now().UTC()))
+}
+
+// ExportCampaign is the handler that returns the archive of the specified
+// campaign, along with its parties and creatures. Only its dungeon master can
+// export it, since it reveals the statistics of every monster and NPC.
+func ExportCampaign(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	if !isDungeonMaster(ctx, currentUser(r), mux.Vars(r)["campaign"]) {
+		forbiddenResponse(w, "Only the dungeon master of the campaign can export it.")
+		return
+	}
+
+	a, err := CollectArchive(mux.Vars(r)["campaign"])
+	if err != nil {
+		archiveError(w, err)
+		return
+	}
+
+	archiveResponse(w, r, a)
+}
+
+// ExportAll is the handler that returns the archive of every campaign the user
+// who made the request runs, along with their own creatures outside of any
+// campaign.
+func ExportAll(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 4*contextTimeout)
+	defer cancel()
+
+	user := currentUser(r)
+
+	campaigns := []campaign.Campaign{}
+	err := findAll(ctx, campaignsCollection, bson.M{"dungeon_master": user}, &campaigns)
+	if err != nil {
+		archiveError(w, err)
+		return
+	}
+	names := bson.A{}
+	for _, c := range campaigns {
+		names = append(names, c.Name)
+	}
+
+	a, err := collectArchive(ctx, bson.M{"name": bson.M{"$in": names}}, bson.M{
+		"$or": bson.A{
+			bson.M{"campaign": bson.M{"$in": names}},
+			bson.M{"campaign": nil, "owner": user},
+		},
+	})
+	if err != nil {
+		archiveError(w, err)
+		return
+	}
+
+	archiveResponse(w, r, a)
+}
+
+// ImportArchive is the handler that imports the archive in the body of the
+// request, either as JSON or as YAML. The conflict query tells what happens to
+// the entries whose name is taken: skip, the default, overwrite or rename.
+func ImportArchive(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	format := requestFormat(r, "Content-Type")
+	conflict := r.FormValue("conflict")
+	if conflict == "" {
+		conflict = archive.Skip
+	}
+	if !archive.ValidFormat(format) || !archive.ValidConflict(conflict) {
+		sendErrorResponse(w, enc,
+			"invalid archive",
+			"The format should be one of json or yaml, and the conflict one of skip, overwrite or rename.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid archive",
+			"The body of the request could not be read.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	a, err := archive.Decode(data, format)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid archive",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	report, err := StoreArchive(a, currentUser(r), conflict)
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error inserting the new entry in the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	jsonEncode(w, enc, report)
+}
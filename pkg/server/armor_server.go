@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/aakordas/creature_manager/pkg/armor"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// calculateArmorClass returns the armor class of the creature, based on its
+// equipped armor, abilities and classes, unless it is set by hand.
+func calculateArmorClass(c *creature.Creature) int {
+	if c.Defense.Manual {
+		return c.ArmorClass
+	}
+
+	return armor.Class(c.Inventory.EquippedArmor(), c.Abilities, c.Classes, c.Defense)
+}
+
+// markManualArmorClasses marks the armor class of the creatures stored before
+// it could be calculated as set by hand, so that it is not replaced by the one
+// of their, possibly missing, armor.
+func markManualArmorClasses(db *mongo.Database) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	_, err := db.Collection(players).UpdateMany(ctx,
+		bson.M{"defense": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"defense.manual": true}},
+	)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// changeDefense fetches the requested creature, applies change to its defense
+// and stores it along with the recalculated armor class.
+func changeDefense(w http.ResponseWriter, r *http.Request, change func(d *armor.Defense)) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	player, err := getPlayer(w, r)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"Could not fetch the player.",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	change(&player.Defense)
+
+	f := unchangedFilter(player)
+	u := bson.M{
+		"$set": bson.M{
+			"defense":     player.Defense,
+			"armor_class": calculateArmorClass(player),
+		}}
+
+	setNoUpsert(w, r, enc, f, u)
+}
+
+// ClearArmorClass is the handler that stops using the armor class set by hand
+// for the requested creature and calculates it from its armor instead.
+func ClearArmorClass(w http.ResponseWriter, r *http.Request) {
+	changeDefense(w, r, func(d *armor.Defense) {
+		d.Manual = false
+	})
+}
+
+// SetArmorBonus is the handler that sets the flat bonus to the armor class of
+// the requested creature.
+func SetArmorBonus(w http.ResponseWriter, r *http.Request) {
+	value, err := strconv.Atoi(mux.Vars(r)["number"])
+	if err != nil {
+		enc := json.NewEncoder(w)
+		sendErrorResponse(w, enc,
+			"invalid value",
+			"Please provide a valid numeric value.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	changeDefense(w, r, func(d *armor.Defense) {
+		d.Bonus = value
+	})
+}
+
+// SetMageArmor is the handler that puts a Mage Armor style effect on the
+// requested creature.
+func SetMageArmor(w http.ResponseWriter, r *http.Request) {
+	changeDefense(w, r, func(d *armor.Defense) {
+		d.MageArmor = true
+	})
+}
+
+// RemoveMageArmor is the handler that ends a Mage Armor style effect on the
+// requested creature.
+func RemoveMageArmor(w http.ResponseWriter, r *http.Request) {
+	changeDefense(w, r, func(d *armor.Defense) {
+		d.MageArmor = false
+	})
+}
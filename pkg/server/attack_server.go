@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/campaign"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/dice"
+	"github.com/aakordas/creature_manager/pkg/weapons"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// attackResponse models the outcome of an attack of a creature.
+type attackResponse struct {
+	Weapon     string `json:"weapon" bson:"weapon"`
+	Ability    string `json:"ability" bson:"ability"`
+	Proficient bool   `json:"proficient" bson:"proficient"`
+	Target     string `json:"target,omitempty" bson:"target,omitempty"`
+
+	weapons.Result `bson:",inline"`
+}
+
+// setWeaponProficiency adds or removes a weapon proficiency of the requested
+// creature.
+func setWeaponProficiency(w http.ResponseWriter, r *http.Request, add bool) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	weapon := strings.ToLower(strings.TrimSpace(vars["weapon"]))
+	if weapon == "" {
+		sendErrorResponse(w, enc,
+			"invalid weapon",
+			"Please provide a valid weapon name or category.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	operator := "$addToSet"
+	if !add {
+		operator = "$pull"
+	}
+
+	f := creatureFilter(r)
+	u := bson.M{
+		operator: bson.M{
+			"weapon_proficiencies": weapon,
+		}}
+
+	setNoUpsert(w, r, enc, f, u)
+}
+
+// AddWeaponProficiency is the handler that makes the requested creature
+// proficient with a weapon category, like martial, or a single weapon.
+func AddWeaponProficiency(w http.ResponseWriter, r *http.Request) {
+	setWeaponProficiency(w, r, true)
+}
+
+// RemoveWeaponProficiency is the handler that removes a weapon proficiency of
+// the requested creature.
+func RemoveWeaponProficiency(w http.ResponseWriter, r *http.Request) {
+	setWeaponProficiency(w, r, false)
+}
+
+// attackRoll returns the d20 roll that the advantage and disadvantage queries
+// ask for. Having both of them cancels them out.
+func attackRoll(r *http.Request) dice.Dice {
+	advantage := r.FormValue("advantage") == "true"
+	disadvantage := r.FormValue("disadvantage") == "true"
+
+	switch {
+	case advantage && !disadvantage:
+		return dice.Advantage
+	case disadvantage && !advantage:
+		return dice.Disadvantage
+	default:
+		return dice.D20
+	}
+}
+
+// Attack is the handler that makes the requested creature attack with the
+// weapon of its inventory named in the weapon query. The attack is made either
+// against the armor class in the target_ac query or against the armor class of
+// the creature in the target query. The advantage, disadvantage and two_handed
+// queries modify the attack. The armor class of a target whose statistics are
+// hidden from the user is left out of the response.
+func Attack(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	player, err := getPlayer(w, r)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"Could not fetch the player.",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	name := r.FormValue("weapon")
+	i := player.Inventory.Find(name)
+	if i < 0 || player.Inventory.Items[i].Weapon == nil {
+		sendErrorResponse(w, enc,
+			"invalid weapon",
+			"The creature does not carry a weapon with the provided name.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	item := player.Inventory.Items[i]
+	weapon := *item.Weapon
+
+	response := attackResponse{
+		Weapon:     item.Name,
+		Ability:    weapon.Ability(player.Abilities),
+		Proficient: weapon.Proficient(item.Name, player.WeaponProficiencies),
+	}
+
+	var (
+		targetAC  int
+		concealed bool
+	)
+	if target := r.FormValue("target"); target != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		var c creature.Creature
+		err := findPlayer(ctx, playersDatabase.Collection(players), campaignOf(r), target).Decode(&c)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			sendErrorResponse(w, enc,
+				"player not found",
+				missingPlayerError{target}.Error(),
+				http.StatusNotFound,
+			)
+			return
+		}
+		targetAC = c.ArmorClass
+		concealed = campaign.Conceals(requestRole(r), c)
+		response.Target = c.Name
+	} else {
+		targetAC, err = strconv.Atoi(r.FormValue("target_ac"))
+		if err != nil {
+			sendErrorResponse(w, enc,
+				"invalid target",
+				"Please provide either a target creature or a target armor class.",
+				http.StatusBadRequest,
+			)
+			return
+		}
+	}
+
+	modifier := player.Abilities.Modifier(response.Ability)
+	attack := weapons.Attack{
+		Weapon:         weapon,
+		TwoHanded:      r.FormValue("two_handed") == "true",
+		AttackBonus:    modifier,
+		DamageModifier: modifier,
+		TargetAC:       targetAC,
+		Roll:           attackRoll(r),
+	}
+	if response.Proficient {
+		attack.AttackBonus += player.ProficiencyBonus
+	}
+
+	response.Result, err = attack.Resolve()
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid attack",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	if concealed {
+		response.TargetAC = 0
+	}
+
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, response)
+}
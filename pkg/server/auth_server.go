@@ -0,0 +1,553 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aakordas/creature_manager/pkg/campaign"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/users"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	usersCollection  = "users"
+	tokensCollection = "tokens"
+)
+
+// sessionCookie is the name of the cookie that holds the session token.
+const sessionCookie = "session"
+
+// credentials models the name and password a user registers or logs in with.
+type credentials struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// tokenResponse models a newly created token. The secret is returned only
+// once, when the token is created.
+type tokenResponse struct {
+	Secret string `json:"token" bson:"token"`
+
+	users.Token `bson:",inline"`
+}
+
+// authRoutes properly initializes the routes for the account part of the
+// server.
+func authRoutes(r *mux.Router) *mux.Router {
+	token := "{token:[a-zA-Z0-9 _-]+}"
+
+	api := r.PathPrefix("/api/v1/").Subrouter()
+	api.HandleFunc("/users", Register).Methods(http.MethodPost)
+	api.HandleFunc("/login", Login).Methods(http.MethodPost)
+
+	private := r.PathPrefix("/api/v1/").Subrouter()
+	private.Use(requireUser)
+	private.HandleFunc("/logout", Logout).Methods(http.MethodPost)
+	private.HandleFunc("/users/me", GetCurrentUser).Methods(http.MethodGet)
+	private.HandleFunc("/tokens", GetTokens).Methods(http.MethodGet)
+	private.HandleFunc("/tokens/"+token, AddToken).Methods(http.MethodPut)
+	private.HandleFunc("/tokens/"+token, DeleteToken).Methods(http.MethodDelete)
+
+	return r
+}
+
+// createAuthIndexes makes the user names and the token hashes unique.
+func createAuthIndexes(db *mongo.Database) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	for collection, key := range map[string]string{
+		usersCollection:  "name",
+		tokensCollection: "hash",
+	} {
+		_, err := db.Collection(collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.M{key: 1},
+			Options: options.Index().SetUnique(true),
+		})
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// tokenKey is the key of the token that authenticated a request in its
+// context.
+type tokenKey struct{}
+
+// requestToken returns the secret of the bearer token or the session cookie of
+// the request, if any.
+func requestToken(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(h, "Bearer "))
+	}
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		return c.Value
+	}
+
+	return ""
+}
+
+// authenticate stores the token that authenticates each request in its
+// context. Requests without a valid token are passed on as anonymous.
+func authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret := requestToken(r)
+		if secret == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		col := playersDatabase.Collection(tokensCollection)
+
+		var t users.Token
+		err := col.FindOne(ctx, bson.M{"hash": users.HashToken(secret)}).Decode(&t)
+		if err != nil || t.Expired(time.Now()) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tokenKey{}, t)))
+	})
+}
+
+// currentToken returns the token that authenticated the request, if any.
+func currentToken(r *http.Request) (users.Token, bool) {
+	t, ok := r.Context().Value(tokenKey{}).(users.Token)
+	return t, ok
+}
+
+// currentUser returns the name of the user who made the request, or an empty
+// one for anonymous requests.
+func currentUser(r *http.Request) string {
+	t, _ := currentToken(r)
+	return t.User
+}
+
+// requireUser rejects the anonymous requests.
+func requireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if currentUser(r) == "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.WriteHeader(http.StatusUnauthorized)
+			sendErrorResponse(w, json.NewEncoder(w),
+				"unauthorized",
+				"Please log in or provide a bearer token.",
+				http.StatusUnauthorized,
+			)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// forbiddenResponse responds that the user may not do what they requested.
+func forbiddenResponse(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	sendErrorResponse(w, json.NewEncoder(w),
+		"forbidden",
+		message,
+		http.StatusForbidden,
+	)
+}
+
+// campaignRole returns the role of the user in the provided campaign, or an
+// empty one if they take no part in it.
+func campaignRole(ctx context.Context, user, name string) string {
+	if user == "" || name == "" {
+		return ""
+	}
+
+	col := playersDatabase.Collection(campaignsCollection)
+
+	var c campaign.Campaign
+	if err := col.FindOne(ctx, bson.M{"name": name}).Decode(&c); err != nil {
+		return ""
+	}
+
+	return c.Role(user)
+}
+
+// isDungeonMaster checks whether the user runs the provided campaign.
+func isDungeonMaster(ctx context.Context, user, name string) bool {
+	return campaignRole(ctx, user, name) == campaign.DungeonMaster
+}
+
+// canEdit checks whether the user may change the provided creature. Creatures
+// outside of any campaign are left to their owner, while the ones without an
+// owner, like the ones stored before the accounts or imported from the command
+// line, can only be read. In a campaign, the dungeon master may change every
+// creature and the players only their own.
+func canEdit(ctx context.Context, user string, c *creature.Creature) bool {
+	if c.Campaign == "" {
+		return c.Owner != "" && c.Owner == user
+	}
+
+	switch campaignRole(ctx, user, c.Campaign) {
+	case campaign.DungeonMaster:
+		return true
+	case campaign.Player:
+		return c.Owner == user
+	default:
+		return false
+	}
+}
+
+// roleKey is the key of the campaign role of the user in the context of a
+// request.
+type roleKey struct{}
+
+// requestRole returns the role of the user who made the request in the campaign
+// the request is about, or an empty one outside of any campaign.
+func requestRole(r *http.Request) string {
+	role, _ := r.Context().Value(roleKey{}).(string)
+	return role
+}
+
+// creatureAccess rejects the requests for the creatures of a campaign from
+// users who take no part in it, along with the requests that change a
+// creature the user may not change. Creating monsters and NPCs in a campaign
+// is left to its dungeon master and spectators cannot create anything.
+func creatureAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		user := currentUser(r)
+		name := campaignOf(r)
+		role := campaignRole(ctx, user, name)
+		if name != "" && role == "" {
+			forbiddenResponse(w, "Only the users of the campaign can access its creatures.")
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), roleKey{}, role))
+
+		if r.Method == http.MethodGet || mux.Vars(r)["name"] == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		playersCollection := playersDatabase.Collection(players)
+
+		var c creature.Creature
+		opts := options.FindOne().SetProjection(bson.M{"owner": 1, "campaign": 1})
+		err := playersCollection.FindOne(ctx, creatureFilter(r), opts).Decode(&c)
+		switch {
+		case err == mongo.ErrNoDocuments:
+			if role == campaign.Spectator {
+				forbiddenResponse(w, "Spectators cannot create creatures in the campaign.")
+				return
+			}
+			if name != "" && creatureKind(r) != creature.Player && role != campaign.DungeonMaster {
+				forbiddenResponse(w, "Only the dungeon master of the campaign can create monsters and NPCs in it.")
+				return
+			}
+		case err != nil:
+			// The handler reports any database error.
+		case !canEdit(ctx, user, &c):
+			forbiddenResponse(w, "Only the owner of the creature or the dungeon master of its campaign can change it.")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// revealed checks whether the user who made the request may see the statistics
+// of the provided creature, responding with an error if they may not.
+func revealed(w http.ResponseWriter, r *http.Request, c *creature.Creature) bool {
+	if campaign.Conceals(requestRole(r), *c) {
+		forbiddenResponse(w, "Only the dungeon master of the campaign can see the statistics of its monsters and NPCs.")
+		return false
+	}
+
+	return true
+}
+
+// Register is the handler that creates a new user. The name and the password
+// are provided as JSON in the body of the request, e.g.
+// {"name": "gandalf", "password": "you shall not pass"}.
+func Register(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	var c credentials
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		sendErrorResponse(w, enc,
+			"invalid user",
+			"Please provide the name and the password as a JSON object.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	u, err := users.New(c.Name, c.Password)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid user",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(usersCollection)
+
+	if col.FindOne(ctx, bson.M{"name": u.Name}).Err() != mongo.ErrNoDocuments {
+		sendErrorResponse(w, enc,
+			"user exists",
+			"A user with the provided name already exists.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	if _, err := col.InsertOne(ctx, u); err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error inserting the new entry in the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	jsonEncode(w, enc, u)
+}
+
+// storeToken creates and stores a new token of the provided kind for the user.
+func storeToken(ctx context.Context, user, kind, name string) (tokenResponse, error) {
+	secret, t, err := users.NewToken(user, kind, name, time.Now())
+	if err != nil {
+		return tokenResponse{}, err
+	}
+
+	col := playersDatabase.Collection(tokensCollection)
+	if _, err := col.InsertOne(ctx, t); err != nil {
+		return tokenResponse{}, err
+	}
+
+	return tokenResponse{secret, t}, nil
+}
+
+// Login is the handler that starts a session for a user. The name and the
+// password are provided as JSON in the body of the request. The session token
+// is both returned and set as a cookie.
+func Login(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	var c credentials
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		sendErrorResponse(w, enc,
+			"invalid credentials",
+			"Please provide the name and the password as a JSON object.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(usersCollection)
+
+	var u users.User
+	err := col.FindOne(ctx, bson.M{"name": c.Name}).Decode(&u)
+	if err == nil {
+		err = u.Authenticate(c.Password)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		sendErrorResponse(w, enc,
+			"invalid credentials",
+			"The user name or the password is wrong.",
+			http.StatusUnauthorized,
+		)
+		return
+	}
+
+	response, err := storeToken(ctx, u.Name, users.Session, "")
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error inserting the new entry in the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    response.Secret,
+		Path:     "/",
+		Expires:  response.Expires,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	w.WriteHeader(http.StatusCreated)
+	jsonEncode(w, enc, response)
+}
+
+// Logout is the handler that ends the session, or revokes the token, the
+// request was made with.
+func Logout(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	t, _ := currentToken(r)
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(tokensCollection)
+
+	if _, err := col.DeleteOne(ctx, bson.M{"hash": t.Hash}); err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error deleting the entry from the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:   sessionCookie,
+		Path:   "/",
+		MaxAge: -1,
+	})
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// GetCurrentUser is the handler that returns the user who made the request.
+func GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	w.WriteHeader(http.StatusFound)
+	jsonEncode(w, enc, users.User{Name: currentUser(r)})
+}
+
+// GetTokens is the handler that returns the API tokens of the user who made the
+// request, without their secrets.
+func GetTokens(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(tokensCollection)
+
+	opts := options.Find().SetSort(bson.M{"name": 1})
+	cursor, err := col.Find(ctx, bson.M{
+		"user": currentUser(r),
+		"kind": users.API,
+	}, opts)
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	result := []users.Token{}
+	if err := cursor.All(ctx, &result); err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, result)
+}
+
+// AddToken is the handler that creates a named API token for the user who made
+// the request. The secret of the token is returned only once.
+func AddToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	name := mux.Vars(r)["token"]
+	user := currentUser(r)
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(tokensCollection)
+
+	err := col.FindOne(ctx, bson.M{
+		"user": user,
+		"kind": users.API,
+		"name": name,
+	}).Err()
+	if err != mongo.ErrNoDocuments {
+		sendErrorResponse(w, enc,
+			"token exists",
+			"A token with the provided name already exists.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	response, err := storeToken(ctx, user, users.API, name)
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error inserting the new entry in the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	jsonEncode(w, enc, response)
+}
+
+// DeleteToken is the handler that revokes an API token of the user who made the
+// request.
+func DeleteToken(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(tokensCollection)
+
+	_, err := col.DeleteOne(ctx, bson.M{
+		"user": currentUser(r),
+		"kind": users.API,
+		"name": mux.Vars(r)["token"],
+	})
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error deleting the entry from the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
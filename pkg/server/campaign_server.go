@@ -0,0 +1,727 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/aakordas/creature_manager/pkg/campaign"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	campaignsCollection = "campaigns"
+	partiesCollection   = "parties"
+)
+
+// campaignRoutes properly initializes the routes for the campaign part of the
+// server. The creatures of a campaign are served by playerRoutes.
+func campaignRoutes(r *mux.Router) *mux.Router {
+	var (
+		name   = "{campaign:[a-zA-Z0-9 _-]+}"
+		party  = "{party:[a-zA-Z0-9 _-]+}"
+		member = "{name:" + creatureName + "}"
+		user   = "{user:[a-zA-Z0-9_-]+}"
+		roll   = "{roll:[0-9a-f]{24}}"
+	)
+
+	api := r.PathPrefix("/api/v1/").Subrouter()
+	api.Use(requireUser)
+
+	api.HandleFunc("/campaigns", GetCampaigns).Methods(http.MethodGet)
+
+	c := api.PathPrefix("/campaigns/" + name).Subrouter()
+	c.Use(campaignAccess)
+	c.HandleFunc("", AddCampaign).Methods(http.MethodPut)
+	c.HandleFunc("", GetCampaign).Methods(http.MethodGet)
+	c.HandleFunc("", DeleteCampaign).Methods(http.MethodDelete)
+	c.HandleFunc("/users", GetCampaignUsers).Methods(http.MethodGet)
+	c.HandleFunc("/users/"+user, SetCampaignUser).Methods(http.MethodPut)
+	c.HandleFunc("/users/"+user, RemoveCampaignUser).Methods(http.MethodDelete)
+	c.HandleFunc("/export", ExportCampaign).Methods(http.MethodGet)
+	c.HandleFunc("/parties", GetParties).Methods(http.MethodGet)
+	c.HandleFunc("/rolls", GetRolls).Methods(http.MethodGet)
+	c.HandleFunc("/rolls/feed", RollFeed).Methods(http.MethodGet)
+	c.HandleFunc("/rolls/"+roll+"/reveal", RevealRoll).Methods(http.MethodPost)
+
+	p := "/parties/" + party
+	c.HandleFunc(p, AddParty).Methods(http.MethodPut)
+	c.HandleFunc(p, GetParty).Methods(http.MethodGet)
+	c.HandleFunc(p, DeleteParty).Methods(http.MethodDelete)
+	c.HandleFunc(p+"/members/"+member, AddMember).Methods(http.MethodPut)
+	c.HandleFunc(p+"/members/"+member, RemoveMember).Methods(http.MethodDelete)
+	c.HandleFunc(p+"/summary", GetPartySummary).Methods(http.MethodGet)
+
+	return r
+}
+
+// campaignAccess rejects the requests that read a campaign from users who take
+// no part in it. The handlers that change a campaign check for its dungeon
+// master themselves.
+func campaignAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		role := campaignRole(ctx, currentUser(r), mux.Vars(r)["campaign"])
+		if r.Method == http.MethodGet && role == "" {
+			forbiddenResponse(w, "Only the users of the campaign can access it.")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), roleKey{}, role)))
+	})
+}
+
+// createCampaignIndexes makes the names of the creatures unique per campaign,
+// instead of across the whole database, and sorts the roll history of each
+// campaign.
+func createCampaignIndexes(db *mongo.Database) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	_, err := db.Collection(players).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "campaign", Value: 1},
+			{Key: "name", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Println(err)
+	}
+
+	_, err = db.Collection(rollsCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "campaign", Value: 1},
+			{Key: "time", Value: -1},
+		},
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// campaignExists checks whether the campaign with the provided name exists.
+func campaignExists(ctx context.Context, name string) bool {
+	col := playersDatabase.Collection(campaignsCollection)
+
+	return col.FindOne(ctx, bson.M{"name": name}).Err() == nil
+}
+
+// requireDungeonMaster checks whether the user who made the request runs the
+// requested campaign, responding with an error if they do not.
+func requireDungeonMaster(ctx context.Context, w http.ResponseWriter, r *http.Request) bool {
+	if !isDungeonMaster(ctx, currentUser(r), mux.Vars(r)["campaign"]) {
+		forbiddenResponse(w, "Only the dungeon master of the campaign can change it.")
+		return false
+	}
+
+	return true
+}
+
+// partyFilter returns the filter that matches the requested party.
+func partyFilter(r *http.Request) bson.M {
+	vars := mux.Vars(r)
+
+	return bson.M{
+		"name":     vars["party"],
+		"campaign": vars["campaign"],
+	}
+}
+
+// GetCampaigns is the handler that returns every campaign the user who made the
+// request takes part in, sorted by name.
+func GetCampaigns(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(campaignsCollection)
+
+	user := currentUser(r)
+	opts := options.Find().SetSort(bson.M{"name": 1})
+	cursor, err := col.Find(ctx, bson.M{
+		"$or": bson.A{
+			bson.M{"dungeon_master": user},
+			bson.M{"users." + user: bson.M{"$exists": true}},
+		},
+	}, opts)
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	result := []campaign.Campaign{}
+	if err := cursor.All(ctx, &result); err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, result)
+}
+
+// AddCampaign is the handler that creates a new campaign, run by the user who
+// made the request, or updates the description of an existing one. The
+// description is optionally provided as JSON in the body of the request, e.g.
+// {"description": "The War of the Ring"}.
+func AddCampaign(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	var c campaign.Campaign
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+			sendErrorResponse(w, enc,
+				"invalid campaign",
+				"Please provide the campaign as a JSON object.",
+				http.StatusBadRequest,
+			)
+			return
+		}
+	}
+	c.Name = mux.Vars(r)["campaign"]
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(campaignsCollection)
+
+	var existing campaign.Campaign
+	err := col.FindOne(ctx, bson.M{"name": c.Name}).Decode(&existing)
+	c.DungeonMaster = existing.DungeonMaster
+	c.Users = existing.Users
+	switch {
+	case err == mongo.ErrNoDocuments || c.DungeonMaster == "":
+		c.DungeonMaster = currentUser(r)
+	case err != nil:
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	case c.DungeonMaster != currentUser(r):
+		forbiddenResponse(w, "Only the dungeon master of the campaign can change it.")
+		return
+	}
+
+	opts := options.Replace().SetUpsert(true)
+	_, err = col.ReplaceOne(ctx, bson.M{"name": c.Name}, c, opts)
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error inserting the new entry in the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// GetCampaign is the handler that returns a single campaign.
+func GetCampaign(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(campaignsCollection)
+
+	var c campaign.Campaign
+	err := col.FindOne(ctx, bson.M{"name": mux.Vars(r)["campaign"]}).Decode(&c)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		jsonEncode(w, enc, emptyResponse{})
+		return
+	}
+
+	w.WriteHeader(http.StatusFound)
+	jsonEncode(w, enc, c)
+}
+
+// GetCampaignUsers is the handler that returns the role of every user of a
+// campaign, including its dungeon master.
+func GetCampaignUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(campaignsCollection)
+
+	var c campaign.Campaign
+	err := col.FindOne(ctx, bson.M{"name": mux.Vars(r)["campaign"]}).Decode(&c)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		jsonEncode(w, enc, emptyResponse{})
+		return
+	}
+
+	roles := map[string]string{
+		c.DungeonMaster: campaign.DungeonMaster,
+	}
+	for user, role := range c.Users {
+		roles[user] = role
+	}
+
+	w.WriteHeader(http.StatusFound)
+	jsonEncode(w, enc, roles)
+}
+
+// SetCampaignUser is the handler that gives the requested user a role in a
+// campaign, either player or spectator, as provided in the role query. Players
+// are the default. Only the dungeon master of the campaign can set roles.
+func SetCampaignUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	user := vars["user"]
+	role := r.FormValue("role")
+	if role == "" {
+		role = campaign.Player
+	}
+	if !campaign.ValidRole(role) {
+		sendErrorResponse(w, enc,
+			"invalid role",
+			"Please provide either player or spectator as the role.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	if !requireDungeonMaster(ctx, w, r) {
+		return
+	}
+	if user == currentUser(r) {
+		sendErrorResponse(w, enc,
+			"invalid role",
+			"The dungeon master cannot give themselves another role.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	err := playersDatabase.Collection(usersCollection).FindOne(ctx, bson.M{"name": user}).Err()
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		sendErrorResponse(w, enc,
+			"user not found",
+			"No user found with name "+user,
+			http.StatusNotFound,
+		)
+		return
+	}
+
+	col := playersDatabase.Collection(campaignsCollection)
+
+	_, err = col.UpdateOne(ctx, bson.M{"name": vars["campaign"]}, bson.M{
+		"$set": bson.M{
+			"users." + user: role,
+		}})
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error updating the entry in the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// RemoveCampaignUser is the handler that removes the requested user from a
+// campaign. The dungeon master can remove anyone, while the other users can
+// only leave the campaign themselves.
+func RemoveCampaignUser(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	user := vars["user"]
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	if user != currentUser(r) && !requireDungeonMaster(ctx, w, r) {
+		return
+	}
+
+	col := playersDatabase.Collection(campaignsCollection)
+
+	_, err := col.UpdateOne(ctx, bson.M{"name": vars["campaign"]}, bson.M{
+		"$unset": bson.M{
+			"users." + user: "",
+		}})
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error updating the entry in the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// DeleteCampaign is the handler that deletes a campaign, along with its
+// creatures, parties and encounters. The creatures are moved to the deleted
+// ones, like the ones deleted one by one. Only its dungeon master can delete
+// it.
+func DeleteCampaign(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	name := mux.Vars(r)["campaign"]
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	if !requireDungeonMaster(ctx, w, r) {
+		return
+	}
+
+	if err := deleteCampaignCreatures(ctx, currentUser(r), name); err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error deleting the entry from the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	for _, collection := range []string{partiesCollection, encountersCollection} {
+		col := playersDatabase.Collection(collection)
+		if _, err := col.DeleteMany(ctx, bson.M{"campaign": name}); err != nil {
+			log.Println(err)
+			sendErrorResponse(w, enc, databaseError,
+				"There was an error deleting the entry from the database",
+				http.StatusInternalServerError,
+			)
+			return
+		}
+	}
+
+	col := playersDatabase.Collection(campaignsCollection)
+	if _, err := col.DeleteOne(ctx, bson.M{"name": name}); err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error deleting the entry from the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// deleteCampaignCreatures moves every creature of the provided campaign to the
+// deleted ones, on behalf of the provided user.
+func deleteCampaignCreatures(ctx context.Context, user, name string) error {
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := playersDatabase.Collection(players).Find(ctx, bson.M{"campaign": name}, opts)
+	if err != nil {
+		return err
+	}
+
+	var result []creature.Creature
+	if err := cursor.All(ctx, &result); err != nil {
+		return err
+	}
+
+	for _, c := range result {
+		err := deleteCreature(ctx, user, bson.M{"_id": c.ID})
+		if err != nil && err != mongo.ErrNoDocuments {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetParties is the handler that returns the parties of a campaign, sorted by
+// name.
+func GetParties(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(partiesCollection)
+
+	opts := options.Find().SetSort(bson.M{"name": 1})
+	cursor, err := col.Find(ctx, bson.M{
+		"campaign": mux.Vars(r)["campaign"],
+	}, opts)
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	result := []campaign.Party{}
+	if err := cursor.All(ctx, &result); err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, result)
+}
+
+// AddParty is the handler that creates a new party in a campaign.
+func AddParty(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	if !campaignExists(ctx, vars["campaign"]) {
+		w.WriteHeader(http.StatusNotFound)
+		sendErrorResponse(w, enc,
+			"campaign not found",
+			"No campaign found with name "+vars["campaign"],
+			http.StatusNotFound,
+		)
+		return
+	}
+	if !requireDungeonMaster(ctx, w, r) {
+		return
+	}
+
+	col := playersDatabase.Collection(partiesCollection)
+
+	err := col.FindOne(ctx, partyFilter(r)).Err()
+	if err != mongo.ErrNoDocuments {
+		sendErrorResponse(w, enc,
+			"party exists",
+			"A party with the provided name already exists in the campaign.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	_, err = col.InsertOne(ctx, campaign.Party{
+		Name:     vars["party"],
+		Campaign: vars["campaign"],
+		Members:  []string{},
+	})
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error inserting the new entry in the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// GetParty is the handler that returns a single party of a campaign.
+func GetParty(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(partiesCollection)
+
+	var p campaign.Party
+	if err := col.FindOne(ctx, partyFilter(r)).Decode(&p); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		jsonEncode(w, enc, emptyResponse{})
+		return
+	}
+
+	w.WriteHeader(http.StatusFound)
+	jsonEncode(w, enc, p)
+}
+
+// DeleteParty is the handler that deletes a party of a campaign. Its members
+// are left untouched.
+func DeleteParty(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	if !requireDungeonMaster(ctx, w, r) {
+		return
+	}
+
+	col := playersDatabase.Collection(partiesCollection)
+
+	if _, err := col.DeleteOne(ctx, partyFilter(r)); err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error deleting the entry from the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// changeParty fetches the requested party, applies change to it and stores it,
+// responding with its new state. Only the dungeon master of the campaign can
+// change its parties.
+func changeParty(w http.ResponseWriter, r *http.Request, change func(p *campaign.Party) error) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	if !requireDungeonMaster(ctx, w, r) {
+		return
+	}
+
+	col := playersDatabase.Collection(partiesCollection)
+
+	var p campaign.Party
+	if err := col.FindOne(ctx, partyFilter(r)).Decode(&p); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		sendErrorResponse(w, enc,
+			"party not found",
+			"No party found with name "+mux.Vars(r)["party"],
+			http.StatusNotFound,
+		)
+		return
+	}
+
+	if err := change(&p); err != nil {
+		sendErrorResponse(w, enc,
+			"invalid party operation",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	if _, err := col.ReplaceOne(ctx, partyFilter(r), p); err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error updating the entry in the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, p)
+}
+
+// AddMember is the handler that adds the requested creature of the campaign to
+// a party.
+func AddMember(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	var c creature.Creature
+	err := findPlayer(ctx, playersDatabase.Collection(players), vars["campaign"], name).Decode(&c)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		sendErrorResponse(w, enc,
+			"player not found",
+			missingPlayerError{name}.Error(),
+			http.StatusNotFound,
+		)
+		return
+	}
+
+	changeParty(w, r, func(p *campaign.Party) error {
+		return p.Add(c.Name)
+	})
+}
+
+// RemoveMember is the handler that removes the requested creature from a party.
+func RemoveMember(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	changeParty(w, r, func(p *campaign.Party) error {
+		return p.Remove(name)
+	})
+}
+
+// GetPartySummary is the handler that returns the members of a party, along
+// with its highest and lowest passive Perception and its total experience
+// points.
+func GetPartySummary(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(partiesCollection)
+
+	var p campaign.Party
+	if err := col.FindOne(ctx, partyFilter(r)).Decode(&p); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		jsonEncode(w, enc, emptyResponse{})
+		return
+	}
+
+	opts := options.Find().SetSort(bson.M{"name": 1})
+	cursor, err := playersDatabase.Collection(players).Find(ctx, bson.M{
+		"campaign": p.Campaign,
+		"name":     bson.M{"$in": p.Members},
+	}, opts)
+	members := []creature.Creature{}
+	if err == nil {
+		err = cursor.All(ctx, &members)
+	}
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, campaign.Summarize(p.Name, members))
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/conditions"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// setCondition adds or removes a condition of the requested creature. Adding a
+// condition the creature already has replaces its duration.
+func setCondition(w http.ResponseWriter, r *http.Request, add bool) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	condition := strings.ToLower(vars["condition"])
+	if !conditions.Valid(condition) || condition == conditions.Exhaustion {
+		sendErrorResponse(w, enc,
+			"invalid condition",
+			"Please provide a valid condition. Exhaustion has an endpoint of its own.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	var duration int
+	if d := r.FormValue("duration"); d != "" {
+		var err error
+		duration, err = strconv.Atoi(d)
+		if err != nil || duration < 0 {
+			sendErrorResponse(w, enc,
+				"invalid duration",
+				"Please provide the duration as a number of turns.",
+				http.StatusBadRequest,
+			)
+			return
+		}
+	}
+
+	player, err := getPlayer(w, r)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"Could not fetch the player.",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	if add && player.ImmuneToCondition(condition) {
+		sendErrorResponse(w, enc,
+			"immune to condition",
+			"The creature is immune to the provided condition.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	list := []conditions.Condition{}
+	for _, c := range player.Conditions {
+		if c.Name != condition {
+			list = append(list, c)
+		}
+	}
+	if add {
+		list = append(list, conditions.Condition{
+			Name:     condition,
+			Duration: duration,
+		})
+	}
+
+	f := unchangedFilter(player)
+	u := bson.M{
+		"$set": bson.M{
+			"conditions": list,
+		}}
+
+	if err := setNoUpsert(w, r, enc, f, u); err != nil {
+		return
+	}
+
+	jsonEncode(w, enc, list)
+}
+
+// AddCondition is the handler that makes the requested creature suffer from a
+// condition. The duration query sets the turns of the creature it lasts.
+func AddCondition(w http.ResponseWriter, r *http.Request) {
+	setCondition(w, r, true)
+}
+
+// RemoveCondition is the handler that removes a condition of the requested
+// creature.
+func RemoveCondition(w http.ResponseWriter, r *http.Request) {
+	setCondition(w, r, false)
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/conditions"
+	"github.com/aakordas/creature_manager/pkg/damage"
+	"github.com/aakordas/creature_manager/pkg/spellcasting"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// damageResponse models the state of a creature after it took damage.
+type damageResponse struct {
+	Damage             int                 `json:"damage" bson:"damage"` // The damage the creature actually took.
+	HitPoints          int                 `json:"hit_points" bson:"hit_points"`
+	Breakdown          damage.Breakdown    `json:"breakdown" bson:"breakdown"`
+	ConcentrationCheck *concentrationCheck `json:"concentration_check,omitempty" bson:"concentration_check,omitempty"`
+}
+
+// Damage is the handler that deals the provided damage to the requested
+// creature. The type query sets the damage type, which the resistances,
+// vulnerabilities and immunities of the creature apply to. If the creature is
+// concentrating on a spell, the response prompts for the Constitution saving
+// throw it has to make.
+func Damage(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	amount, err := strconv.Atoi(vars["number"])
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid value",
+			"Please provide a valid numeric value.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	damageType := r.FormValue("type")
+	if damageType != "" && !damage.ValidType(damageType) {
+		sendErrorResponse(w, enc,
+			"invalid damage type",
+			"Please provide a valid damage type.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	player, err := getPlayer(w, r)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"Could not fetch the player.",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	breakdown := player.Defenses.Apply(amount, damageType)
+
+	hitPoints := player.CurrentHitPoints - breakdown.Taken
+	if hitPoints < 0 {
+		hitPoints = 0
+	}
+
+	response := damageResponse{
+		Damage:    breakdown.Taken,
+		HitPoints: hitPoints,
+		Breakdown: breakdown,
+	}
+	if s := player.Spellcasting; s != nil && s.Concentration != "" && breakdown.Taken > 0 {
+		response.ConcentrationCheck = &concentrationCheck{
+			Spell:     s.Concentration,
+			DC:        spellcasting.ConcentrationDC(breakdown.Taken),
+			SaveBonus: constitutionSave(player),
+		}
+	}
+
+	f := unchangedFilter(player)
+	u := bson.M{
+		"$set": bson.M{
+			"hit_points": hitPoints,
+		}}
+
+	if err := setNoUpsert(w, r, enc, f, u); err != nil {
+		return
+	}
+
+	jsonEncode(w, enc, response)
+}
+
+// setDefense adds or removes a damage type or a condition from the requested
+// list of defenses of a creature.
+func setDefense(w http.ResponseWriter, r *http.Request, list string, add bool) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	var value string
+	if list == "condition_immunities" {
+		value = strings.ToLower(vars["condition"])
+		if !conditions.Valid(value) {
+			sendErrorResponse(w, enc,
+				"invalid condition",
+				"Please provide a valid condition.",
+				http.StatusBadRequest,
+			)
+			return
+		}
+	} else {
+		value = strings.ToLower(vars["type"])
+		if !damage.ValidType(value) {
+			sendErrorResponse(w, enc,
+				"invalid damage type",
+				"Please provide a valid damage type.",
+				http.StatusBadRequest,
+			)
+			return
+		}
+	}
+
+	operator := "$addToSet"
+	if !add {
+		operator = "$pull"
+	}
+
+	f := creatureFilter(r)
+	u := bson.M{
+		operator: bson.M{
+			"damage_defenses." + list: value,
+		}}
+
+	setNoUpsert(w, r, enc, f, u)
+}
+
+// AddResistance is the handler that makes the requested creature resistant to
+// a damage type.
+func AddResistance(w http.ResponseWriter, r *http.Request) {
+	setDefense(w, r, "resistances", true)
+}
+
+// RemoveResistance is the handler that removes a resistance of the requested
+// creature.
+func RemoveResistance(w http.ResponseWriter, r *http.Request) {
+	setDefense(w, r, "resistances", false)
+}
+
+// AddVulnerability is the handler that makes the requested creature vulnerable
+// to a damage type.
+func AddVulnerability(w http.ResponseWriter, r *http.Request) {
+	setDefense(w, r, "vulnerabilities", true)
+}
+
+// RemoveVulnerability is the handler that removes a vulnerability of the
+// requested creature.
+func RemoveVulnerability(w http.ResponseWriter, r *http.Request) {
+	setDefense(w, r, "vulnerabilities", false)
+}
+
+// AddImmunity is the handler that makes the requested creature immune to a
+// damage type.
+func AddImmunity(w http.ResponseWriter, r *http.Request) {
+	setDefense(w, r, "immunities", true)
+}
+
+// RemoveImmunity is the handler that removes a damage immunity of the
+// requested creature.
+func RemoveImmunity(w http.ResponseWriter, r *http.Request) {
+	setDefense(w, r, "immunities", false)
+}
+
+// AddConditionImmunity is the handler that makes the requested creature immune
+// to a condition.
+func AddConditionImmunity(w http.ResponseWriter, r *http.Request) {
+	setDefense(w, r, "condition_immunities", true)
+}
+
+// RemoveConditionImmunity is the handler that removes a condition immunity of
+// the requested creature.
+func RemoveConditionImmunity(w http.ResponseWriter, r *http.Request) {
+	setDefense(w, r, "condition_immunities", false)
+}
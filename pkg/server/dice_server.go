@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/aakordas/creature_manager/pkg/dice"
+	"github.com/aakordas/creature_manager/pkg/rolls"
 	"github.com/gorilla/mux"
 )
 
@@ -44,6 +45,11 @@ type rollResponse struct {
 	Count  int `json:"count" bson:"count"`   // The number of dice that got rolled.
 	Sides  int `json:"sides" bson:"sides"`   // The number of sides each dice had.
 	Result int `json:"result" bson:"result"` // The result of the rolling.
+
+	// Set only for the rolls made in a campaign.
+	ID         string `json:"id,omitempty" bson:"id,omitempty"`
+	Visibility string `json:"visibility,omitempty" bson:"visibility,omitempty"`
+	Hidden     bool   `json:"hidden,omitempty" bson:"hidden,omitempty"` // Whether the result is hidden from the roller.
 }
 
 type errorResponse struct {
@@ -148,34 +154,19 @@ func Roll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response(w, s, c)
+	response(w, r, s, c)
 }
 
 // chooseDice chooses the appropriate dice given a number of sides and returns
 // it and the number of sides as an integer..
 func chooseDice(sides int) dice.Dice {
-	switch sides {
-	case 4:
-		return dice.D4
-	case 6:
-		return dice.D6
-	case 8:
-		return dice.D8
-	case 10:
-		return dice.D10
-	case 12:
-		return dice.D12
-	case 20:
-		return dice.D20
-	case 100:
-		return dice.D100
-	default:
-		return nil
-	}
+	return dice.WithSides(sides)
 }
 
 // response deals with the response part of the HTTP response, whether that is an error response or not.
-func response(w http.ResponseWriter, s, c int) {
+// Rolls made in a campaign, given in the campaign query, are recorded in its
+// history.
+func response(w http.ResponseWriter, r *http.Request, s, c int) {
 	w.Header().Set("Content-Type", "application/json")
 
 	result, err := rollDice(s, c)
@@ -184,7 +175,21 @@ func response(w http.ResponseWriter, s, c int) {
 		return
 	}
 
-	response := rollResponse{c, s, result}
+	response := rollResponse{Count: c, Sides: s, Result: result}
+	if r.FormValue("campaign") != "" {
+		roll, ok := recordRoll(w, r, s, c, result)
+		if !ok {
+			return
+		}
+		response.Result = roll.Result
+		response.ID = roll.ID
+		response.Visibility = roll.Visibility
+		response.Hidden = roll.Hidden
+	} else if v := r.FormValue("visibility"); v != "" && v != rolls.Public {
+		visibilityErrResponse(w, "Only the rolls made in a campaign can be hidden.")
+		return
+	}
+
 	w.WriteHeader(http.StatusOK)
 	enc := json.NewEncoder(w)
 	jsonEncode(w, enc, response)
@@ -209,7 +214,7 @@ func RollN(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response(w, s, c)
+	response(w, r, s, c)
 }
 
 // DRollN is the handlre for all the requested rolls of n d dice, where n is
@@ -231,5 +236,5 @@ func DRollN(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response(w, s, c)
+	response(w, r, s, c)
 }
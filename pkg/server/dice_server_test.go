@@ -37,6 +37,8 @@ func TestRoll(t *testing.T) {
 		{"Valid query for sides, invalid for count", "?sides=4&count=0", response{http.StatusNotAcceptable, `"invalid count"`}},
 		{"Valid query for count, invalid for sides", "?count=2&sides=1", response{http.StatusNotAcceptable, `"invalid sides"`}},
 		{"Valid query for sides and count", "?sides=4&count=2", response{http.StatusOK, `"count":2,"sides":4`}},
+		{"Public roll outside of a campaign", "?visibility=public", response{http.StatusOK, ``}},
+		{"Hidden roll outside of a campaign", "?visibility=gm", response{http.StatusNotAcceptable, `"invalid visibility"`}},
 	}
 
 	for _, tt := range tests {
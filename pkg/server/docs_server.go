@@ -0,0 +1,855 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aakordas/creature_manager/pkg/abilities"
+	"github.com/aakordas/creature_manager/pkg/archive"
+	"github.com/aakordas/creature_manager/pkg/campaign"
+	"github.com/aakordas/creature_manager/pkg/conditions"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/encounter"
+	"github.com/aakordas/creature_manager/pkg/history"
+	"github.com/aakordas/creature_manager/pkg/importer"
+	"github.com/aakordas/creature_manager/pkg/inventory"
+	"github.com/aakordas/creature_manager/pkg/rolls"
+	"github.com/aakordas/creature_manager/pkg/saves"
+	"github.com/aakordas/creature_manager/pkg/sheet"
+	"github.com/aakordas/creature_manager/pkg/skills"
+	"github.com/aakordas/creature_manager/pkg/spells"
+	"github.com/aakordas/creature_manager/pkg/users"
+	"github.com/aakordas/creature_manager/pkg/vtt"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// documented is the router the OpenAPI document describes.
+var documented *mux.Router
+
+// docsRoutes initializes the routes of the documentation of the API, which
+// describes every route of the provided router, including its own.
+func docsRoutes(r *mux.Router) *mux.Router {
+	documented = r
+
+	api := r.PathPrefix("/api/v1/").Subrouter()
+	api.HandleFunc("/openapi.json", GetOpenAPI).Methods(http.MethodGet)
+	api.HandleFunc("/docs", GetDocs).Methods(http.MethodGet)
+
+	return r
+}
+
+// operation documents the handler of one or more routes. The schemas are the
+// names of the components of the document, with a [] prefix for an array of
+// them.
+type operation struct {
+	Summary  string
+	Query    map[string]string // The query parameters the handler reads, with their description.
+	Request  string            // The schema of the body of the request, if it has one.
+	Response string            // The schema of the body of a successful response, if it has one.
+	Status   int               // The status of a successful response, if not 200.
+	Types    []string          // The media types of a successful response, if not JSON.
+}
+
+// visibility documents the query of the rolls that can be made in a campaign.
+var visibility = map[string]string{
+	"campaign":   "The campaign to record the roll in.",
+	"visibility": "Who sees the roll in the campaign: public, the default, gm, blind or self.",
+}
+
+// hitPoints documents the query of the handlers that level creatures up.
+var hitPoints = map[string]string{
+	"hp": "Whether the hit points of the new levels are rolled, when roll, or the average of the hit die.",
+}
+
+// campaignQuery describes the campaign the handlers of the routes of a
+// campaign read from the query on the rest of their routes.
+const campaignQuery = "The campaign the request is about, if any."
+
+// operations documents every handler of the server, by its name. A route
+// whose handler is missing is left out of the document, which the tests
+// catch.
+var operations = map[string]operation{
+	// Users
+	"Register":       {Summary: "Registers a new user.", Request: "Credentials", Response: "User", Status: http.StatusCreated},
+	"Login":          {Summary: "Logs the user in, returning a new token.", Request: "Credentials", Response: "Token", Status: http.StatusCreated},
+	"Logout":         {Summary: "Revokes the token of the request.", Status: http.StatusAccepted},
+	"GetCurrentUser": {Summary: "Returns the user who made the request.", Response: "User", Status: http.StatusFound},
+	"GetTokens":      {Summary: "Returns the tokens of the user, without their secrets.", Response: "[]TokenInfo"},
+	"AddToken":       {Summary: "Creates a named token for the user.", Response: "Token", Status: http.StatusCreated},
+	"DeleteToken":    {Summary: "Revokes the named token of the user.", Status: http.StatusAccepted},
+
+	// Dice
+	"Roll":   {Summary: "Rolls dice of the provided sides.", Query: mergeQuery(visibility, map[string]string{"sides": "The sides of the dice.", "count": "The number of dice."}), Response: "Roll"},
+	"RollN":  {Summary: "Rolls dice of the sides in the path.", Query: mergeQuery(visibility, map[string]string{"count": "The number of dice."}), Response: "Roll"},
+	"DRollN": {Summary: "Rolls the number of dice of the sides in the path.", Query: visibility, Response: "Roll"},
+
+	// Creatures
+	"ListCreatures": {
+		Summary: "Returns a page of the creatures, with the link to the next one in the Link header.",
+		Query: map[string]string{
+			"sort":      "The field to sort by: name, level or hp.",
+			"order":     "The order to sort in: asc or desc.",
+			"limit":     "The number of creatures of the page, from 1 to 100.",
+			"class":     "Only the creatures of the class.",
+			"condition": "Only the creatures with the condition.",
+			"level_min": "Only the creatures of at least the level.",
+			"level_max": "Only the creatures of at most the level.",
+			"prefix":    "Only the creatures whose name starts with the prefix.",
+			"cursor":    "The cursor of the page, from the Link header of the previous one.",
+		},
+		Response: "[]Creature",
+	},
+	"AddPlayer":       {Summary: "Creates a creature of first level.", Response: "Creature", Status: http.StatusCreated},
+	"GetPlayer":       {Summary: "Returns the creature, with its version in the ETag header.", Response: "Creature", Status: http.StatusFound},
+	"DeletePlayer":    {Summary: "Deletes the creature, which can be restored later.", Status: http.StatusAccepted},
+	"RenameCreature":  {Summary: "Renames the creature, keeping its ID.", Response: "Creature"},
+	"GetHistory":      {Summary: "Returns the changes of the creature, newest first.", Response: "[]Event"},
+	"Undo":            {Summary: "Undoes the last changes of the creature.", Response: "Creature"},
+	"RestoreCreature": {Summary: "Restores the deleted creature.", Response: "Creature"},
+	"ExportCreature":  {Summary: "Returns the archive of the creature.", Query: map[string]string{"format": "The format of the archive: json or yaml."}, Response: "Archive", Types: []string{"application/json", "application/x-yaml"}},
+	"GetSheet":        {Summary: "Returns the character sheet of the creature, in the format the Accept header prefers.", Response: "Sheet", Types: sheetTypes},
+	"ExportTabletop":  {Summary: "Returns the creature as a character of the virtual tabletop.", Response: "Tabletop"},
+	"ImportTabletop":  {Summary: "Creates the creature from a character of the virtual tabletop.", Request: "Tabletop", Response: "Creature", Status: http.StatusCreated},
+	"ImportMonsters":  {Summary: "Imports the monsters of a stat block, an array of them or an Open5e page.", Query: map[string]string{"overwrite": "Whether to replace the monsters with the same name."}, Request: "StatBlock", Response: "Import", Status: http.StatusCreated},
+
+	"SetHitPoints":        {Summary: "Sets the hit points of the creature."},
+	"SetMaximumHitPoints": {Summary: "Sets the maximum hit points of the creature."},
+	"SetLevel":            {Summary: "Sets the level of the creature."},
+	"SetExhaustion":       {Summary: "Sets the exhaustion level of the creature."},
+	"SetArmorClass":       {Summary: "Sets the armor class of the creature, overriding the one of its armor."},
+	"ClearArmorClass":     {Summary: "Clears the armor class of the creature, which gets computed from its armor again."},
+	"SetArmorBonus":       {Summary: "Sets the bonus to the armor class of the creature."},
+	"SetMageArmor":        {Summary: "Casts Mage Armor on the creature."},
+	"RemoveMageArmor":     {Summary: "Ends Mage Armor on the creature."},
+	"SetClass":            {Summary: "Sets the levels of the creature in the class."},
+	"SetResource":         {Summary: "Sets the uses of the resource of the creature.", Query: map[string]string{"recharge": "The rest that recharges the resource: short or long, the default."}},
+	"ShortRest":           {Summary: "Makes the creature take a short rest, spending as many hit dice of each class as the query says, like ?fighter=2.", Response: "Rest"},
+	"LongRest":            {Summary: "Makes the creature take a long rest.", Response: "Rest"},
+	"AwardExperience":     {Summary: "Awards experience points to the creature.", Query: hitPoints, Response: "Experience"},
+	"SetAdvancement":      {Summary: "Sets whether the creature levels up by experience or by milestones."},
+	"LevelUp":             {Summary: "Levels the creature up in the class.", Query: mergeQuery(hitPoints, map[string]string{"class": "The class to level up in."}), Response: "Experience"},
+
+	"SetSpellcasting":  {Summary: "Sets the spellcasting ability of the creature."},
+	"GetSpellcasting":  {Summary: "Returns the spellcasting of the creature, with its save DC and attack bonus.", Response: "Spellcasting", Status: http.StatusFound},
+	"AddKnownSpell":    {Summary: "Adds the spell to the spells the creature knows."},
+	"RemoveKnownSpell": {Summary: "Removes the spell from the spells the creature knows."},
+	"PrepareSpell":     {Summary: "Prepares the spell."},
+	"UnprepareSpell":   {Summary: "Unprepares the spell."},
+	"ExpendSlot":       {Summary: "Expends a spell slot of the level."},
+	"CastSpell":        {Summary: "Casts a spell the creature knows or has prepared, expending a slot.", Query: map[string]string{"level": "The level to cast the spell at.", "concentration": "Whether the spell requires concentration."}, Response: "Cast"},
+	"EndConcentration": {Summary: "Ends the concentration of the creature."},
+
+	"GetInventory":    {Summary: "Returns the inventory of the creature, with its weight and attunements.", Response: "Inventory", Status: http.StatusFound},
+	"AddItem":         {Summary: "Adds the item to the inventory of the creature, with the details of the body, if any.", Request: "Item"},
+	"RemoveItem":      {Summary: "Removes the item from the inventory of the creature.", Query: map[string]string{"quantity": "The number of items to remove, every one if missing."}},
+	"EquipItem":       {Summary: "Equips the item."},
+	"UnequipItem":     {Summary: "Unequips the item."},
+	"AttuneItem":      {Summary: "Attunes the creature to the item."},
+	"EndAttunement":   {Summary: "Ends the attunement of the creature to the item."},
+	"TransferItem":    {Summary: "Moves the item to the inventory of the target creature.", Query: map[string]string{"quantity": "The number of items to move, every one if missing."}, Response: "Inventory"},
+	"SetCurrency":     {Summary: "Sets the coins of the kind the creature has."},
+	"ConvertCurrency": {Summary: "Converts coins of the creature to another kind."},
+
+	"Damage":                  {Summary: "Damages the creature, taking its resistances, vulnerabilities and immunities into account.", Query: map[string]string{"type": "The type of the damage."}, Response: "Damage"},
+	"AddResistance":           {Summary: "Makes the creature resistant to the damage type."},
+	"RemoveResistance":        {Summary: "Removes the resistance of the creature to the damage type."},
+	"AddVulnerability":        {Summary: "Makes the creature vulnerable to the damage type."},
+	"RemoveVulnerability":     {Summary: "Removes the vulnerability of the creature to the damage type."},
+	"AddImmunity":             {Summary: "Makes the creature immune to the damage type."},
+	"RemoveImmunity":          {Summary: "Removes the immunity of the creature to the damage type."},
+	"AddConditionImmunity":    {Summary: "Makes the creature immune to the condition."},
+	"RemoveConditionImmunity": {Summary: "Removes the immunity of the creature to the condition."},
+	"AddCondition":            {Summary: "Applies the condition to the creature.", Query: map[string]string{"duration": "The number of turns the condition lasts, until removed if missing."}},
+	"RemoveCondition":         {Summary: "Removes the condition from the creature."},
+
+	"AddWeaponProficiency":    {Summary: "Makes the creature proficient with the weapon or the weapon category."},
+	"RemoveWeaponProficiency": {Summary: "Removes the proficiency of the creature with the weapon or the weapon category."},
+	"Attack": {
+		Summary: "Makes the creature attack with a weapon of its inventory.",
+		Query: map[string]string{
+			"weapon":       "The weapon to attack with.",
+			"target":       "The creature to attack, which takes the damage on a hit. Its armor class is left out if its statistics are hidden from the user.",
+			"target_ac":    "The armor class to hit, if there is no target.",
+			"two_handed":   "Whether a versatile weapon is wielded with two hands.",
+			"advantage":    "Whether the attack has advantage.",
+			"disadvantage": "Whether the attack has disadvantage.",
+		},
+		Response: "Attack",
+	},
+
+	"GetAbilities":       {Summary: "Returns the ability scores and modifiers of the creature.", Response: "Abilities", Status: http.StatusFound},
+	"SetAbility":         {Summary: "Sets the ability score of the creature."},
+	"GetSkills":          {Summary: "Returns the skills the creature is proficient in.", Response: "Skills", Status: http.StatusFound},
+	"SetSkill":           {Summary: "Makes the creature proficient in the skill."},
+	"GetSaves":           {Summary: "Returns the saving throws the creature is proficient in.", Response: "SavingThrows", Status: http.StatusFound},
+	"SetSave":            {Summary: "Makes the creature proficient in the saving throw."},
+	"SetChallengeRating": {Summary: "Sets the challenge rating of the monster, along with its experience value and proficiency bonus."},
+	"SetDetails":         {Summary: "Sets the type, alignment, multiattack and legendary actions of the monster.", Request: "MonsterDetails"},
+	"Spawn":              {Summary: "Spawns instances of the monster, named after it.", Query: map[string]string{"hp": "Whether the hit points of the instances are rolled, when roll, or average."}, Response: "[]Spawn", Status: http.StatusCreated},
+	"GetInstances":       {Summary: "Returns the instances spawned from the monster.", Response: "[]Creature"},
+
+	"AwardPartyExperience": {Summary: "Splits the experience points among the players of the party.", Query: hitPoints, Response: "[]Experience"},
+
+	// Spells
+	"GetSpells": {
+		Summary: "Returns the spells, optionally filtered.",
+		Query: map[string]string{
+			"class":  "Only the spells of the class.",
+			"school": "Only the spells of the school.",
+			"level":  "Only the spells of the level.",
+			"q":      "Only the spells whose name contains the text.",
+		},
+		Response: "[]Spell",
+	},
+	"GetSpell":    {Summary: "Returns the spell.", Response: "Spell", Status: http.StatusFound},
+	"AddSpell":    {Summary: "Adds a custom spell, or replaces one the user made.", Query: map[string]string{"campaign": "The campaign the spell is made for, whose dungeon master may change it too."}, Request: "Spell", Status: http.StatusCreated},
+	"DeleteSpell": {Summary: "Deletes the custom spell, if the user made it.", Status: http.StatusAccepted},
+
+	// Encounters
+	"GetEncounters":   {Summary: "Returns the encounters of the campaign, or the ones of the user outside of any campaign.", Response: "[]Encounter"},
+	"RateEncounter":   {Summary: "Rates the difficulty of an encounter for a party.", Request: "DifficultyRequest", Response: "Difficulty"},
+	"AddEncounter":    {Summary: "Creates the encounter.", Status: http.StatusCreated},
+	"GetEncounter":    {Summary: "Returns the encounter, with the combatant whose turn it is.", Response: "Encounter", Status: http.StatusFound},
+	"DeleteEncounter": {Summary: "Deletes the encounter.", Status: http.StatusAccepted},
+	"AddCombatant":    {Summary: "Adds the creature to the encounter.", Query: map[string]string{"initiative": "The initiative of the creature, instead of rolling it."}},
+	"RemoveCombatant": {Summary: "Removes the creature from the encounter."},
+	"StartCombat":     {Summary: "Starts the combat, with the combatants in initiative order."},
+	"NextTurn":        {Summary: "Moves to the turn of the next combatant."},
+	"DelayTurn":       {Summary: "Delays the turn of the current combatant."},
+	"ResumeTurn":      {Summary: "Resumes the delayed turn of the combatant."},
+	"ReadyAction":     {Summary: "Readies an action of the current combatant.", Query: map[string]string{"trigger": "What triggers the readied action."}},
+	"EndCombat":       {Summary: "Ends the combat."},
+
+	// Campaigns
+	"GetCampaigns":       {Summary: "Returns the campaigns of the user.", Response: "[]Campaign"},
+	"AddCampaign":        {Summary: "Creates the campaign, run by the user.", Request: "Campaign", Status: http.StatusCreated},
+	"GetCampaign":        {Summary: "Returns the campaign.", Response: "Campaign", Status: http.StatusFound},
+	"DeleteCampaign":     {Summary: "Deletes the campaign.", Status: http.StatusAccepted},
+	"GetCampaignUsers":   {Summary: "Returns the users of the campaign, with their roles.", Response: "Roles", Status: http.StatusFound},
+	"SetCampaignUser":    {Summary: "Adds the user to the campaign.", Query: map[string]string{"role": "The role of the user: player, the default, or spectator."}},
+	"RemoveCampaignUser": {Summary: "Removes the user from the campaign.", Status: http.StatusAccepted},
+	"ExportCampaign":     {Summary: "Returns the archive of the campaign, with its parties and creatures.", Query: map[string]string{"format": "The format of the archive: json or yaml."}, Response: "Archive", Types: []string{"application/json", "application/x-yaml"}},
+	"GetParties":         {Summary: "Returns the parties of the campaign.", Response: "[]Party"},
+	"AddParty":           {Summary: "Creates the party.", Status: http.StatusCreated},
+	"GetParty":           {Summary: "Returns the party.", Response: "Party", Status: http.StatusFound},
+	"DeleteParty":        {Summary: "Deletes the party.", Status: http.StatusAccepted},
+	"AddMember":          {Summary: "Adds the creature to the party."},
+	"RemoveMember":       {Summary: "Removes the creature from the party."},
+	"GetPartySummary":    {Summary: "Returns the summary of the members of the party.", Response: "Summary"},
+	"GetRolls":           {Summary: "Returns the rolls of the campaign the user may see.", Response: "[]CampaignRoll"},
+	"RollFeed":           {Summary: "Streams the rolls of the campaign as server-sent events.", Types: []string{"text/event-stream"}},
+	"RevealRoll":         {Summary: "Reveals the hidden roll.", Response: "CampaignRoll"},
+
+	// Archives
+	"ExportAll":     {Summary: "Returns the archive of every campaign the user runs, along with their own creatures.", Query: map[string]string{"format": "The format of the archive: json or yaml."}, Response: "Archive", Types: []string{"application/json", "application/x-yaml"}},
+	"ImportArchive": {Summary: "Imports an archive.", Query: map[string]string{"format": "The format of the archive: json or yaml, from the Content-Type header if missing.", "conflict": "What happens to the entries whose name is taken: skip, overwrite or rename."}, Request: "Archive", Response: "Report", Status: http.StatusCreated},
+
+	// Documentation
+	"GetOpenAPI": {Summary: "Returns this document."},
+	"GetDocs":    {Summary: "Returns the documentation of the API as a web page.", Types: []string{"text/html"}},
+}
+
+// mergeQuery returns the query parameters of both provided maps.
+func mergeQuery(a, b map[string]string) map[string]string {
+	query := make(map[string]string, len(a)+len(b))
+	for _, m := range []map[string]string{a, b} {
+		for k, v := range m {
+			query[k] = v
+		}
+	}
+
+	return query
+}
+
+// schemas are the types of the bodies of the requests and the responses, by
+// the name of their component. A schema can be given as is too.
+var schemas = map[string]interface{}{
+	"Error":             errorResponse{},
+	"Roll":              rollResponse{},
+	"Creature":          creature.Creature{},
+	"Credentials":       credentials{},
+	"User":              users.User{},
+	"Token":             tokenResponse{},
+	"TokenInfo":         users.Token{},
+	"Event":             history.Event{},
+	"Archive":           archive.Archive{},
+	"Report":            archive.Report{},
+	"Sheet":             sheet.Sheet{},
+	"StatBlock":         importer.StatBlock{},
+	"Import":            importResponse{},
+	"FoundryActor":      vtt.Actor{},
+	"Roll20Character":   vtt.Character{},
+	"Rest":              restResponse{},
+	"Experience":        experienceResponse{},
+	"Spellcasting":      spellcastingResponse{},
+	"Cast":              castResponse{},
+	"Inventory":         inventoryResponse{},
+	"Item":              inventory.Item{},
+	"Damage":            damageResponse{},
+	"Attack":            attackResponse{},
+	"Abilities":         abilities.Abilities{},
+	"Skills":            skills.Skills{},
+	"SavingThrows":      saves.SavingThrows{},
+	"MonsterDetails":    monsterDetails{},
+	"Spawn":             spawnResponse{},
+	"Spell":             spells.Spell{},
+	"Encounter":         encounterResponse{},
+	"DifficultyRequest": difficultyRequest{},
+	"Difficulty":        encounter.Difficulty{},
+	"Campaign":          campaign.Campaign{},
+	"Roles":             map[string]string{},
+	"Party":             campaign.Party{},
+	"Summary":           campaign.Summary{},
+	"CampaignRoll":      rolls.Roll{},
+	"Condition":         conditions.Condition{},
+	"Tabletop": jsonSchema{
+		"description": "A character of Foundry VTT or of Roll20, as the path says.",
+		"oneOf":       []jsonSchema{reference("FoundryActor"), reference("Roll20Character")},
+	},
+}
+
+// jsonSchema is a JSON schema, as the OpenAPI document uses them.
+type jsonSchema map[string]interface{}
+
+// reference returns the schema that refers to the provided component.
+func reference(name string) jsonSchema {
+	return jsonSchema{"$ref": "#/components/schemas/" + name}
+}
+
+// bodySchema returns the schema of the provided body, like []Creature for an
+// array of creatures.
+func bodySchema(name string) jsonSchema {
+	if strings.HasPrefix(name, "[]") {
+		return jsonSchema{"type": "array", "items": reference(name[2:])}
+	}
+
+	return reference(name)
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+)
+
+// schemaOf returns the schema of the provided type, as it gets encoded to
+// JSON. The types being described are passed along, so that recursive types
+// end up as plain objects.
+func schemaOf(t reflect.Type, seen map[reflect.Type]bool) jsonSchema {
+	switch t {
+	case timeType:
+		return jsonSchema{"type": "string", "format": "date-time"}
+	case objectIDType:
+		return jsonSchema{"type": "string", "pattern": "^[0-9a-f]{24}$"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem(), seen)
+	case reflect.Bool:
+		return jsonSchema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return jsonSchema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return jsonSchema{"type": "number"}
+	case reflect.String:
+		return jsonSchema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return jsonSchema{"type": "string", "format": "byte"}
+		}
+		return jsonSchema{"type": "array", "items": schemaOf(t.Elem(), seen)}
+	case reflect.Map:
+		return jsonSchema{"type": "object", "additionalProperties": schemaOf(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return jsonSchema{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		properties := jsonSchema{}
+		structProperties(t, properties, seen)
+		return jsonSchema{"type": "object", "properties": properties}
+	default:
+		return jsonSchema{}
+	}
+}
+
+// structProperties adds the properties of the fields of the provided struct,
+// with the fields of the embedded structs without a name of their own.
+func structProperties(t reflect.Type, properties jsonSchema, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (f.PkgPath != "" && !f.Anonymous) {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				structProperties(ft, properties, seen)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = schemaOf(f.Type, seen)
+	}
+}
+
+// openAPIParameter is a parameter of the path or the query of an operation.
+type openAPIParameter struct {
+	Name        string     `json:"name"`
+	In          string     `json:"in"`
+	Description string     `json:"description,omitempty"`
+	Required    bool       `json:"required"`
+	Schema      jsonSchema `json:"schema"`
+}
+
+// openAPIContent maps the media types of a body to their schemas.
+type openAPIContent map[string]struct {
+	Schema jsonSchema `json:"schema,omitempty"`
+}
+
+type openAPIResponse struct {
+	Description string         `json:"description"`
+	Content     openAPIContent `json:"content,omitempty"`
+}
+
+type openAPIOperation struct {
+	Summary     string                     `json:"summary"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIRequestBody struct {
+	Required bool           `json:"required"`
+	Content  openAPIContent `json:"content"`
+}
+
+// openAPI is the OpenAPI 3 document of the API.
+type openAPI struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       map[string]string                       `json:"info"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components struct {
+		Schemas         map[string]jsonSchema `json:"schemas"`
+		SecuritySchemes map[string]jsonSchema `json:"securitySchemes"`
+	} `json:"components"`
+	Security []map[string][]string `json:"security"`
+}
+
+// handlerName returns the name of the function of the provided handler, like
+// GetPlayer.
+func handlerName(h http.Handler) string {
+	v := reflect.ValueOf(h)
+	if v.Kind() != reflect.Func {
+		return ""
+	}
+	name := runtime.FuncForPC(v.Pointer()).Name()
+
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// templateParameters returns the provided path template of the router in the
+// form of OpenAPI, like /player/{name} for /player/{name:[^/]+}, along with
+// its variables and their patterns.
+func templateParameters(template string) (string, []openAPIParameter) {
+	var (
+		path       strings.Builder
+		parameters []openAPIParameter
+	)
+	for i := 0; i < len(template); i++ {
+		if template[i] != '{' {
+			path.WriteByte(template[i])
+			continue
+		}
+
+		// Patterns can have braces of their own, like [a-z]{2}.
+		depth, end := 0, i
+		for ; end < len(template); end++ {
+			if template[end] == '{' {
+				depth++
+			} else if template[end] == '}' {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+		}
+
+		variable := strings.SplitN(template[i+1:end], ":", 2)
+		p := openAPIParameter{Name: variable[0], In: "path", Required: true, Schema: jsonSchema{"type": "string"}}
+		if len(variable) == 2 {
+			p.Schema["pattern"] = "^(?:" + variable[1] + ")$"
+		}
+		parameters = append(parameters, p)
+		path.WriteString("{" + variable[0] + "}")
+		i = end
+	}
+
+	return path.String(), parameters
+}
+
+// newOpenAPI returns the OpenAPI document of the routes of the provided router.
+// It returns the routes whose handler is not documented, which get left out.
+func newOpenAPI(router *mux.Router) (doc openAPI, undocumented []string) {
+	doc.OpenAPI = "3.0.3"
+	doc.Info = map[string]string{
+		"title":       "Creature Manager",
+		"description": "Tracks the creatures of D&D 5th edition campaigns and rolls dice for them.",
+		"version":     "1",
+	}
+	doc.Paths = map[string]map[string]*openAPIOperation{}
+	doc.Components.Schemas = map[string]jsonSchema{}
+	for name, v := range schemas {
+		if s, ok := v.(jsonSchema); ok {
+			doc.Components.Schemas[name] = s
+			continue
+		}
+		doc.Components.Schemas[name] = schemaOf(reflect.TypeOf(v), map[reflect.Type]bool{})
+	}
+	doc.Components.SecuritySchemes = map[string]jsonSchema{
+		"token": {"type": "http", "scheme": "bearer"},
+	}
+	// Only the dice and the documentation are available anonymously.
+	doc.Security = []map[string][]string{{"token": {}}, {}}
+
+	// The handlers of the routes of a campaign serve routes outside of any
+	// campaign too, where they read the campaign from the query.
+	inCampaign := map[string]bool{}
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if template, err := route.GetPathTemplate(); err == nil && strings.Contains(template, "{campaign") {
+			inCampaign[handlerName(route.GetHandler())] = true
+		}
+		return nil
+	})
+
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		h := route.GetHandler()
+		if h == nil {
+			return nil
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{http.MethodGet}
+		}
+
+		handler := handlerName(h)
+		op, ok := operations[handler]
+		if !ok {
+			undocumented = append(undocumented, strings.Join(methods, ",")+" "+template)
+			return nil
+		}
+
+		path, parameters := templateParameters(template)
+		if inCampaign[handler] && !strings.Contains(template, "{campaign") {
+			parameters = append(parameters, openAPIParameter{Name: "campaign", In: "query", Description: campaignQuery, Schema: jsonSchema{"type": "string"}})
+		}
+		queries, _ := route.GetQueriesTemplates()
+		required := map[string]bool{}
+		for _, q := range queries {
+			kv := strings.SplitN(q, "=", 2)
+			_, qs := templateParameters(kv[1])
+			for _, p := range qs {
+				p.Name, p.In, p.Description = kv[0], "query", op.Query[kv[0]]
+				parameters = append(parameters, p)
+				required[kv[0]] = true
+			}
+		}
+		names := make([]string, 0, len(op.Query))
+		for name := range op.Query {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !required[name] {
+				parameters = append(parameters, openAPIParameter{Name: name, In: "query", Description: op.Query[name], Schema: jsonSchema{"type": "string"}})
+			}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*openAPIOperation{}
+		}
+		for _, method := range methods {
+			doc.Paths[path][strings.ToLower(method)] = newOperation(op, path, parameters)
+		}
+
+		return nil
+	})
+
+	return doc, undocumented
+}
+
+// newOperation returns the OpenAPI operation of the provided path.
+func newOperation(op operation, path string, parameters []openAPIParameter) *openAPIOperation {
+	o := &openAPIOperation{
+		Summary:    op.Summary,
+		Parameters: parameters,
+		Responses: map[string]openAPIResponse{
+			"default": {Description: "An error.", Content: openAPIContent{"application/json": {Schema: reference("Error")}}},
+		},
+	}
+
+	// Tag the operations by the first part of the path after the version,
+	// like campaigns or player.
+	if parts := strings.Split(strings.TrimPrefix(path, "/api/v1/"), "/"); parts[0] != "" {
+		o.Tags = []string{parts[0]}
+	}
+
+	if op.Request != "" {
+		o.RequestBody = &openAPIRequestBody{
+			Required: true,
+			Content:  openAPIContent{"application/json": {Schema: bodySchema(op.Request)}},
+		}
+	}
+
+	status, response := op.Status, openAPIResponse{Description: http.StatusText(op.Status)}
+	if status == 0 {
+		status, response.Description = http.StatusOK, http.StatusText(http.StatusOK)
+	}
+	types := op.Types
+	if types == nil && op.Response != "" {
+		types = []string{"application/json"}
+	}
+	if types != nil {
+		response.Content = openAPIContent{}
+	}
+	for _, t := range types {
+		c := response.Content[t]
+		if op.Response != "" && (t == "application/json" || t == "application/x-yaml") {
+			c.Schema = bodySchema(op.Response)
+		}
+		response.Content[t] = c
+	}
+	o.Responses[strconv.Itoa(status)] = response
+
+	return o
+}
+
+var (
+	openAPIOnce     sync.Once
+	openAPIDocument []byte
+	docsDocument    []byte
+)
+
+// loadOpenAPI builds the OpenAPI document of the documented router, along
+// with its web page, the first time either is requested.
+func loadOpenAPI() {
+	openAPIOnce.Do(func() {
+		doc, undocumented := newOpenAPI(documented)
+		for _, route := range undocumented {
+			log.Println("Undocumented route:", route)
+		}
+
+		var err error
+		openAPIDocument, err = json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			log.Println(err)
+		}
+
+		var b bytes.Buffer
+		if err := docsTemplate.Execute(&b, newDocsPage(doc)); err != nil {
+			log.Println(err)
+		}
+		docsDocument = b.Bytes()
+	})
+}
+
+// GetOpenAPI is the handler that returns the OpenAPI document of the API.
+func GetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	loadOpenAPI()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(openAPIDocument)
+}
+
+// docsPage is the OpenAPI document of the API, in the order the web page shows
+// it.
+type docsPage struct {
+	Info     map[string]string
+	Sections []docsSection
+	Schemas  map[string]jsonSchema
+}
+
+// docsSection holds the operations of a tag of the document.
+type docsSection struct {
+	Tag        string
+	Operations []docsOperation
+}
+
+// docsOperation is an operation of the document, along with its route.
+type docsOperation struct {
+	Method string
+	Path   string
+	*openAPIOperation
+}
+
+// newDocsPage returns the web page of the provided document, with its sections
+// sorted by tag and their operations by path.
+func newDocsPage(doc openAPI) docsPage {
+	tags := map[string][]docsOperation{}
+	for path, operations := range doc.Paths {
+		for method, op := range operations {
+			tag := ""
+			if len(op.Tags) > 0 {
+				tag = op.Tags[0]
+			}
+			tags[tag] = append(tags[tag], docsOperation{strings.ToUpper(method), path, op})
+		}
+	}
+
+	page := docsPage{Info: doc.Info, Schemas: doc.Components.Schemas}
+	for tag, operations := range tags {
+		sort.Slice(operations, func(i, j int) bool {
+			if operations[i].Path != operations[j].Path {
+				return operations[i].Path < operations[j].Path
+			}
+			return operations[i].Method < operations[j].Method
+		})
+		page.Sections = append(page.Sections, docsSection{tag, operations})
+	}
+	sort.Slice(page.Sections, func(i, j int) bool {
+		return page.Sections[i].Tag < page.Sections[j].Tag
+	})
+
+	return page
+}
+
+// schemaLabel returns the provided schema as the page shows it, like
+// Creature, []Creature or string.
+func schemaLabel(v interface{}) string {
+	s, ok := v.(jsonSchema)
+	if !ok {
+		return ""
+	}
+	if ref, ok := s["$ref"].(string); ok {
+		return strings.TrimPrefix(ref, "#/components/schemas/")
+	}
+
+	switch s["type"] {
+	case "array":
+		return "[]" + schemaLabel(s["items"])
+	case "object":
+		if additional, ok := s["additionalProperties"]; ok {
+			return "map of " + schemaLabel(additional)
+		}
+		return "object"
+	case nil:
+		var labels []string
+		oneOf, _ := s["oneOf"].([]jsonSchema)
+		for _, one := range oneOf {
+			labels = append(labels, schemaLabel(one))
+		}
+		if labels == nil {
+			return "any"
+		}
+		return strings.Join(labels, " or ")
+	default:
+		return s["type"].(string)
+	}
+}
+
+// schemaLink returns the component the provided schema refers to, if any, so
+// that the page can link to it.
+func schemaLink(v interface{}) string {
+	label := strings.TrimPrefix(schemaLabel(v), "[]")
+	if s, ok := v.(jsonSchema); ok && s["type"] == "array" {
+		v = s["items"]
+	}
+	if s, ok := v.(jsonSchema); ok && s["$ref"] != nil {
+		return label
+	}
+
+	return ""
+}
+
+// docsTemplate renders the documentation of the API. The page is rendered by
+// the server, without any script, so that it depends on nothing else.
+var docsTemplate = template.Must(template.New("docs").Funcs(map[string]interface{}{
+	"label": schemaLabel,
+	"link":  schemaLink,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Info.title}} API</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: 2em auto; color: #222; }
+h2 { border-bottom: 2px solid #922610; color: #922610; text-transform: capitalize; }
+h3 { font-family: monospace; font-size: 1.1em; margin-bottom: 0.25em; }
+.method { display: inline-block; min-width: 4em; color: #922610; }
+table { border-collapse: collapse; margin: 0.5em 0; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.75em; text-align: left; vertical-align: top; }
+code { font-size: 0.95em; }
+</style>
+</head>
+<body>
+<h1>{{.Info.title}} API</h1>
+<p>{{.Info.description}} The OpenAPI document of the API is at <a href="/api/v1/openapi.json">/api/v1/openapi.json</a>.</p>
+<ul>
+{{range .Sections}}<li><a href="#tag-{{.Tag}}">{{.Tag}}</a></li>
+{{end}}<li><a href="#schemas">Schemas</a></li>
+</ul>
+{{range .Sections}}<h2 id="tag-{{.Tag}}">{{.Tag}}</h2>
+{{range .Operations}}<h3><span class="method">{{.Method}}</span> {{.Path}}</h3>
+<p>{{.Summary}}</p>
+{{if .Parameters}}<table>
+<tr><th>Parameter</th><th>In</th><th>Description</th></tr>
+{{range .Parameters}}<tr><td><code>{{.Name}}</code>{{if .Required}} (required){{end}}</td><td>{{.In}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+{{end}}{{with .RequestBody}}<p><strong>Body:</strong>{{range $type, $c := .Content}} <code>{{$type}}</code>{{with link $c.Schema}} <a href="#schema-{{.}}">{{label $c.Schema}}</a>{{end}}{{end}}</p>
+{{end}}<table>
+<tr><th>Status</th><th>Body</th></tr>
+{{range $status, $r := .Responses}}<tr><td>{{$status}} {{$r.Description}}</td><td>{{range $type, $c := $r.Content}}<code>{{$type}}</code>{{with link $c.Schema}} <a href="#schema-{{.}}">{{label $c.Schema}}</a>{{end}} {{end}}</td></tr>
+{{end}}</table>
+{{end}}{{end}}<h2 id="schemas">Schemas</h2>
+{{range $name, $s := .Schemas}}<h3 id="schema-{{$name}}">{{$name}}</h3>
+{{with $s.description}}<p>{{.}}</p>
+{{end}}{{with $s.properties}}<table>
+<tr><th>Property</th><th>Type</th></tr>
+{{range $property, $p := .}}<tr><td><code>{{$property}}</code></td><td>{{with link $p}}<a href="#schema-{{.}}">{{label $p}}</a>{{else}}{{label $p}}{{end}}</td></tr>
+{{end}}</table>
+{{else}}<p>{{label $s}}</p>
+{{end}}{{end}}</body>
+</html>
+`))
+
+// GetDocs is the handler that returns the documentation of the API as a web
+// page.
+func GetDocs(w http.ResponseWriter, r *http.Request) {
+	loadOpenAPI()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	w.WriteHeader(http.StatusOK)
+	w.Write(docsDocument)
+}
@@ -0,0 +1,395 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestTemplateParameters(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		patterns []string
+	}{
+		{"No variables", "/api/v1/spells", "/api/v1/spells", nil},
+		{"Variable", "/api/v1/player/{name:[^/]+}", "/api/v1/player/{name}", []string{"^(?:[^/]+)$"}},
+		{"Variable without pattern", "/api/v1/player/{name}/sheet", "/api/v1/player/{name}/sheet", []string{""}},
+		{"Braces in pattern", "/currency/{coin:[a-z]{2}}/{number:[0-9]+}", "/currency/{coin}/{number}", []string{"^(?:[a-z]{2})$", "^(?:[0-9]+)$"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, parameters := templateParameters(tt.template)
+			if got != tt.want {
+				t.Errorf("templateParameters() = %v, want %v", got, tt.want)
+			}
+
+			var patterns []string
+			for _, p := range parameters {
+				pattern, _ := p.Schema["pattern"].(string)
+				patterns = append(patterns, pattern)
+			}
+			if !reflect.DeepEqual(patterns, tt.patterns) {
+				t.Errorf("templateParameters() patterns = %v, want %v", patterns, tt.patterns)
+			}
+		})
+	}
+}
+
+// TestOpenAPI_Documented fails for every route of the server whose handler
+// is not documented, and for every documented handler without a route.
+func TestOpenAPI_Documented(t *testing.T) {
+	r := newRouter()
+
+	doc, undocumented := newOpenAPI(r)
+	for _, route := range undocumented {
+		t.Errorf("Route %v is not documented in operations", route)
+	}
+
+	handlers := map[string]bool{}
+	r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if h := route.GetHandler(); h != nil {
+			handlers[handlerName(h)] = true
+		}
+		return nil
+	})
+	for name := range operations {
+		if !handlers[name] {
+			t.Errorf("Operation %v has no route", name)
+		}
+	}
+
+	for path, method := range map[string]string{
+		"/api/v1/roll/{sides}":                     http.MethodGet,
+		"/api/v1/player/{name}":                    http.MethodPut,
+		"/api/v1/campaigns/{campaign}/npcs/{name}": http.MethodDelete,
+		"/api/v1/openapi.json":                     http.MethodGet,
+	} {
+		if _, ok := doc.Paths[path][strings.ToLower(method)]; !ok {
+			t.Errorf("Missing %v %v", method, path)
+		}
+	}
+
+	if _, err := json.Marshal(doc); err != nil {
+		t.Errorf("json.Marshal() error = %v", err)
+	}
+}
+
+func TestOpenAPI_Schemas(t *testing.T) {
+	doc, _ := newOpenAPI(newRouter())
+
+	for path, operations := range doc.Paths {
+		for method, op := range operations {
+			for status, response := range op.Responses {
+				for _, content := range response.Content {
+					if ref, ok := content.Schema["$ref"].(string); ok && doc.Components.Schemas[ref[len("#/components/schemas/"):]] == nil {
+						t.Errorf("%v %v responds %v with the unknown schema %v", method, path, status, ref)
+					}
+				}
+			}
+		}
+	}
+
+	properties := doc.Components.Schemas["Creature"]["properties"].(jsonSchema)
+	for _, name := range []string{"id", "name", "hit_points", "abilities", "classes", "inventory"} {
+		if _, ok := properties[name]; !ok {
+			t.Errorf("Creature schema misses %v", name)
+		}
+	}
+	if _, ok := properties["Inventory"]; ok {
+		t.Errorf("Creature schema has the embedded Inventory by its type name")
+	}
+}
+
+// handlerSource holds what the functions of the package read from the query
+// of a request and the successful statuses they respond with, as found in
+// their source.
+type handlerSource struct {
+	funcs    map[string]*ast.FuncDecl
+	queries  map[string]map[string]bool
+	statuses map[string]map[string]bool
+	params   map[string]map[int]bool // The parameters of each function that name a query.
+}
+
+// parseHandlers parses the source of the package.
+func parseHandlers(t *testing.T) *handlerSource {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &handlerSource{
+		funcs:    map[string]*ast.FuncDecl{},
+		queries:  map[string]map[string]bool{},
+		statuses: map[string]map[string]bool{},
+		params:   map[string]map[int]bool{},
+	}
+	for _, f := range pkgs["server"].Files {
+		for _, d := range f.Decls {
+			if fn, ok := d.(*ast.FuncDecl); ok && fn.Recv == nil {
+				s.funcs[fn.Name.Name] = fn
+			}
+		}
+	}
+	for name, fn := range s.funcs {
+		s.params[name] = queryParams(fn)
+	}
+
+	return s
+}
+
+// queryParams returns the indexes of the parameters of the provided function
+// that get read from the query of the request, like the one of intQuery.
+func queryParams(fn *ast.FuncDecl) map[int]bool {
+	index := map[string]int{}
+	i := 0
+	for _, field := range fn.Type.Params.List {
+		for _, name := range field.Names {
+			index[name.Name] = i
+			i++
+		}
+		if len(field.Names) == 0 {
+			i++
+		}
+	}
+
+	params := map[int]bool{}
+	ast.Inspect(fn, func(n ast.Node) bool {
+		if arg, ok := formValue(n).(*ast.Ident); ok {
+			if i, ok := index[arg.Name]; ok {
+				params[i] = true
+			}
+		}
+		return true
+	})
+
+	return params
+}
+
+// formValue returns the argument of the provided node, if it is a call of
+// FormValue.
+func formValue(n ast.Node) ast.Expr {
+	call, ok := n.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return nil
+	}
+	if sel, ok := call.Fun.(*ast.SelectorExpr); !ok || sel.Sel.Name != "FormValue" {
+		return nil
+	}
+
+	return call.Args[0]
+}
+
+// literal returns the value of the provided string literal, if it is one.
+func literal(e ast.Expr) (string, bool) {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	v, err := strconv.Unquote(lit.Value)
+
+	return v, err == nil
+}
+
+// read collects the queries the provided function reads and the successful
+// statuses it responds with, along with the ones of the functions it calls.
+func (s *handlerSource) read(name string) (map[string]bool, map[string]bool) {
+	if q, ok := s.queries[name]; ok {
+		return q, s.statuses[name]
+	}
+	queries, statuses := map[string]bool{}, map[string]bool{}
+	s.queries[name], s.statuses[name] = queries, statuses
+
+	// The keys of the maps that get ranged over, for the queries read in
+	// loops, like the ones of the levels.
+	keys := map[string][]string{}
+	ast.Inspect(s.funcs[name], func(n ast.Node) bool {
+		if rs, ok := n.(*ast.RangeStmt); ok {
+			if key, ok := rs.Key.(*ast.Ident); ok {
+				if m, ok := rs.X.(*ast.CompositeLit); ok {
+					for _, e := range m.Elts {
+						if kv, ok := e.(*ast.KeyValueExpr); ok {
+							if v, ok := literal(kv.Key); ok {
+								keys[key.Name] = append(keys[key.Name], v)
+							}
+						}
+					}
+				}
+			}
+		}
+
+		if arg := formValue(n); arg != nil {
+			if v, ok := literal(arg); ok {
+				queries[v] = true
+			}
+			if ident, ok := arg.(*ast.Ident); ok {
+				for _, v := range keys[ident.Name] {
+					queries[v] = true
+				}
+			}
+			return true
+		}
+
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		switch fun := call.Fun.(type) {
+		case *ast.SelectorExpr:
+			if fun.Sel.Name != "WriteHeader" || len(call.Args) != 1 {
+				return true
+			}
+			if status, ok := call.Args[0].(*ast.SelectorExpr); ok {
+				statuses[status.Sel.Name] = true
+			}
+		case *ast.Ident:
+			if _, ok := s.funcs[fun.Name]; !ok || fun.Name == name {
+				return true
+			}
+			for i := range s.params[fun.Name] {
+				if i < len(call.Args) {
+					if v, ok := literal(call.Args[i]); ok {
+						queries[v] = true
+					}
+				}
+			}
+			q, st := s.read(fun.Name)
+			for k := range q {
+				queries[k] = true
+			}
+			for k := range st {
+				statuses[k] = true
+			}
+		}
+		return true
+	})
+
+	return queries, statuses
+}
+
+// successStatuses names the successful statuses of the handlers, as their
+// constants of the http package.
+var successStatuses = map[int]string{
+	http.StatusOK:       "StatusOK",
+	http.StatusCreated:  "StatusCreated",
+	http.StatusAccepted: "StatusAccepted",
+	http.StatusFound:    "StatusFound",
+}
+
+// ignoredQueries are the queries the handlers read through the helpers they
+// share with other handlers, without using them.
+var ignoredQueries = map[string]map[string]bool{
+	"RemoveCondition": {"duration": true},
+}
+
+// TestOpenAPI_Handlers fails for every query a handler reads that is not
+// documented, or not in the path, for every documented query no handler
+// reads and for every handler that responds with a successful status other
+// than the documented one.
+func TestOpenAPI_Handlers(t *testing.T) {
+	s := parseHandlers(t)
+
+	// The variables of every route of each handler, which are no queries,
+	// and the ones of some of them, which are queries of the rest.
+	every, some := map[string]map[string]bool{}, map[string]map[string]bool{}
+	newRouter().Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		h := route.GetHandler()
+		if h == nil {
+			return nil
+		}
+		template, _ := route.GetPathTemplate()
+		queries, _ := route.GetQueriesTemplates()
+		for _, q := range queries {
+			template += "/{" + strings.SplitN(q, "=", 2)[0] + "}"
+		}
+		_, parameters := templateParameters(template)
+
+		name := handlerName(h)
+		vars := map[string]bool{}
+		if some[name] == nil {
+			some[name] = map[string]bool{}
+		}
+		for _, p := range parameters {
+			if all, ok := every[name]; !ok || all[p.Name] {
+				vars[p.Name] = true
+			}
+			some[name][p.Name] = true
+		}
+		every[name] = vars
+		return nil
+	})
+
+	for name, op := range operations {
+		if s.funcs[name] == nil {
+			t.Errorf("Operation %v has no handler", name)
+			continue
+		}
+		queries, statuses := s.read(name)
+
+		for q := range queries {
+			// The campaign is documented for the routes outside of one.
+			documented := op.Query[q] != "" || every[name][q] || (q == "campaign" && some[name][q])
+			if !documented && !ignoredQueries[name][q] {
+				t.Errorf("%v reads the undocumented query %v", name, q)
+			}
+		}
+		for q := range op.Query {
+			if !queries[q] && !every[name][q] {
+				t.Errorf("%v documents the query %v, which it does not read", name, q)
+			}
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		var success []string
+		for _, s := range successStatuses {
+			if statuses[s] {
+				success = append(success, s)
+			}
+		}
+		if len(success) > 0 && !statuses[successStatuses[status]] {
+			t.Errorf("%v responds with %v, but documents %v", name, success, successStatuses[status])
+		}
+	}
+}
+
+func TestDocsPage(t *testing.T) {
+	doc, _ := newOpenAPI(newRouter())
+
+	var b bytes.Buffer
+	if err := docsTemplate.Execute(&b, newDocsPage(doc)); err != nil {
+		t.Fatal(err)
+	}
+	page := b.String()
+
+	for _, want := range []string{
+		`<h2 id="tag-player">player</h2>`,
+		`<span class="method">PUT</span> /api/v1/player/{name}`,
+		`<a href="#schema-Roll">Roll</a>`,
+		`<a href="#schema-Creature">[]Creature</a>`,
+		`<h3 id="schema-Tabletop">Tabletop</h3>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Documentation page misses %v", want)
+		}
+	}
+	if strings.Contains(page, "<script") {
+		t.Errorf("Documentation page loads a script")
+	}
+}
@@ -0,0 +1,649 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/aakordas/creature_manager/pkg/conditions"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/encounter"
+	"github.com/aakordas/creature_manager/pkg/history"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var encountersCollection = "encounters"
+
+// maximumMonsters indicates the maximum number of monsters of each kind an
+// encounter can be rated with.
+const maximumMonsters = 100
+
+// encounterResponse models the state of an encounter, along with the conditions
+// that expired when the current turn began.
+type encounterResponse struct {
+	encounter.Encounter `bson:",inline"`
+
+	Current *encounter.Combatant   `json:"current,omitempty" bson:"current,omitempty"`
+	Expired []conditions.Condition `json:"expired,omitempty" bson:"expired,omitempty"`
+}
+
+// difficultyRequest models an encounter to rate. Each monster is either an
+// existing creature, by name, or just a challenge rating.
+type difficultyRequest struct {
+	Party    []string `json:"party"`
+	Monsters []struct {
+		Name            string `json:"name"`
+		ChallengeRating string `json:"challenge_rating"`
+		Count           int    `json:"count"` // One, if missing, and at most maximumMonsters.
+	} `json:"monsters"`
+}
+
+// encounterRoutes properly initializes the routes for the encounter part of the
+// server. Encounters either belong to a campaign or to none, like creatures.
+func encounterRoutes(r *mux.Router) *mux.Router {
+	var (
+		name      = "{encounter:[a-zA-Z0-9 ]+}"
+		combatant = "{name:" + creatureName + "}"
+	)
+
+	api := r.PathPrefix("/api/v1/").Subrouter()
+	api.Use(requireUser)
+
+	for _, prefix := range []string{"/encounters", "/campaigns/{campaign:[a-zA-Z0-9 _-]+}/encounters"} {
+		sub := api.PathPrefix(prefix).Subrouter()
+		sub.Use(encounterAccess)
+
+		sub.HandleFunc("", GetEncounters).Methods(http.MethodGet)
+		sub.HandleFunc("/difficulty", RateEncounter).Methods(http.MethodPost)
+
+		e := "/" + name
+		sub.HandleFunc(e, AddEncounter).Methods(http.MethodPut)
+		sub.HandleFunc(e, GetEncounter).Methods(http.MethodGet)
+		sub.HandleFunc(e, DeleteEncounter).Methods(http.MethodDelete)
+		sub.HandleFunc(e+"/combatants/"+combatant, AddCombatant).Methods(http.MethodPut)
+		sub.HandleFunc(e+"/combatants/"+combatant, RemoveCombatant).Methods(http.MethodDelete)
+		sub.HandleFunc(e+"/combatants/"+combatant+"/resume", ResumeTurn).Methods(http.MethodPost)
+		sub.HandleFunc(e+"/start", StartCombat).Methods(http.MethodPost)
+		sub.HandleFunc(e+"/next", NextTurn).Methods(http.MethodPost)
+		sub.HandleFunc(e+"/delay", DelayTurn).Methods(http.MethodPost)
+		sub.HandleFunc(e+"/ready", ReadyAction).Methods(http.MethodPost)
+		sub.HandleFunc(e+"/end", EndCombat).Methods(http.MethodPost)
+	}
+
+	return r
+}
+
+// createEncounterIndexes makes the names of the encounters unique in each
+// campaign.
+func createEncounterIndexes(db *mongo.Database) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	_, err := db.Collection(encountersCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "campaign", Value: 1},
+			{Key: "name", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// encounterAccess rejects the requests for the encounters of a campaign from
+// users who take no part in it. The handlers that change an encounter check
+// for the dungeon master of its campaign, or for its owner, themselves.
+func encounterAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		name := campaignOf(r)
+		if name != "" && campaignRole(ctx, currentUser(r), name) == "" {
+			forbiddenResponse(w, "Only the users of the campaign can access its encounters.")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// encounterFilter returns the filter that matches the requested encounter, in
+// the campaign of the request, if any.
+func encounterFilter(r *http.Request) bson.M {
+	return bson.M{
+		"name":     mux.Vars(r)["encounter"],
+		"campaign": campaignFilter(campaignOf(r)),
+	}
+}
+
+// mayReadEncounter checks whether the user may see the provided encounter. The
+// encounters of a campaign are left to its users, which the routes check, and
+// the ones outside of any campaign to their owner. The ones without an owner,
+// like the ones stored before the accounts, can be read by no one, like they
+// can be changed by no one.
+func mayReadEncounter(user string, e *encounter.Encounter) bool {
+	return e.Campaign != "" || (e.Owner != "" && e.Owner == user)
+}
+
+// mayChangeEncounter checks whether the user may change the provided
+// encounter. The encounters of a campaign are left to its dungeon master and
+// the ones outside of any campaign to their owner.
+func mayChangeEncounter(ctx context.Context, user string, e *encounter.Encounter) bool {
+	if e.Campaign == "" {
+		return e.Owner != "" && e.Owner == user
+	}
+
+	return isDungeonMaster(ctx, user, e.Campaign)
+}
+
+// newEncounterResponse creates the response for the provided encounter.
+func newEncounterResponse(e *encounter.Encounter, expired []conditions.Condition) encounterResponse {
+	return encounterResponse{
+		Encounter: *e,
+		Current:   e.Current(),
+		Expired:   expired,
+	}
+}
+
+// tickConditions counts down the conditions of the creature of the campaign
+// whose turn began, on behalf of the provided user, returning the ones that
+// expired.
+func tickConditions(ctx context.Context, user, campaign, name string) ([]conditions.Condition, error) {
+	playersCollection := playersDatabase.Collection(players)
+
+	var c creature.Creature
+	if err := findPlayer(ctx, playersCollection, campaign, name).Decode(&c); err != nil {
+		// The creature may have been deleted in the meantime.
+		return nil, nil
+	}
+	if len(c.Conditions) == 0 {
+		return nil, nil
+	}
+
+	remaining, expired := conditions.Tick(c.Conditions)
+	if remaining == nil {
+		remaining = []conditions.Condition{}
+	}
+	// A creature changed in the meantime keeps its conditions until its next
+	// turn.
+	_, err := changeCreature(ctx, user, history.Update, unchangedFilter(&c), bson.M{
+		"$set": bson.M{
+			"conditions": remaining,
+		}}, false)
+	if err == mongo.ErrNoDocuments || err == errChanged {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return expired, nil
+}
+
+// changeEncounter fetches the requested encounter, applies change to it and
+// stores it, responding with its new state. If change begins the turn of a
+// combatant, its conditions are counted down. Only the dungeon master of the
+// campaign of an encounter, or its owner outside of any campaign, can change
+// it. An encounter changed since it was fetched is left as is, so that
+// concurrent changes do not overwrite each other.
+func changeEncounter(w http.ResponseWriter, r *http.Request, change func(e *encounter.Encounter) (*encounter.Combatant, error)) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	name := mux.Vars(r)["encounter"]
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(encountersCollection)
+
+	var e encounter.Encounter
+	if err := col.FindOne(ctx, encounterFilter(r)).Decode(&e); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		sendErrorResponse(w, enc,
+			"encounter not found",
+			"No encounter found with name "+name,
+			http.StatusNotFound,
+		)
+		return
+	}
+	if !mayChangeEncounter(ctx, currentUser(r), &e) {
+		forbiddenResponse(w, "Only the owner of the encounter or the dungeon master of its campaign can change it.")
+		return
+	}
+
+	began, err := change(&e)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid encounter operation",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	f := encounterFilter(r)
+	f["version"] = versionFilter(e.Version)
+	e.Version++
+	result, err := col.ReplaceOne(ctx, f, e)
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error updating the entry in the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+	if result.MatchedCount == 0 {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		sendErrorResponse(w, enc,
+			"precondition failed",
+			"The encounter was changed in the meantime. Please retry.",
+			http.StatusPreconditionFailed,
+		)
+		return
+	}
+
+	var expired []conditions.Condition
+	if began != nil {
+		expired, err = tickConditions(ctx, currentUser(r), e.Campaign, began.Name)
+		if err != nil {
+			log.Println(err)
+			sendErrorResponse(w, enc, databaseError,
+				"There was an error updating the entry in the database.",
+				http.StatusInternalServerError,
+			)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, newEncounterResponse(&e, expired))
+}
+
+// GetEncounters is the handler that returns the encounters of the campaign of
+// the request, sorted by name. Outside of any campaign, it returns the ones of
+// the user.
+func GetEncounters(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(encountersCollection)
+
+	campaign := campaignOf(r)
+	filter := bson.M{"campaign": campaignFilter(campaign)}
+	if campaign == "" {
+		filter["owner"] = currentUser(r)
+	}
+
+	opts := options.Find().SetSort(bson.M{"name": 1})
+	cursor, err := col.Find(ctx, filter, opts)
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	result := []encounter.Encounter{}
+	if err := cursor.All(ctx, &result); err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, result)
+}
+
+// AddEncounter is the handler that creates a new encounter, owned by the user
+// who made the request. Its combatants come from the campaign of the request,
+// if any, whose dungeon master alone can create it.
+func AddEncounter(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	name, campaign := mux.Vars(r)["encounter"], campaignOf(r)
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	if campaign != "" && !isDungeonMaster(ctx, currentUser(r), campaign) {
+		forbiddenResponse(w, "Only the dungeon master of the campaign can change its encounters.")
+		return
+	}
+
+	col := playersDatabase.Collection(encountersCollection)
+
+	err := col.FindOne(ctx, encounterFilter(r)).Err()
+	if err != mongo.ErrNoDocuments {
+		sendErrorResponse(w, enc,
+			"encounter exists",
+			"An encounter with the provided name already exists in the campaign.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	_, err = col.InsertOne(ctx, encounter.Encounter{
+		Name:       name,
+		Campaign:   campaign,
+		Owner:      currentUser(r),
+		Combatants: []encounter.Combatant{},
+	})
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error inserting the new entry in the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// GetEncounter is the handler that returns the state of an encounter.
+func GetEncounter(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(encountersCollection)
+
+	var e encounter.Encounter
+	if err := col.FindOne(ctx, encounterFilter(r)).Decode(&e); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		jsonEncode(w, enc, emptyResponse{})
+		return
+	}
+	if !mayReadEncounter(currentUser(r), &e) {
+		forbiddenResponse(w, "Only the owner of the encounter can see it.")
+		return
+	}
+
+	w.WriteHeader(http.StatusFound)
+	jsonEncode(w, enc, newEncounterResponse(&e, nil))
+}
+
+// DeleteEncounter is the handler that deletes an encounter.
+func DeleteEncounter(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(encountersCollection)
+
+	var e encounter.Encounter
+	err := col.FindOne(ctx, encounterFilter(r)).Decode(&e)
+	if err == nil && !mayChangeEncounter(ctx, currentUser(r), &e) {
+		forbiddenResponse(w, "Only the owner of the encounter or the dungeon master of its campaign can change it.")
+		return
+	}
+
+	_, err = col.DeleteOne(ctx, encounterFilter(r))
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error deleting the entry from the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// AddCombatant is the handler that adds the requested creature to an
+// encounter. The initiative query provides its initiative, instead of rolling
+// it.
+func AddCombatant(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	name := mux.Vars(r)["name"]
+	var initiative int
+	if i := r.FormValue("initiative"); i != "" {
+		var err error
+		initiative, err = strconv.Atoi(i)
+		if err != nil {
+			sendErrorResponse(w, enc,
+				"invalid initiative",
+				"Please provide a valid numeric value.",
+				http.StatusBadRequest,
+			)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	// The combatants come from the campaign of the encounter.
+	var e encounter.Encounter
+	col := playersDatabase.Collection(encountersCollection)
+	opts := options.FindOne().SetProjection(bson.M{"campaign": 1})
+	if err := col.FindOne(ctx, encounterFilter(r), opts).Decode(&e); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		sendErrorResponse(w, enc,
+			"encounter not found",
+			"No encounter found with name "+mux.Vars(r)["encounter"],
+			http.StatusNotFound,
+		)
+		return
+	}
+
+	var c creature.Creature
+	if err := findPlayer(ctx, playersDatabase.Collection(players), e.Campaign, name).Decode(&c); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		sendErrorResponse(w, enc,
+			"player not found",
+			missingPlayerError{name}.Error(),
+			http.StatusNotFound,
+		)
+		return
+	}
+
+	kind := c.Kind
+	if kind == "" {
+		kind = creature.Player
+	}
+
+	changeEncounter(w, r, func(e *encounter.Encounter) (*encounter.Combatant, error) {
+		return nil, e.Add(encounter.Combatant{
+			Name:              c.Name,
+			Kind:              kind,
+			DexterityModifier: c.DexterityModifier,
+			Initiative:        initiative,
+		}, nil)
+	})
+}
+
+// RemoveCombatant is the handler that removes the requested creature from an
+// encounter.
+func RemoveCombatant(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	changeEncounter(w, r, func(e *encounter.Encounter) (*encounter.Combatant, error) {
+		var current string
+		if c := e.Current(); c != nil {
+			current = c.Name
+		}
+		if err := e.Remove(name); err != nil {
+			return nil, err
+		}
+
+		// Removing the current combatant begins the turn of the next one.
+		if current == name {
+			return e.Current(), nil
+		}
+		return nil, nil
+	})
+}
+
+// StartCombat is the handler that rolls initiative for an encounter and starts
+// its first round.
+func StartCombat(w http.ResponseWriter, r *http.Request) {
+	changeEncounter(w, r, func(e *encounter.Encounter) (*encounter.Combatant, error) {
+		if err := e.Start(nil); err != nil {
+			return nil, err
+		}
+		return e.Current(), nil
+	})
+}
+
+// NextTurn is the handler that ends the current turn of an encounter.
+func NextTurn(w http.ResponseWriter, r *http.Request) {
+	changeEncounter(w, r, func(e *encounter.Encounter) (*encounter.Combatant, error) {
+		return e.Next()
+	})
+}
+
+// DelayTurn is the handler that makes the current combatant of an encounter
+// delay its turn.
+func DelayTurn(w http.ResponseWriter, r *http.Request) {
+	changeEncounter(w, r, func(e *encounter.Encounter) (*encounter.Combatant, error) {
+		return e.Delay()
+	})
+}
+
+// ResumeTurn is the handler that makes the requested delayed combatant of an
+// encounter take its turn.
+func ResumeTurn(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	changeEncounter(w, r, func(e *encounter.Encounter) (*encounter.Combatant, error) {
+		return e.Resume(name)
+	})
+}
+
+// ReadyAction is the handler that makes the current combatant of an encounter
+// ready an action, for the trigger in the trigger query, and end its turn.
+func ReadyAction(w http.ResponseWriter, r *http.Request) {
+	trigger := r.FormValue("trigger")
+
+	changeEncounter(w, r, func(e *encounter.Encounter) (*encounter.Combatant, error) {
+		return e.Ready(trigger)
+	})
+}
+
+// EndCombat is the handler that ends the combat of an encounter.
+func EndCombat(w http.ResponseWriter, r *http.Request) {
+	changeEncounter(w, r, func(e *encounter.Encounter) (*encounter.Combatant, error) {
+		e.End()
+		return nil, nil
+	})
+}
+
+// RateEncounter is the handler that calculates the difficulty of an encounter
+// for a party of the campaign of the request, if any, per the rules of the
+// Dungeon Master's Guide. The party and the monsters are provided as
+// JSON in the body of the request, e.g. {"party": ["Merry", "Pippin"],
+// "monsters": [{"name": "Goblin", "count": 3}, {"challenge_rating": "1"}]}.
+func RateEncounter(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	var request difficultyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || len(request.Party) == 0 {
+		sendErrorResponse(w, enc,
+			"invalid encounter",
+			"Please provide the party and the monsters as a JSON object.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	playersCollection := playersDatabase.Collection(players)
+	campaign := campaignOf(r)
+
+	levels := make([]int, 0, len(request.Party))
+	for _, name := range request.Party {
+		var c creature.Creature
+		if err := findPlayer(ctx, playersCollection, campaign, name).Decode(&c); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			sendErrorResponse(w, enc,
+				"player not found",
+				missingPlayerError{name}.Error(),
+				http.StatusNotFound,
+			)
+			return
+		}
+		if creature.OutOfRange(c.Level) {
+			sendErrorResponse(w, enc,
+				"invalid level",
+				"Every member of the party needs a level from 1 to 20, which "+name+" lacks.",
+				http.StatusBadRequest,
+			)
+			return
+		}
+		levels = append(levels, c.Level)
+	}
+
+	monsters := make([]encounter.Group, 0, len(request.Monsters))
+	for _, m := range request.Monsters {
+		rating := m.ChallengeRating
+		if m.Name != "" {
+			var c creature.Creature
+			if err := findPlayer(ctx, playersCollection, campaign, m.Name).Decode(&c); err != nil {
+				w.WriteHeader(http.StatusNotFound)
+				sendErrorResponse(w, enc,
+					"player not found",
+					missingPlayerError{m.Name}.Error(),
+					http.StatusNotFound,
+				)
+				return
+			}
+			rating = c.ChallengeRating
+		}
+		if !creature.ValidChallengeRating(rating) {
+			sendErrorResponse(w, enc,
+				"invalid challenge rating",
+				"Every monster needs a challenge rating from 0 to 30, like 1/4 or 5.",
+				http.StatusBadRequest,
+			)
+			return
+		}
+
+		count := m.Count
+		if count < 1 {
+			count = 1
+		}
+		if count > maximumMonsters {
+			sendErrorResponse(w, enc,
+				"invalid count",
+				"Please provide at most "+strconv.Itoa(maximumMonsters)+" monsters of each kind.",
+				http.StatusBadRequest,
+			)
+			return
+		}
+		monsters = append(monsters, encounter.Group{
+			ExperiencePoints: creature.ExperiencePerChallengeRating[rating],
+			Count:            count,
+		})
+	}
+
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, encounter.Rate(levels, monsters))
+}
@@ -0,0 +1,306 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/experience"
+	"github.com/aakordas/creature_manager/pkg/history"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// experienceResponse models the state of a creature after it gained experience
+// or levels.
+type experienceResponse struct {
+	Name             string              `json:"name" bson:"name"`
+	ExperiencePoints int                 `json:"experience_points" bson:"experience_points"`
+	Level            int                 `json:"level" bson:"level"`
+	LevelUpAvailable bool                `json:"level_up_available" bson:"level_up_available"` // Whether the creature can still level up.
+	Changes          []experience.Change `json:"changes,omitempty" bson:"changes,omitempty"`
+}
+
+// hitPointsRoller returns the roller that the hp query asks for. Hit points are
+// rolled when asked so, otherwise the average of the hit die is taken.
+func hitPointsRoller(r *http.Request) experience.Roller {
+	if r.FormValue("hp") == "roll" {
+		return rollHitDie
+	}
+
+	return nil
+}
+
+// advancementUpdate returns the update that stores everything that changes
+// when a creature gains experience or levels.
+func advancementUpdate(player *creature.Creature) bson.M {
+	set := bson.M{
+		"experience_points":  player.ExperiencePoints,
+		"level":              player.Level,
+		"classes":            player.Classes,
+		"hit_dice":           player.HitDice,
+		"hit_points":         player.CurrentHitPoints,
+		"maximum_hit_points": player.MaximumHitPoints,
+		"proficiency_bonus":  player.ProficiencyBonus,
+		"passive_perception": player.PassivePerception,
+		"skills":             player.Skills,
+		"saving_throws":      player.SavingThrows,
+	}
+	if player.Spellcasting != nil {
+		set["spellcasting"] = player.Spellcasting
+	}
+
+	return bson.M{
+		"$set": set,
+	}
+}
+
+// newExperienceResponse creates the response for a creature that gained
+// experience or levels.
+func newExperienceResponse(player *creature.Creature, changes []experience.Change) experienceResponse {
+	return experienceResponse{
+		Name:             player.Name,
+		ExperiencePoints: player.ExperiencePoints,
+		Level:            player.Level,
+		LevelUpAvailable: experience.CanLevelUp(player) == nil,
+		Changes:          changes,
+	}
+}
+
+// AwardExperience is the handler that gives the provided experience points to
+// the requested creature, leveling it up if possible.
+func AwardExperience(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	v := vars["number"]
+	value, err := strconv.Atoi(v)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid value",
+			"Please provide a valid numeric value.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	player, err := getPlayer(w, r)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"Could not fetch the player.",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	changes := experience.Award(player, value, hitPointsRoller(r))
+
+	f := unchangedFilter(player)
+	if err := setNoUpsert(w, r, enc, f, advancementUpdate(player)); err != nil {
+		return
+	}
+
+	jsonEncode(w, enc, newExperienceResponse(player, changes))
+}
+
+// uniqueNames returns the provided names without the repeated ones, in the
+// order they first appear.
+func uniqueNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		unique = append(unique, name)
+	}
+
+	return unique
+}
+
+// AwardPartyExperience is the handler that splits the provided experience
+// points evenly among the creatures named in the player query, e.g.
+// ?player=Merry&player=Pippin. A creature named more than once gets a single
+// share, and either every creature gets its share or none does.
+func AwardPartyExperience(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	v := vars["number"]
+	value, err := strconv.Atoi(v)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid value",
+			"Please provide a valid numeric value.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	if err := r.ParseForm(); err != nil || len(r.Form["player"]) == 0 {
+		sendErrorResponse(w, enc,
+			"no players",
+			"Please provide the players that share the experience.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	names := uniqueNames(r.Form["player"])
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	playersCollection := playersDatabase.Collection(players)
+
+	party := make([]*creature.Creature, 0, len(names))
+	for _, name := range names {
+		var player creature.Creature
+		err := findPlayer(ctx, playersCollection, campaignOf(r), name).Decode(&player)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			sendErrorResponse(w, enc,
+				"player not found",
+				missingPlayerError{name}.Error(),
+				http.StatusNotFound,
+			)
+			return
+		}
+		if !canEdit(ctx, currentUser(r), &player) {
+			forbiddenResponse(w, "Only the owner of the creature or the dungeon master of its campaign can change it.")
+			return
+		}
+		party = append(party, &player)
+	}
+
+	share := experience.Split(value, len(party))
+	roll := hitPointsRoller(r)
+
+	responses := make([]experienceResponse, 0, len(party))
+	for _, player := range party {
+		changes := experience.Award(player, share, roll)
+		responses = append(responses, newExperienceResponse(player, changes))
+	}
+
+	err = inTransaction(ctx, func(ctx context.Context) error {
+		for _, player := range party {
+			_, err := changeCreature(ctx, currentUser(r), history.Update, unchangedFilter(player), advancementUpdate(player), false)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == mongo.ErrNoDocuments || err == errChanged {
+		preconditionFailedResponse(w, enc)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error updating the entry in the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, responses)
+}
+
+// SetAdvancement is the handler that sets whether the requested creature levels
+// up by experience or by milestones.
+func SetAdvancement(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	var milestone bool
+	switch strings.ToLower(vars["advancement"]) {
+	case experience.Experience:
+		milestone = false
+	case experience.Milestone:
+		milestone = true
+	default:
+		sendErrorResponse(w, enc,
+			"invalid advancement",
+			"A creature advances either by experience or by milestone.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	f := creatureFilter(r)
+	u := bson.M{
+		"$set": bson.M{
+			"milestone": milestone,
+		}}
+
+	setNoUpsert(w, r, enc, f, u)
+}
+
+// LevelUp is the handler that raises the level of the requested creature by
+// one, in the class provided by the class query.
+func LevelUp(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	player, err := getPlayer(w, r)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"Could not fetch the player.",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	class := strings.ToLower(r.FormValue("class"))
+	change, err := experience.LevelUp(player, class, hitPointsRoller(r))
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"cannot level up",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	f := unchangedFilter(player)
+	if err := setNoUpsert(w, r, enc, f, advancementUpdate(player)); err != nil {
+		return
+	}
+
+	jsonEncode(w, enc, newExperienceResponse(player, []experience.Change{change}))
+}
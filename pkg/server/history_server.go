@@ -0,0 +1,457 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/history"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	historyCollection = "history"
+	deletedCollection = "deleted"
+)
+
+const (
+	// deletedRetention indicates how long deleted creatures can be restored.
+	deletedRetention = 30 * 24 * time.Hour
+	// maximumEvents indicates the maximum number of events returned from the
+	// history of a creature.
+	maximumEvents = 100
+)
+
+// errChanged is the error that gets returned when a creature changed between
+// reading and updating it.
+var errChanged = errors.New("the creature was changed in the meantime")
+
+// createHistoryIndexes creates the indexes of the history of the creatures and
+// of the deleted ones, which expire after the retention window.
+func createHistoryIndexes(db *mongo.Database) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	_, err := db.Collection(historyCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "creature", Value: 1},
+			{Key: "_id", Value: -1},
+		},
+	})
+	if err != nil {
+		log.Println(err)
+	}
+
+	_, err = db.Collection(deletedCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"deleted_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(int32(deletedRetention / time.Second)),
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// recordEvent stores the provided event in the history of its creature. The
+// change has already happened, so failing to record it is only logged.
+func recordEvent(ctx context.Context, e history.Event) {
+	if _, err := playersDatabase.Collection(historyCollection).InsertOne(ctx, e); err != nil {
+		log.Println(err)
+	}
+}
+
+// changeCreature applies the update to the creature of the filter, on behalf of
+// the provided user, and records the change in its history. It returns the
+// creature after the change, mongo.ErrNoDocuments if no creature matches the
+// filter and errChanged if it changed while being updated. A missing creature
+// is created if upsert is set.
+func changeCreature(ctx context.Context, user, action string, filter, update bson.M, upsert bool) (bson.M, error) {
+	playersCollection := playersDatabase.Collection(players)
+
+	var before bson.M
+	err := playersCollection.FindOne(ctx, filter).Decode(&before)
+	if err == mongo.ErrNoDocuments && upsert {
+		before = bson.M{}
+	} else if err != nil {
+		return nil, err
+	}
+
+	// The update applies only to the version that was read, so that the
+	// recorded change is exactly the one that happened.
+	f := filter
+	if id, ok := before["_id"]; ok {
+		f = bson.M{
+			"_id":     id,
+			"version": versionFilter(versionOf(before)),
+		}
+	}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetUpsert(len(before) == 0)
+	var after bson.M
+	err = playersCollection.FindOneAndUpdate(ctx, f, versioned(update), opts).Decode(&after)
+	if err == mongo.ErrNoDocuments {
+		return nil, errChanged
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	e := history.Diff(before, after)
+	if len(e.Fields) > 0 {
+		e.Creature, _ = after["_id"].(primitive.ObjectID)
+		e.User = user
+		e.Time = time.Now()
+		e.Action = action
+		e.Version = versionOf(after)
+		recordEvent(ctx, e)
+	}
+
+	return after, nil
+}
+
+// replaceCreature replaces the provided creature with c, on behalf of the
+// provided user, and records the change in its history. The creature keeps its
+// ID and moves to a new version. It returns errChanged if the creature changed
+// since it was read.
+func replaceCreature(ctx context.Context, user string, old *creature.Creature, c creature.Creature) error {
+	c.ID = old.ID
+	c.Version = old.Version + 1
+
+	result, err := playersDatabase.Collection(players).ReplaceOne(ctx, unchangedFilter(old), c)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errChanged
+	}
+
+	before, err := creatureDocument(*old)
+	if err != nil {
+		return err
+	}
+	after, err := creatureDocument(c)
+	if err != nil {
+		return err
+	}
+
+	e := history.Diff(before, after)
+	if len(e.Fields) > 0 {
+		e.Creature = c.ID
+		e.User = user
+		e.Time = time.Now()
+		e.Action = history.Update
+		e.Version = c.Version
+		recordEvent(ctx, e)
+	}
+
+	return nil
+}
+
+// creatureDocument returns the document the provided creature is stored as.
+func creatureDocument(c creature.Creature) (bson.M, error) {
+	var doc bson.M
+	b, err := bson.Marshal(c)
+	if err == nil {
+		err = bson.Unmarshal(b, &doc)
+	}
+
+	return doc, err
+}
+
+// documentCreature returns the creature stored as the provided document.
+func documentCreature(doc bson.M) (creature.Creature, error) {
+	var c creature.Creature
+	b, err := bson.Marshal(doc)
+	if err == nil {
+		err = bson.Unmarshal(b, &c)
+	}
+
+	return c, err
+}
+
+// versionOf returns the version of the creature of the provided document.
+func versionOf(doc bson.M) int {
+	switch v := doc["version"].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	default:
+		return 0
+	}
+}
+
+// GetHistory is the handler that returns the latest changes to the requested
+// creature, newest first.
+func GetHistory(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	c, err := getPlayer(w, r)
+	if err != nil || !revealed(w, r, c) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(historyCollection)
+
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(maximumEvents)
+	cursor, err := col.Find(ctx, bson.M{"creature": c.ID}, opts)
+	events := []history.Event{}
+	if err == nil {
+		err = cursor.All(ctx, &events)
+	}
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, events)
+}
+
+// Undo is the handler that reverts the latest changes to the requested creature
+// that have not been undone yet, as many as the provided number. The undo is a
+// change of its own, which shows up in the history but cannot be undone.
+// Renames are permanent, so the changes of only the name or the template of the
+// creature are skipped.
+func Undo(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	count, err := strconv.Atoi(mux.Vars(r)["number"])
+	if err != nil || count < 1 || count > maximumEvents {
+		sendErrorResponse(w, enc,
+			"invalid count",
+			"Please provide a number of changes from 1 to 100.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	c, err := getPlayer(w, r)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(historyCollection)
+
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(int64(count))
+	cursor, err := col.Find(ctx, bson.M{
+		"creature": c.ID,
+		"action":   history.Update,
+		"undone":   bson.M{"$ne": true},
+		"fields":   bson.M{"$elemMatch": bson.M{"$nin": history.Permanent}},
+	}, opts)
+	var events []history.Event
+	if err == nil {
+		err = cursor.All(ctx, &events)
+	}
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	update := history.Revert(events)
+	if update == nil {
+		sendErrorResponse(w, enc,
+			"nothing to undo",
+			"The creature has no changes left to undo.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	after, err := changeCreature(ctx, currentUser(r), history.Undo, unchangedFilter(c), update, false)
+	switch {
+	case err == mongo.ErrNoDocuments || err == errChanged:
+		preconditionFailedResponse(w, enc)
+		return
+	case err != nil:
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error updating the entry in the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	ids := make(bson.A, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	_, err = col.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{
+		"$set": bson.M{
+			"undone": true,
+		}})
+	if err != nil {
+		log.Println(err)
+	}
+
+	undone, err := documentCreature(after)
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, serverError,
+			"An error was encountered while processing the request.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.Header().Set("ETag", etag(undone.Version))
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, undone)
+}
+
+// deleteCreature moves the creature of the filter to the deleted ones, where it
+// can be restored from until the retention window passes. It returns
+// mongo.ErrNoDocuments if no creature matches the filter.
+func deleteCreature(ctx context.Context, user string, filter bson.M) error {
+	playersCollection := playersDatabase.Collection(players)
+
+	var doc bson.M
+	if err := playersCollection.FindOne(ctx, filter).Decode(&doc); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deleted := bson.M{}
+	for k, v := range doc {
+		deleted[k] = v
+	}
+	deleted["_id"] = primitive.NewObjectID()
+	deleted["creature"] = doc["_id"]
+	deleted["deleted_at"] = now
+	deleted["deleted_by"] = user
+
+	if _, err := playersDatabase.Collection(deletedCollection).InsertOne(ctx, deleted); err != nil {
+		return err
+	}
+
+	result, err := playersCollection.DeleteOne(ctx, bson.M{
+		"_id":     doc["_id"],
+		"version": versionFilter(versionOf(doc)),
+	})
+	if err == nil && result.DeletedCount == 0 {
+		err = errChanged
+	}
+	if err != nil {
+		// The creature stays, so its deleted copy should not.
+		playersDatabase.Collection(deletedCollection).DeleteOne(ctx, bson.M{"_id": deleted["_id"]})
+		return err
+	}
+
+	recordEvent(ctx, history.Event{
+		Creature: doc["_id"].(primitive.ObjectID),
+		User:     user,
+		Time:     now,
+		Action:   history.Delete,
+		Version:  versionOf(doc),
+	})
+
+	return nil
+}
+
+// RestoreCreature is the handler that brings back the requested creature, the
+// latest one deleted with its name or ID, unless another creature took its name
+// in the meantime.
+func RestoreCreature(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	name := mux.Vars(r)["name"]
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	deleted := playersDatabase.Collection(deletedCollection)
+
+	f := creatureFilter(r)
+	if id, ok := f["_id"]; ok {
+		delete(f, "_id")
+		f["creature"] = id
+	}
+	var doc bson.M
+	opts := options.FindOne().SetSort(bson.M{"deleted_at": -1})
+	if err := deleted.FindOne(ctx, f, opts).Decode(&doc); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		sendErrorResponse(w, enc,
+			"player not found",
+			"No deleted player found with name "+name,
+			http.StatusNotFound,
+		)
+		return
+	}
+
+	c, err := documentCreature(doc)
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, serverError,
+			"An error was encountered while processing the request.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+	c.ID, _ = doc["creature"].(primitive.ObjectID)
+	c.Version++
+
+	if !canEdit(ctx, currentUser(r), &c) {
+		forbiddenResponse(w, "Only the owner of the creature or the dungeon master of its campaign can restore it.")
+		return
+	}
+
+	playersCollection := playersDatabase.Collection(players)
+
+	if findPlayer(ctx, playersCollection, c.Campaign, c.Name).Err() != mongo.ErrNoDocuments {
+		sendErrorResponse(w, enc,
+			"player exists",
+			"A player with the provided name already exists in the database.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	if _, err := playersCollection.InsertOne(ctx, c); err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error inserting the new entry in the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+	if _, err := deleted.DeleteOne(ctx, bson.M{"_id": doc["_id"]}); err != nil {
+		log.Println(err)
+	}
+
+	recordEvent(ctx, history.Event{
+		Creature: c.ID,
+		User:     currentUser(r),
+		Time:     time.Now(),
+		Action:   history.Restore,
+		Version:  c.Version,
+	})
+
+	w.Header().Set("ETag", etag(c.Version))
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, c)
+}
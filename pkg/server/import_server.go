@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/importer"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// importResponse models the outcome of importing monsters.
+type importResponse struct {
+	Imported []string `json:"imported" bson:"imported"`
+	Skipped  []string `json:"skipped" bson:"skipped"` // Monsters that were not imported, because their name is invalid or taken.
+}
+
+// StoreMonsters stores the provided monsters in the provided campaign, or
+// outside of any campaign if it is empty. Monsters whose name is invalid are
+// skipped, and so are the ones whose name is taken, unless overwrite is set, the existing creature is a monster too and
+// the provided user may change it. The new monsters belong to the provided
+// user, if any, while the replaced ones keep their owner. The replacements are
+// recorded in the history of the monsters as changes of the provided user.
+// Without a user, as when importing from the command line, every monster may
+// be replaced.
+func StoreMonsters(monsters []creature.Creature, campaign, user string, overwrite bool) (imported, skipped []string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*contextTimeout)
+	defer cancel()
+
+	playersCollection := playersDatabase.Collection(players)
+
+	imported, skipped = []string{}, []string{}
+	for _, m := range monsters {
+		if !nameable(m.Name) {
+			skipped = append(skipped, m.Name)
+			continue
+		}
+		m.Campaign = campaign
+		m.Owner = user
+
+		var existing creature.Creature
+		err := findPlayer(ctx, playersCollection, campaign, m.Name).Decode(&existing)
+		switch {
+		case err == mongo.ErrNoDocuments:
+			_, err = playersCollection.InsertOne(ctx, m)
+		case err != nil:
+		case overwrite && existing.Kind == creature.Monster && (user == "" || canEdit(ctx, user, &existing)):
+			m.Owner = existing.Owner
+			err = replaceCreature(ctx, user, &existing, m)
+		default:
+			skipped = append(skipped, m.Name)
+			continue
+		}
+		if err != nil {
+			return imported, skipped, err
+		}
+
+		imported = append(imported, m.Name)
+	}
+
+	return imported, skipped, nil
+}
+
+// ImportMonsters is the handler that imports the monsters of the stat blocks in
+// the body of the request. The body is either a single stat block, an array of
+// them or an Open5e page. Existing monsters are replaced only if the overwrite
+// query is true.
+func ImportMonsters(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	campaign := campaignOf(r)
+	if campaign != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		if !campaignExists(ctx, campaign) {
+			w.WriteHeader(http.StatusNotFound)
+			sendErrorResponse(w, enc,
+				"campaign not found",
+				"No campaign found with name "+campaign,
+				http.StatusNotFound,
+			)
+			return
+		}
+		if !isDungeonMaster(ctx, currentUser(r), campaign) {
+			forbiddenResponse(w, "Only the dungeon master of the campaign can import monsters in it.")
+			return
+		}
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid stat block",
+			"The body of the request could not be read.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	monsters, err := importer.Monsters(data)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid stat block",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	imported, skipped, err := StoreMonsters(monsters, campaign, currentUser(r), r.FormValue("overwrite") == "true")
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error inserting the new entry in the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	jsonEncode(w, enc, importResponse{imported, skipped})
+}
@@ -0,0 +1,325 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/history"
+	"github.com/aakordas/creature_manager/pkg/inventory"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// inventoryResponse models the inventory of a creature, along with how much it
+// weighs and how encumbered the creature is.
+type inventoryResponse struct {
+	inventory.Inventory `bson:",inline"`
+
+	Weight           float64 `json:"weight" bson:"weight"`
+	CarryingCapacity float64 `json:"carrying_capacity" bson:"carrying_capacity"`
+	Encumbrance      string  `json:"encumbrance" bson:"encumbrance"`
+}
+
+// newInventoryResponse creates the inventory response of a creature.
+func newInventoryResponse(c *creature.Creature) inventoryResponse {
+	weight := c.Inventory.Weight()
+
+	return inventoryResponse{
+		Inventory:        c.Inventory,
+		Weight:           weight,
+		CarryingCapacity: inventory.CarryingCapacity(c.Abilities.Strength),
+		Encumbrance:      inventory.Encumbrance(c.Abilities.Strength, weight),
+	}
+}
+
+// inventoryUpdate returns the update that stores the inventory of a creature,
+// along with the armor class it results in.
+func inventoryUpdate(c *creature.Creature) bson.M {
+	return bson.M{
+		"$set": bson.M{
+			"inventory":   c.Inventory,
+			"armor_class": calculateArmorClass(c),
+		}}
+}
+
+// changeInventory fetches the requested creature, applies change to it and
+// stores its new inventory, responding with it.
+func changeInventory(w http.ResponseWriter, r *http.Request, change func(c *creature.Creature) error) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	player, err := getPlayer(w, r)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"Could not fetch the player.",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	if err := change(player); err != nil {
+		sendErrorResponse(w, enc,
+			"invalid inventory operation",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	f := unchangedFilter(player)
+	if err := setNoUpsert(w, r, enc, f, inventoryUpdate(player)); err != nil {
+		return
+	}
+
+	jsonEncode(w, enc, newInventoryResponse(player))
+}
+
+// getQuantity gets the integer value of the quantity query. A missing quantity
+// means every item.
+func getQuantity(r *http.Request) (int, error) {
+	quantity := r.FormValue("quantity")
+	if quantity == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(quantity)
+}
+
+// GetInventory is the handler that returns the inventory of a player in the
+// database.
+func GetInventory(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	player, err := getPlayer(w, r)
+	if err != nil || !revealed(w, r, player) {
+		return
+	}
+
+	w.WriteHeader(http.StatusFound)
+	jsonEncode(w, enc, newInventoryResponse(player))
+}
+
+// AddItem is the handler that adds the requested item to the inventory of a
+// creature. The weight, quantity, value and whether the item requires
+// attunement can be provided as JSON in the body of the request.
+func AddItem(w http.ResponseWriter, r *http.Request) {
+	var item inventory.Item
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil && err != io.EOF {
+		enc := json.NewEncoder(w)
+		sendErrorResponse(w, enc,
+			"invalid item",
+			"Please provide the item as a JSON object.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	item.Name = mux.Vars(r)["item"]
+
+	changeInventory(w, r, func(c *creature.Creature) error {
+		return c.Inventory.Add(item)
+	})
+}
+
+// RemoveItem is the handler that removes the requested item from the inventory
+// of a creature. The quantity query limits how many get removed.
+func RemoveItem(w http.ResponseWriter, r *http.Request) {
+	quantity, err := getQuantity(r)
+	if err != nil {
+		countErrResponse(w)
+		return
+	}
+	item := mux.Vars(r)["item"]
+
+	changeInventory(w, r, func(c *creature.Creature) error {
+		_, err := c.Inventory.Remove(item, quantity)
+		return err
+	})
+}
+
+// EquipItem is the handler that equips the requested item of a creature.
+func EquipItem(w http.ResponseWriter, r *http.Request) {
+	item := mux.Vars(r)["item"]
+
+	changeInventory(w, r, func(c *creature.Creature) error {
+		return c.Inventory.Equip(item, true)
+	})
+}
+
+// UnequipItem is the handler that unequips the requested item of a creature.
+func UnequipItem(w http.ResponseWriter, r *http.Request) {
+	item := mux.Vars(r)["item"]
+
+	changeInventory(w, r, func(c *creature.Creature) error {
+		return c.Inventory.Equip(item, false)
+	})
+}
+
+// AttuneItem is the handler that attunes a creature to the requested item.
+func AttuneItem(w http.ResponseWriter, r *http.Request) {
+	item := mux.Vars(r)["item"]
+
+	changeInventory(w, r, func(c *creature.Creature) error {
+		return c.Inventory.Attune(item, true)
+	})
+}
+
+// EndAttunement is the handler that ends the attunement of a creature to the
+// requested item.
+func EndAttunement(w http.ResponseWriter, r *http.Request) {
+	item := mux.Vars(r)["item"]
+
+	changeInventory(w, r, func(c *creature.Creature) error {
+		return c.Inventory.Attune(item, false)
+	})
+}
+
+// SetCurrency is the handler that sets the number of coins of the requested
+// kind a creature has.
+func SetCurrency(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	coin := vars["coin"]
+	n, err := strconv.Atoi(vars["number"])
+	if err != nil {
+		countErrResponse(w)
+		return
+	}
+
+	changeInventory(w, r, func(c *creature.Creature) error {
+		return c.Inventory.Currency.Set(coin, n)
+	})
+}
+
+// ConvertCurrency is the handler that exchanges coins of a creature for coins
+// of another kind.
+func ConvertCurrency(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	from, to := vars["coin"], vars["to"]
+	n, err := strconv.Atoi(vars["number"])
+	if err != nil {
+		countErrResponse(w)
+		return
+	}
+
+	changeInventory(w, r, func(c *creature.Creature) error {
+		return c.Inventory.Currency.Convert(from, to, n)
+	})
+}
+
+// TransferItem is the handler that moves the requested item from the inventory
+// of a creature to the inventory of the target creature. The quantity query
+// limits how many get moved. Both creatures change together, or neither does,
+// and the user has to be allowed to change both.
+func TransferItem(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	targetName := vars["target"]
+	itemName := vars["item"]
+	if playerName == "" || targetName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	if playerName == targetName {
+		sendErrorResponse(w, enc,
+			"invalid inventory operation",
+			"An item cannot be transferred to the creature that carries it.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	quantity, err := getQuantity(r)
+	if err != nil {
+		countErrResponse(w)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	playersCollection := playersDatabase.Collection(players)
+
+	var source, target creature.Creature
+	for _, p := range []struct {
+		name string
+		c    *creature.Creature
+	}{{playerName, &source}, {targetName, &target}} {
+		if err := findPlayer(ctx, playersCollection, campaignOf(r), p.name).Decode(p.c); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			sendErrorResponse(w, enc,
+				"player not found",
+				missingPlayerError{p.name}.Error(),
+				http.StatusNotFound,
+			)
+			return
+		}
+	}
+
+	if !canEdit(ctx, currentUser(r), &target) {
+		forbiddenResponse(w, "Only the owner of the target creature or the dungeon master of its campaign can change it.")
+		return
+	}
+
+	if v, ok := ifMatch(r); ok && v != source.Version {
+		preconditionFailedResponse(w, enc)
+		return
+	}
+
+	item, err := source.Inventory.Remove(itemName, quantity)
+	if err == nil {
+		err = target.Inventory.Add(item)
+	}
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid inventory operation",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	err = inTransaction(ctx, func(ctx context.Context) error {
+		for _, c := range []*creature.Creature{&source, &target} {
+			_, err := changeCreature(ctx, currentUser(r), history.Update, unchangedFilter(c), inventoryUpdate(c), false)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == mongo.ErrNoDocuments || err == errChanged {
+		preconditionFailedResponse(w, enc)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error updating the entry in the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, newInventoryResponse(&source))
+}
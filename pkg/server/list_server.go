@@ -0,0 +1,357 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/classes"
+	"github.com/aakordas/creature_manager/pkg/conditions"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// defaultPageSize and maximumPageSize indicate the number of creatures
+	// in a page of a list, unless requested otherwise, and at most.
+	defaultPageSize = 20
+	maximumPageSize = 100
+)
+
+// sortFields maps the values of the sort query to the fields they sort by.
+var sortFields = map[string]string{
+	"name":  "name",
+	"level": "level",
+	"hp":    "hit_points",
+}
+
+// listError is the error that gets returned when the queries of a list request
+// are invalid.
+type listError struct {
+	Message string
+}
+
+func (e listError) Error() string {
+	return e.Message
+}
+
+// listCursor marks the last creature of a page, so that the next page starts
+// right after it.
+type listCursor struct {
+	Sort  string      `json:"s"`
+	Value interface{} `json:"v"`
+	ID    string      `json:"id"`
+}
+
+// encode returns the opaque form of the cursor that clients pass back.
+func (c listCursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor decodes the opaque cursor of a list sorted by the provided
+// field.
+func decodeCursor(s, sort string) (*listCursor, error) {
+	errInvalid := listError{"The cursor is invalid."}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalid
+	}
+
+	dec := json.NewDecoder(strings.NewReader(string(b)))
+	dec.UseNumber()
+	var c listCursor
+	if err := dec.Decode(&c); err != nil || c.Sort != sort {
+		return nil, errInvalid
+	}
+
+	// Numbers come back as json.Number, while the database needs integers.
+	if n, ok := c.Value.(json.Number); ok {
+		v, err := strconv.Atoi(n.String())
+		if err != nil {
+			return nil, errInvalid
+		}
+		c.Value = v
+	}
+
+	return &c, nil
+}
+
+// listQuery holds what a list request asks for.
+type listQuery struct {
+	filter bson.M
+	sort   string // The field to sort by.
+	order  int    // 1 for ascending, -1 for descending.
+	limit  int
+	after  *listCursor
+}
+
+// intQuery returns the integer value of the provided query, or def if it is
+// missing.
+func intQuery(r *http.Request, query string, def int) (int, error) {
+	v := r.FormValue(query)
+	if v == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, listError{"Please provide a valid numeric value for " + query + "."}
+	}
+
+	return n, nil
+}
+
+// parseListQuery parses the queries of a list request. Creatures can be sorted
+// by name, level or hp, in asc or desc order, and filtered by a level range,
+// a class, a condition and a name prefix. The campaign and the kind come from
+// the route, or the campaign query.
+func parseListQuery(r *http.Request) (listQuery, error) {
+	q := listQuery{
+		filter: bson.M{
+			"kind":     kindFilter(creatureKind(r)),
+			"campaign": campaignFilter(campaignOf(r)),
+		},
+		order: 1,
+	}
+
+	sort := r.FormValue("sort")
+	if sort == "" {
+		sort = "name"
+	}
+	field, ok := sortFields[sort]
+	if !ok {
+		return q, listError{"Please sort by name, level or hp."}
+	}
+	q.sort = field
+
+	switch r.FormValue("order") {
+	case "", "asc":
+	case "desc":
+		q.order = -1
+	default:
+		return q, listError{"Please provide either asc or desc as the order."}
+	}
+
+	var err error
+	q.limit, err = intQuery(r, "limit", defaultPageSize)
+	if err != nil {
+		return q, err
+	}
+	if q.limit < 1 || q.limit > maximumPageSize {
+		return q, listError{"Please provide a limit from 1 to 100."}
+	}
+
+	levels := bson.M{}
+	for query, operator := range map[string]string{
+		"level_min": "$gte",
+		"level_max": "$lte",
+	} {
+		if r.FormValue(query) == "" {
+			continue
+		}
+		level, err := intQuery(r, query, 0)
+		if err != nil {
+			return q, err
+		}
+		levels[operator] = level
+	}
+	if len(levels) > 0 {
+		q.filter["level"] = levels
+	}
+
+	if class := strings.ToLower(r.FormValue("class")); class != "" {
+		if !classes.Valid(class) {
+			return q, listError{"Please provide a valid class."}
+		}
+		q.filter["classes."+class] = bson.M{"$exists": true}
+	}
+
+	if condition := strings.ToLower(r.FormValue("condition")); condition != "" {
+		if !conditions.Valid(condition) {
+			return q, listError{"Please provide a valid condition."}
+		}
+		q.filter["conditions.name"] = condition
+	}
+
+	if prefix := r.FormValue("prefix"); prefix != "" {
+		// Anchored and case sensitive, so that the name index serves it.
+		q.filter["name"] = bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}
+	}
+
+	if cursor := r.FormValue("cursor"); cursor != "" {
+		q.after, err = decodeCursor(cursor, q.sort)
+		if err != nil {
+			return q, err
+		}
+	}
+
+	return q, nil
+}
+
+// find returns the filter and the options that fetch the page the query asks
+// for, along with one more creature that tells whether there is a next page.
+func (q listQuery) find() (bson.M, *options.FindOptions, error) {
+	f := bson.M{}
+	for k, v := range q.filter {
+		f[k] = v
+	}
+
+	if q.after != nil {
+		id, err := primitive.ObjectIDFromHex(q.after.ID)
+		if err != nil {
+			return nil, nil, listError{"The cursor is invalid."}
+		}
+
+		operator := "$gt"
+		if q.order < 0 {
+			operator = "$lt"
+		}
+		f["$or"] = bson.A{
+			bson.M{q.sort: bson.M{operator: q.after.Value}},
+			bson.M{q.sort: q.after.Value, "_id": bson.M{operator: id}},
+		}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{
+			{Key: q.sort, Value: q.order},
+			{Key: "_id", Value: q.order},
+		}).
+		SetLimit(int64(q.limit + 1))
+
+	return f, opts, nil
+}
+
+// cursorAfter returns the cursor that marks the provided creature as the last
+// one of a page.
+func (q listQuery) cursorAfter(doc bson.Raw) listCursor {
+	c := listCursor{Sort: q.sort}
+	if id, ok := doc.Lookup("_id").ObjectIDOK(); ok {
+		c.ID = id.Hex()
+	}
+
+	v := doc.Lookup(q.sort)
+	if s, ok := v.StringValueOK(); ok {
+		c.Value = s
+	} else if n, ok := v.Int32OK(); ok {
+		c.Value = int(n)
+	} else if n, ok := v.Int64OK(); ok {
+		c.Value = int(n)
+	}
+
+	return c
+}
+
+// createListIndexes creates the indexes that serve the sorting and the
+// filtering of the creature lists.
+func createListIndexes(db *mongo.Database) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	var models []mongo.IndexModel
+	for _, field := range sortFields {
+		models = append(models, mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "campaign", Value: 1},
+				{Key: "kind", Value: 1},
+				{Key: field, Value: 1},
+				{Key: "_id", Value: 1},
+			},
+		})
+	}
+	models = append(models, mongo.IndexModel{
+		Keys: bson.M{"conditions.name": 1},
+	})
+
+	if _, err := db.Collection(players).Indexes().CreateMany(ctx, models); err != nil {
+		log.Println(err)
+	}
+}
+
+// nextPageLink returns the link to the page after the provided cursor, with
+// the rest of the queries of the request kept.
+func nextPageLink(r *http.Request, cursor string) string {
+	u := url.URL{Path: r.URL.Path}
+	query := r.URL.Query()
+	query.Set("cursor", cursor)
+	u.RawQuery = query.Encode()
+
+	return "<" + u.String() + `>; rel="next"`
+}
+
+// ListCreatures is the handler that returns a page of the creatures of the kind
+// and the campaign the request is about, sorted by name unless requested
+// otherwise. The link to the next page, if any, is in the Link header.
+func ListCreatures(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	q, err := parseListQuery(r)
+	var f bson.M
+	var opts *options.FindOptions
+	if err == nil {
+		f, opts, err = q.find()
+	}
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid query",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	playersCollection := playersDatabase.Collection(players)
+
+	cursor, err := playersCollection.Find(ctx, f, opts)
+	var docs []bson.Raw
+	if err == nil {
+		err = cursor.All(ctx, &docs)
+	}
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	result := make([]creature.Creature, 0, len(docs))
+	for i, doc := range docs {
+		if i == q.limit {
+			// There is a next page, which starts after the last creature of
+			// this one.
+			w.Header().Set("Link", nextPageLink(r, q.cursorAfter(docs[i-1]).encode()))
+			break
+		}
+
+		var c creature.Creature
+		if err := bson.Unmarshal(doc, &c); err != nil {
+			log.Println(err)
+			sendErrorResponse(w, enc, databaseError,
+				"An error was encountered while accessing the database.",
+				http.StatusInternalServerError,
+			)
+			return
+		}
+		result = append(result, c)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, visibleCreatures(r, result))
+}
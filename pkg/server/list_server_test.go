@@ -0,0 +1,91 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseListQuery(t *testing.T) {
+	kind := bson.M{"$in": bson.A{creature.Player, nil}}
+	after := listCursor{Sort: "level", Value: 3, ID: "5f0c1a2b3c4d5e6f7a8b9c0d"}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    listQuery
+		wantErr bool
+	}{
+		{
+			name:  "Defaults",
+			query: "",
+			want:  listQuery{bson.M{"kind": kind, "campaign": nil}, "name", 1, defaultPageSize, nil},
+		},
+		{
+			name:  "Sorted by hp, descending",
+			query: "?sort=hp&order=desc&limit=5",
+			want:  listQuery{bson.M{"kind": kind, "campaign": nil}, "hit_points", -1, 5, nil},
+		},
+		{
+			name:  "Filtered",
+			query: "?campaign=Phandelver&level_min=2&level_max=4&class=Wizard&condition=poisoned&prefix=M.",
+			want: listQuery{bson.M{
+				"kind":            kind,
+				"campaign":        "Phandelver",
+				"level":           bson.M{"$gte": 2, "$lte": 4},
+				"classes.wizard":  bson.M{"$exists": true},
+				"conditions.name": "poisoned",
+				"name":            bson.M{"$regex": `^M\.`},
+			}, "name", 1, defaultPageSize, nil},
+		},
+		{
+			name:  "Cursor",
+			query: "?sort=level&cursor=" + after.encode(),
+			want:  listQuery{bson.M{"kind": kind, "campaign": nil}, "level", 1, defaultPageSize, &after},
+		},
+		{name: "Cursor of another sort", query: "?sort=name&cursor=" + after.encode(), wantErr: true},
+		{name: "Invalid cursor", query: "?cursor=not-a-cursor", wantErr: true},
+		{name: "Invalid sort", query: "?sort=strength", wantErr: true},
+		{name: "Invalid order", query: "?order=up", wantErr: true},
+		{name: "Limit too large", query: "?limit=101", wantErr: true},
+		{name: "Invalid level", query: "?level_min=one", wantErr: true},
+		{name: "Invalid class", query: "?class=jester", wantErr: true},
+		{name: "Invalid condition", query: "?condition=sleepy", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/player"+tt.query, nil)
+			got, err := parseListQuery(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseListQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseListQuery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListQuery_cursorAfter(t *testing.T) {
+	doc, err := bson.Marshal(bson.M{"name": "Merry", "level": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sort := range []string{"name", "level"} {
+		q := listQuery{sort: sort}
+		c := q.cursorAfter(doc)
+
+		got, err := decodeCursor(c.encode(), sort)
+		if err != nil {
+			t.Fatalf("decodeCursor() error = %v", err)
+		}
+		if !reflect.DeepEqual(*got, c) {
+			t.Errorf("decodeCursor() = %+v, want %+v", *got, c)
+		}
+	}
+}
@@ -18,13 +18,14 @@ func Connect(db string) *mux.Router {
 	if v != nil {
 		log.Fatal(v)
 	}
-	client, err := mongo.NewClient(opts)
+	var err error
+	client, err = mongo.NewClient(opts)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// TODO: context.WithClose. Return the close function and pass it in Disconnect.
-	ctx, _ := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
 	err = client.Connect(ctx)
 	if err != nil {
@@ -32,17 +33,57 @@ func Connect(db string) *mux.Router {
 	}
 
 	playersDatabase = client.Database(database)
+	loadSpells(playersDatabase)
+	createCampaignIndexes(playersDatabase)
+	createAuthIndexes(playersDatabase)
+	createListIndexes(playersDatabase)
+	createHistoryIndexes(playersDatabase)
+	createEncounterIndexes(playersDatabase)
+	markManualArmorClasses(playersDatabase)
 
+	return newRouter()
+}
+
+// newRouter returns the router of every route of the server.
+func newRouter() *mux.Router {
+	// Every request is authenticated, if it carries a token, but only the
+	// dice and the documentation are available anonymously.
 	r := mux.NewRouter()
+	r.Use(authenticate)
+	r = authRoutes(r)
 	r = diceRoutes(r)
 	r = playerRoutes(r)
+	r = spellRoutes(r)
+	r = encounterRoutes(r)
+	r = campaignRoutes(r)
+	r = archiveRoutes(r)
+	r = docsRoutes(r)
 
 	return r
 }
 
+// inTransaction runs fn in a transaction, so that either every change it makes
+// to the database happens or none does. The changes take part in the
+// transaction only if they use the context fn is called with.
+func inTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
 // Disconnect disconnecs the client from the database.
 func Disconnect() {
-	err := client.Disconnect(*dbContext)
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	err := client.Disconnect(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,279 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/campaign"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/dice"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// monsterDetails models the descriptive statistics of a monster or an NPC.
+type monsterDetails struct {
+	Type             string            `json:"type" bson:"type"`
+	Alignment        string            `json:"alignment" bson:"alignment"`
+	Multiattack      string            `json:"multiattack" bson:"multiattack"`
+	LegendaryActions []creature.Action `json:"legendary_actions" bson:"legendary_actions"`
+}
+
+// spawnResponse models an instance spawned from a template.
+type spawnResponse struct {
+	Name      string `json:"name" bson:"name"`
+	HitPoints int    `json:"hit_points" bson:"hit_points"`
+}
+
+// maximumSpawn indicates the maximum number of instances spawned at once.
+const maximumSpawn = 100
+
+// visibleCreatures returns the provided creatures the way the user who made the
+// request sees them, with the monsters and NPCs of a campaign concealed from
+// its players.
+func visibleCreatures(r *http.Request, creatures []creature.Creature) []interface{} {
+	visible := make([]interface{}, 0, len(creatures))
+	for _, c := range creatures {
+		if campaign.Conceals(requestRole(r), c) {
+			visible = append(visible, campaign.Conceal(c))
+			continue
+		}
+		visible = append(visible, c)
+	}
+
+	return visible
+}
+
+// SetChallengeRating is the handler that sets the challenge rating of the
+// requested creature, along with the experience points it is worth.
+func SetChallengeRating(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	rating := vars["rating"]
+	if !creature.ValidChallengeRating(rating) {
+		sendErrorResponse(w, enc,
+			"invalid challenge rating",
+			"Please provide a challenge rating from 0 to 30, like 1/4 or 5.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	f := creatureFilter(r)
+	u := bson.M{
+		"$set": bson.M{
+			"challenge_rating": rating,
+			"experience_value": creature.ExperiencePerChallengeRating[rating],
+		}}
+
+	setNoUpsert(w, r, enc, f, u)
+}
+
+// SetDetails is the handler that sets the type, alignment, multiattack and
+// legendary actions of the requested creature. The details are provided as
+// JSON in the body of the request.
+func SetDetails(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	var details monsterDetails
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		sendErrorResponse(w, enc,
+			"invalid details",
+			"Please provide the details as a JSON object.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	details.Type = strings.ToLower(details.Type)
+
+	f := creatureFilter(r)
+	u := bson.M{
+		"$set": details,
+	}
+
+	setNoUpsert(w, r, enc, f, u)
+}
+
+// instanceFilter returns the filter that matches the instances of the provided
+// template in the campaign, along with any creature named like one.
+func instanceFilter(campaign, template string) bson.M {
+	return bson.M{
+		"campaign": campaignFilter(campaign),
+		"$or": bson.A{
+			bson.M{"template": template},
+			bson.M{"name": bson.M{
+				"$regex": "^" + regexp.QuoteMeta(template) + " [0-9]+$",
+			}},
+		},
+	}
+}
+
+// instanceHitPoints returns the hit points of a new instance of the template.
+// They are rolled from the hit points dice of the template when roll is set,
+// otherwise their average is taken.
+func instanceHitPoints(template *creature.Creature, roll bool) int {
+	expression, err := dice.Parse(template.HitPointsDice)
+	if err != nil {
+		return template.MaximumHitPoints
+	}
+
+	hitPoints := expression.Average()
+	if roll {
+		hitPoints, _ = expression.Roll()
+	}
+	if hitPoints < 1 {
+		hitPoints = 1
+	}
+
+	return hitPoints
+}
+
+// Spawn is the handler that creates the provided number of instances of the
+// requested creature, like "Goblin 1" to "Goblin 6". Each instance has hit
+// points of its own, rolled when the hp query is roll and average otherwise,
+// and its own state, so later changes to the template do not affect it.
+func Spawn(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	count, err := strconv.Atoi(vars["number"])
+	if err != nil || count < 1 || count > maximumSpawn {
+		countErrResponse(w)
+		return
+	}
+
+	template, err := getPlayer(w, r)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"Could not fetch the player.",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+	if template.Template != "" {
+		sendErrorResponse(w, enc,
+			"invalid template",
+			"Instances cannot be spawned from another instance.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	playersCollection := playersDatabase.Collection(players)
+
+	opts := options.Find().SetProjection(bson.M{"name": 1})
+	cursor, err := playersCollection.Find(ctx, instanceFilter(template.Campaign, template.Name), opts)
+	var existing []creature.Creature
+	if err == nil {
+		err = cursor.All(ctx, &existing)
+	}
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	names := make([]string, 0, len(existing))
+	for _, c := range existing {
+		names = append(names, c.Name)
+	}
+
+	roll := r.FormValue("hp") == "roll"
+	instances := make([]interface{}, 0, count)
+	response := make([]spawnResponse, 0, count)
+	for _, name := range creature.InstanceNames(template.Name, names, count) {
+		instance := template.Instance(name, instanceHitPoints(template, roll))
+		instances = append(instances, instance)
+		response = append(response, spawnResponse{name, instance.CurrentHitPoints})
+	}
+
+	if _, err := playersCollection.InsertMany(ctx, instances); err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error inserting the new entry in the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	jsonEncode(w, enc, response)
+}
+
+// GetInstances is the handler that returns the instances spawned from the
+// requested creature, sorted by name.
+func GetInstances(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	// The creature may be requested by its ID, while its instances refer to
+	// it by name.
+	template, err := getPlayer(w, r)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	playersCollection := playersDatabase.Collection(players)
+
+	opts := options.Find().SetSort(bson.M{"name": 1})
+	cursor, err := playersCollection.Find(ctx, bson.M{
+		"template": template.Name,
+		"kind":     kindFilter(creatureKind(r)),
+		"campaign": campaignFilter(campaignOf(r)),
+	}, opts)
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	result := []creature.Creature{}
+	if err := cursor.All(ctx, &result); err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, visibleCreatures(r, result))
+}
@@ -10,11 +10,15 @@ import (
 	"time"
 
 	"github.com/aakordas/creature_manager/pkg/abilities"
+	"github.com/aakordas/creature_manager/pkg/campaign"
+	"github.com/aakordas/creature_manager/pkg/classes"
 	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/history"
 	"github.com/aakordas/creature_manager/pkg/saves"
 	"github.com/aakordas/creature_manager/pkg/skills"
 	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -25,7 +29,6 @@ import (
 var (
 	client          *mongo.Client
 	playersDatabase *mongo.Database
-	dbContext       *context.Context
 
 	database = "creatures"
 	players  = "players"
@@ -38,32 +41,148 @@ const (
 	databaseError = "database error"
 	// playerNotFoundError    = "player not found"
 	invalidPlayerNameError = "invalid player name"
+
+	// creatureName matches the name, or the ID, of a creature in a path.
+	// Names can have any character apart from slashes, URL encoded.
+	creatureName = "[^/]+"
 )
 
 // // playerRoutes properly initializes the routes for the player part of
 // // the server.
 func playerRoutes(r *mux.Router) *mux.Router {
+	api := r.PathPrefix("/api/v1/").Subrouter()
+	api.Use(requireUser)
+
+	// Creatures either belong to a campaign or to none.
+	campaign := "/campaigns/{campaign:[a-zA-Z0-9 _-]+}"
+
+	api.HandleFunc("/monsters/import", ImportMonsters).Methods(http.MethodPost)
+	api.HandleFunc(campaign+"/monsters/import", ImportMonsters).Methods(http.MethodPost)
+
+	// Players, monsters and NPCs share the same routes and handlers, with
+	// their kind stored in the context of each request.
+	for prefix, kind := range map[string]string{
+		"/player":              creature.Player,
+		"/monsters":            creature.Monster,
+		"/npcs":                creature.NPC,
+		campaign + "/players":  creature.Player,
+		campaign + "/monsters": creature.Monster,
+		campaign + "/npcs":     creature.NPC,
+	} {
+		sub := api.PathPrefix(prefix).Subrouter()
+		sub.Use(kindMiddleware(kind), creatureAccess)
+		creatureRoutes(sub)
+	}
+
+	api.HandleFunc("/experience/{number:[0-9]+}", AwardPartyExperience).Methods(http.MethodPost)
+	api.HandleFunc(campaign+"/experience/{number:[0-9]+}", AwardPartyExperience).Methods(http.MethodPost)
+
+	return r
+}
+
+// creatureRoutes initializes the routes of a single kind of creature.
+func creatureRoutes(player *mux.Router) {
 	var (
-		name    = "{name:[a-zA-Z ]+}"
-		number  = "{number:[0-9]+}"
-		ability = "{ability:[a-zA-Z]+}"
-		skill   = "{skill:[a-zA-Z_]+}"
-		save    = "{save:[a-zA-Z]+}"
+		name        = "{name:" + creatureName + "}"
+		number      = "{number:[0-9]+}"
+		bonus       = "{number:-?[0-9]+}"
+		ability     = "{ability:[a-zA-Z]+}"
+		skill       = "{skill:[a-zA-Z_]+}"
+		save        = "{save:[a-zA-Z]+}"
+		class       = "{class:[a-zA-Z]+}"
+		resource    = "{resource:[a-zA-Z_]+}"
+		advancement = "{advancement:[a-zA-Z]+}"
+		spell       = "{spell:[a-zA-Z' -]+}"
+		item        = "{item:[a-zA-Z' -]+}"
+		target      = "{target:" + creatureName + "}"
+		coin        = "{coin:[a-z]{2}}"
+		to          = "{to:[a-z]{2}}"
+		weapon      = "{weapon:[a-zA-Z' -]+}"
+		damageType  = "{type:[a-zA-Z]+}"
+		condition   = "{condition:[a-zA-Z]+}"
+		rating      = "{rating:[0-9]+(?:/[0-9]+)?}"
+		tabletop    = "{tabletop:foundry|roll20}"
 	)
 
-	api := r.PathPrefix("/api/v1/").Subrouter()
-
 	// Player
-	player := api.PathPrefix("/player").Subrouter()
+	player.HandleFunc("", ListCreatures).Methods(http.MethodGet)
 	player.HandleFunc("/"+name, AddPlayer).Methods(http.MethodPut)
 	player.HandleFunc("/"+name, GetPlayer).Methods(http.MethodGet)
 	player.HandleFunc("/"+name, DeletePlayer).Methods(http.MethodDelete)
+	player.HandleFunc("/"+name+"/name/{rename:"+creatureName+"}", RenameCreature).Methods(http.MethodPut)
+	player.HandleFunc("/"+name+"/history", GetHistory).Methods(http.MethodGet)
+	player.HandleFunc("/"+name+"/undo/{number:[0-9]+}", Undo).Methods(http.MethodPost)
+	player.HandleFunc("/"+name+"/restore", RestoreCreature).Methods(http.MethodPost)
+	player.HandleFunc("/"+name+"/export", ExportCreature).Methods(http.MethodGet)
+	player.HandleFunc("/"+name+"/sheet", GetSheet).Methods(http.MethodGet)
+	player.HandleFunc("/"+name+"/"+tabletop, ExportTabletop).Methods(http.MethodGet)
+	player.HandleFunc("/"+name+"/"+tabletop, ImportTabletop).Methods(http.MethodPut)
 
 	// Cannot (?) create subrouters with variables, like `name'.
 	playerName := "/" + name + "/"
 	player.HandleFunc(playerName+"hitpoints/"+number, SetHitPoints).Methods(http.MethodPut)
 	player.HandleFunc(playerName+"level/"+number, SetLevel).Methods(http.MethodPut)
 	player.HandleFunc(playerName+"armor/"+number, SetArmorClass).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"armor", ClearArmorClass).Methods(http.MethodDelete)
+	player.HandleFunc(playerName+"armor/bonus/"+bonus, SetArmorBonus).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"armor/mage_armor", SetMageArmor).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"armor/mage_armor", RemoveMageArmor).Methods(http.MethodDelete)
+	player.HandleFunc(playerName+"maximum_hitpoints/"+number, SetMaximumHitPoints).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"exhaustion/"+number, SetExhaustion).Methods(http.MethodPut)
+
+	// Player's classes
+	player.HandleFunc(playerName+"classes/"+class+"/"+number, SetClass).Methods(http.MethodPut)
+
+	// Player's resources and resting
+	player.HandleFunc(playerName+"resources/"+resource+"/"+number, SetResource).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"rest/short", ShortRest).Methods(http.MethodPost)
+	player.HandleFunc(playerName+"rest/long", LongRest).Methods(http.MethodPost)
+
+	// Player's experience and advancement
+	player.HandleFunc(playerName+"experience/"+number, AwardExperience).Methods(http.MethodPost)
+	player.HandleFunc(playerName+"advancement/"+advancement, SetAdvancement).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"levelup", LevelUp).Methods(http.MethodPost)
+
+	// Player's spellcasting
+	player.HandleFunc(playerName+"spellcasting/"+ability, SetSpellcasting).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"spellcasting", GetSpellcasting).Methods(http.MethodGet)
+	player.HandleFunc(playerName+"spells/known/"+spell, AddKnownSpell).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"spells/known/"+spell, RemoveKnownSpell).Methods(http.MethodDelete)
+	player.HandleFunc(playerName+"spells/prepared/"+spell, PrepareSpell).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"spells/prepared/"+spell, UnprepareSpell).Methods(http.MethodDelete)
+	player.HandleFunc(playerName+"slots/"+number+"/expend", ExpendSlot).Methods(http.MethodPost)
+	player.HandleFunc(playerName+"cast/"+spell, CastSpell).Methods(http.MethodPost)
+	player.HandleFunc(playerName+"concentration", EndConcentration).Methods(http.MethodDelete)
+
+	// Player's inventory
+	player.HandleFunc(playerName+"inventory", GetInventory).Methods(http.MethodGet)
+	player.HandleFunc(playerName+"inventory/"+item, AddItem).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"inventory/"+item, RemoveItem).Methods(http.MethodDelete)
+	player.HandleFunc(playerName+"inventory/"+item+"/equip", EquipItem).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"inventory/"+item+"/equip", UnequipItem).Methods(http.MethodDelete)
+	player.HandleFunc(playerName+"inventory/"+item+"/attune", AttuneItem).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"inventory/"+item+"/attune", EndAttunement).Methods(http.MethodDelete)
+	player.HandleFunc(playerName+"inventory/"+item+"/transfer/"+target, TransferItem).Methods(http.MethodPost)
+	player.HandleFunc(playerName+"currency/"+coin+"/"+number, SetCurrency).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"currency/"+coin+"/"+to+"/"+number, ConvertCurrency).Methods(http.MethodPost)
+
+	// Player's damage and defenses
+	player.HandleFunc(playerName+"damage/"+number, Damage).Methods(http.MethodPost)
+	player.HandleFunc(playerName+"resistances/"+damageType, AddResistance).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"resistances/"+damageType, RemoveResistance).Methods(http.MethodDelete)
+	player.HandleFunc(playerName+"vulnerabilities/"+damageType, AddVulnerability).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"vulnerabilities/"+damageType, RemoveVulnerability).Methods(http.MethodDelete)
+	player.HandleFunc(playerName+"immunities/"+damageType, AddImmunity).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"immunities/"+damageType, RemoveImmunity).Methods(http.MethodDelete)
+	player.HandleFunc(playerName+"condition_immunities/"+condition, AddConditionImmunity).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"condition_immunities/"+condition, RemoveConditionImmunity).Methods(http.MethodDelete)
+	player.HandleFunc(playerName+"conditions/"+condition, AddCondition).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"conditions/"+condition, RemoveCondition).Methods(http.MethodDelete)
+
+	// Player's weapons and attacks
+	player.HandleFunc(playerName+"proficiencies/weapons/"+weapon, AddWeaponProficiency).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"proficiencies/weapons/"+weapon, RemoveWeaponProficiency).Methods(http.MethodDelete)
+	player.HandleFunc(playerName+"attack", Attack).Methods(http.MethodPost)
 
 	// Player's abilities
 	player.HandleFunc(playerName+"abilities/"+ability+"/"+number, SetAbility).Methods(http.MethodPut)
@@ -77,7 +196,11 @@ func playerRoutes(r *mux.Router) *mux.Router {
 	player.HandleFunc(playerName+"saving_throws/"+save, SetSave).Methods(http.MethodPut)
 	player.HandleFunc(playerName+"saving_throws", GetSaves).Methods(http.MethodGet)
 
-	return r
+	// Monster statistics
+	player.HandleFunc(playerName+"challenge_rating/"+rating, SetChallengeRating).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"details", SetDetails).Methods(http.MethodPut)
+	player.HandleFunc(playerName+"spawn/"+number, Spawn).Methods(http.MethodPost)
+	player.HandleFunc(playerName+"instances", GetInstances).Methods(http.MethodGet)
 }
 
 // sendErrorResponse creates and sends a custom error response.
@@ -88,15 +211,116 @@ func sendErrorResponse(w http.ResponseWriter, enc *json.Encoder, s, l string, h
 }
 
 // findPlayer returns the SingleResult of looking up the name of a single player
-// in the database.
-func findPlayer(ctx context.Context, col *mongo.Collection, name string) *mongo.SingleResult {
-	findResult := col.FindOne(ctx, bson.M{
-		"name": name,
-	})
+// of the provided campaign in the database.
+func findPlayer(ctx context.Context, col *mongo.Collection, campaign, name string) *mongo.SingleResult {
+	findResult := col.FindOne(ctx, nameFilter(campaign, name))
 
 	return findResult
 }
 
+// campaignOf returns the campaign the request is about, either from its path
+// or from its campaign query. Creatures outside of any campaign have an empty
+// one.
+func campaignOf(r *http.Request) string {
+	if campaign, ok := mux.Vars(r)["campaign"]; ok {
+		return campaign
+	}
+
+	return r.FormValue("campaign")
+}
+
+// campaignFilter returns the filter that matches the creatures of the provided
+// campaign.
+func campaignFilter(campaign string) interface{} {
+	if campaign == "" {
+		// Matches a missing campaign too.
+		return nil
+	}
+
+	return campaign
+}
+
+// nameFilter returns the filter that matches the creature with the provided
+// name, or ID, in the provided campaign.
+func nameFilter(campaign, name string) bson.M {
+	f := bson.M{
+		"campaign": campaignFilter(campaign),
+	}
+	if id, err := primitive.ObjectIDFromHex(name); err == nil {
+		f["_id"] = id
+	} else {
+		f["name"] = name
+	}
+
+	return f
+}
+
+// nameable checks whether the provided name can be given to a creature.
+// Names that look like IDs are rejected, since the routes could not tell them
+// apart.
+func nameable(name string) bool {
+	_, err := primitive.ObjectIDFromHex(name)
+	return creature.ValidName(name) && err != nil
+}
+
+// validCreatureName checks whether the provided name can be given to a
+// creature, responding with an error if it cannot.
+func validCreatureName(w http.ResponseWriter, enc *json.Encoder, name string) bool {
+	if !nameable(name) {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A name should have up to 64 characters, without slashes or surrounding spaces, and should not look like an ID.",
+			http.StatusBadRequest,
+		)
+		return false
+	}
+
+	return true
+}
+
+// kindKey is the key of the creature kind in the context of a request.
+type kindKey struct{}
+
+// kindMiddleware stores the provided creature kind in the context of each
+// request.
+func kindMiddleware(kind string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), kindKey{}, kind)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// creatureKind returns the kind of creature the request is about.
+func creatureKind(r *http.Request) string {
+	if kind, ok := r.Context().Value(kindKey{}).(string); ok {
+		return kind
+	}
+
+	return creature.Player
+}
+
+// kindFilter returns the filter that matches creatures of the provided kind.
+// Creatures stored before kinds existed are players.
+func kindFilter(kind string) interface{} {
+	if kind == creature.Player {
+		return bson.M{
+			"$in": bson.A{creature.Player, nil},
+		}
+	}
+
+	return kind
+}
+
+// creatureFilter returns the filter that matches the creature the request is
+// about.
+func creatureFilter(r *http.Request) bson.M {
+	f := nameFilter(campaignOf(r), mux.Vars(r)["name"])
+	f["kind"] = kindFilter(creatureKind(r))
+
+	return f
+}
+
 // AddPlayer is the handler that creates new players in the database.
 func AddPlayer(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -104,11 +328,7 @@ func AddPlayer(w http.ResponseWriter, r *http.Request) {
 
 	vars := mux.Vars(r)
 	playerName := vars["name"]
-	if playerName == "" {
-		sendErrorResponse(w, enc, invalidPlayerNameError,
-			"A player's name should contain only characters.",
-			http.StatusBadRequest,
-		)
+	if !validCreatureName(w, enc, playerName) {
 		return
 	}
 
@@ -118,7 +338,18 @@ func AddPlayer(w http.ResponseWriter, r *http.Request) {
 	playersCollection := playersDatabase.Collection(players)
 
 	// Ensure that the provided name does not already exist in the databae.
-	findResult := findPlayer(ctx, playersCollection, playerName)
+	campaign := campaignOf(r)
+	if campaign != "" && !campaignExists(ctx, campaign) {
+		w.WriteHeader(http.StatusNotFound)
+		sendErrorResponse(w, enc,
+			"campaign not found",
+			"No campaign found with name "+campaign,
+			http.StatusNotFound,
+		)
+		return
+	}
+
+	findResult := findPlayer(ctx, playersCollection, campaign, playerName)
 	// Can this even happen?
 	if findResult == nil {
 		log.Println("Error in FindOne")
@@ -140,12 +371,19 @@ func AddPlayer(w http.ResponseWriter, r *http.Request) {
 
 	// A brand new player is of first level, with the initial proficiency
 	// bonus of +2 and only has their name associated with them. Everything
-	// else will have to be added by subsequent requests.
-	entry, err := bson.Marshal(creature.Creature{
+	// else will have to be added by subsequent requests. The response
+	// carries its ID, which stays the same if the player gets renamed.
+	player := creature.Creature{
+		ID:               primitive.NewObjectID(),
 		Name:             playerName,
+		Kind:             creatureKind(r),
+		Campaign:         campaign,
+		Owner:            currentUser(r),
 		Level:            1,
 		ProficiencyBonus: creature.ProficiencyBonusPerLevel[1],
-	})
+	}
+	player.ArmorClass = calculateArmorClass(&player)
+	entry, err := bson.Marshal(player)
 	if err != nil {
 		log.Println(err)
 		sendErrorResponse(w, enc, serverError,
@@ -166,12 +404,121 @@ func AddPlayer(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.WriteHeader(http.StatusCreated)
+	jsonEncode(w, enc, player)
+}
+
+// RenameCreature is the handler that renames the requested creature, along with
+// its mentions in the parties and the encounters of its campaign and in the
+// instances spawned from it. Either everything gets renamed or nothing does.
+func RenameCreature(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	rename := mux.Vars(r)["rename"]
+	if !validCreatureName(w, enc, rename) {
+		return
+	}
+
+	c, err := getPlayer(w, r)
+	if err != nil {
+		return
+	}
+	if c.Name == rename {
+		w.WriteHeader(http.StatusOK)
+		jsonEncode(w, enc, c)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	playersCollection := playersDatabase.Collection(players)
+
+	if findPlayer(ctx, playersCollection, c.Campaign, rename).Err() != mongo.ErrNoDocuments {
+		sendErrorResponse(w, enc,
+			"player exists",
+			"A player with the provided name already exists in the database.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	err = inTransaction(ctx, func(ctx context.Context) error {
+		_, err := changeCreature(ctx, currentUser(r), history.Update, unchangedFilter(c), bson.M{
+			"$set": bson.M{"name": rename},
+		}, false)
+		if err != nil {
+			return err
+		}
+		return renameMentions(ctx, currentUser(r), c.Campaign, c.Name, rename)
+	})
+	if err == mongo.ErrNoDocuments || err == errChanged {
+		preconditionFailedResponse(w, enc)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error updating the entry in the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	c.Name = rename
+	c.Version++
+	w.Header().Set("ETag", etag(c.Version))
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, c)
+}
+
+// renameMentions renames the creature of the provided campaign in the parties
+// and the encounters of the campaign and in the instances spawned from it, on
+// behalf of the provided user. The instances record the change in their
+// history.
+func renameMentions(ctx context.Context, user, campaign, old, rename string) error {
+	playersCollection := playersDatabase.Collection(players)
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := playersCollection.Find(ctx, bson.M{"campaign": campaignFilter(campaign), "template": old}, opts)
+	if err != nil {
+		return err
+	}
+	var instances []creature.Creature
+	if err := cursor.All(ctx, &instances); err != nil {
+		return err
+	}
+	for _, instance := range instances {
+		_, err := changeCreature(ctx, user, history.Update, bson.M{"_id": instance.ID}, bson.M{
+			"$set": bson.M{"template": rename},
+		}, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	updates := []struct {
+		col    string
+		filter bson.M
+		update bson.M
+	}{
+		{partiesCollection, bson.M{"campaign": campaign, "members": old}, bson.M{"$set": bson.M{"members.$": rename}}},
+		{encountersCollection, bson.M{"campaign": campaignFilter(campaign), "combatants.name": old}, bson.M{"$set": bson.M{"combatants.$.name": rename}, "$inc": bson.M{"version": 1}}},
+	}
+	for _, u := range updates {
+		if _, err := playersDatabase.Collection(u.col).UpdateMany(ctx, u.filter, u.update); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // emptyResponse models a response with an empty object.
 type emptyResponse struct {
 }
 
+// getInfo responds with the part of the requested creature that v is of. The
+// players of a campaign see only the concealed monsters and NPCs of it.
 func getInfo(w http.ResponseWriter, r *http.Request, v interface{}) {
 	enc := json.NewEncoder(w)
 
@@ -180,6 +527,18 @@ func getInfo(w http.ResponseWriter, r *http.Request, v interface{}) {
 		return
 	}
 
+	_, whole := v.(creature.Creature)
+	if campaign.Conceals(requestRole(r), *player) {
+		if !whole {
+			revealed(w, r, player)
+			return
+		}
+
+		w.WriteHeader(http.StatusFound)
+		jsonEncode(w, enc, campaign.Conceal(*player))
+		return
+	}
+
 	w.WriteHeader(http.StatusFound)
 
 	var res interface{}
@@ -227,12 +586,16 @@ func DeletePlayer(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 	defer cancel()
 
-	playersCollection := playersDatabase.Collection("players")
+	f := conditionalFilter(r, creatureFilter(r))
+	_, conditional := f["version"]
 
-	_, err := playersCollection.DeleteOne(ctx, bson.M{
-		"name": playerName,
-	})
-	if err != nil {
+	err := deleteCreature(ctx, currentUser(r), f)
+	switch {
+	case err == errChanged, err == mongo.ErrNoDocuments && conditional:
+		preconditionFailedResponse(w, enc)
+		return
+	case err == mongo.ErrNoDocuments:
+	case err != nil:
 		log.Println(err)
 		sendErrorResponse(w, enc, databaseError,
 			"There was an error deleting the entry from the database",
@@ -273,7 +636,7 @@ func getPlayer(w http.ResponseWriter, r *http.Request) (*creature.Creature, erro
 
 	playersCollection := playersDatabase.Collection(players)
 
-	findResult := findPlayer(ctx, playersCollection, playerName)
+	findResult := playersCollection.FindOne(ctx, creatureFilter(r))
 	// Can this even happen?
 	if findResult == nil {
 		log.Println(findResult.Err())
@@ -293,6 +656,13 @@ func getPlayer(w http.ResponseWriter, r *http.Request) (*creature.Creature, erro
 		return nil, missingPlayerError{playerName}
 	}
 
+	// Changes are refused early if they are meant for another version.
+	if v, ok := ifMatch(r); ok && r.Method != http.MethodGet && v != player.Version {
+		preconditionFailedResponse(w, enc)
+		return nil, missingPlayerError{playerName}
+	}
+	w.Header().Set("ETag", etag(player.Version))
+
 	return &player, nil
 }
 
@@ -370,88 +740,77 @@ func SetAbility(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-	defer cancel()
-
-	playersCollection := playersDatabase.Collection(players)
-
 	player, err := getPlayer(w, r)
 	if err != nil {
-		sendErrorResponse(w, enc,
-			"Could not fetch the player.",
-			"There was an error retrieving the player from the database.",
-			http.StatusBadRequest,
-		)
 		return
 	}
 
 	modifier := abilities.AbilityScoresAndModifiers[value]
+	player.Abilities.Set(ability, value)
 
 	query := bson.M{
 		"abilities." + ability:               value,
 		"abilities." + ability + "_modifier": modifier,
+		"armor_class":                        calculateArmorClass(player),
 	}
 
 	if ability == abilities.Wisdom {
 		query["passive_perception"] = calculatePassivePerception(*player, value, player.Level)
 	}
 
-	// Changes the modifier of a skill, if it depends on this ability.
-	for skill := range player.Skills {
-		_, err = playersCollection.UpdateMany(ctx, bson.M{
-			"name":                          playerName,
-			"skills." + skill + ".modifier": ability,
-		}, bson.M{
-			"$set": bson.M{
-				"skills." + skill + ".value": modifier + player.ProficiencyBonus,
-			}})
-		if err != nil {
-			log.Println(err)
-			sendErrorResponse(w, enc, databaseError,
-				"There was an error updating the entry in the database.",
-				http.StatusInternalServerError,
-			)
-			return
+	// Changes the modifier of a skill, if it depends on this ability. Every
+	// change is part of the same update, made only if nobody changed the
+	// player since it was read.
+	for name, skill := range player.Skills {
+		if skill.Modifier == ability {
+			query["skills."+name+".value"] = modifier + player.ProficiencyBonus
 		}
 	}
 
-	_, err = playersCollection.UpdateOne(ctx, bson.M{
-		"name": playerName,
-	}, bson.M{
+	setNoUpsert(w, r, enc, unchangedFilter(player), bson.M{
 		"$set": query,
 	})
-	if err != nil {
-		log.Println(err)
-		sendErrorResponse(w, enc, databaseError,
-			"There was an error updating the entry in the database.",
-			http.StatusInternalServerError,
-		)
-		return
-	}
+}
 
-	w.WriteHeader(http.StatusOK)
+// setNoUpsert sets the provided attribute to the provided value. The returned
+// error has already been reported to the client.
+func setNoUpsert(w http.ResponseWriter, r *http.Request, enc *json.Encoder, filter, update bson.M) error {
+	return updateCreature(w, r, enc, filter, update, false)
 }
 
-// setNoUpsert sets the provided attribute to the provided value.
-func setNoUpsert(w http.ResponseWriter, r *http.Request, enc *json.Encoder, filter, update interface{}) {
+// updateCreature applies the provided update to the creature of the filter,
+// giving it a new version and recording the change in its history. If the
+// filter, or the request, expects a version of the creature and it has changed
+// since, nothing gets updated and the client receives a 412. The returned error
+// has already been reported to the client.
+func updateCreature(w http.ResponseWriter, r *http.Request, enc *json.Encoder, filter, update bson.M, upsert bool) error {
 	w.Header().Set("Content-Type", "application/json")
 
 	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 	defer cancel()
 
-	playersCollection := playersDatabase.Collection(players)
+	filter = conditionalFilter(r, filter)
+	_, conditional := filter["version"]
 
-	_, err := playersCollection.UpdateOne(ctx, filter, update)
-	if err != nil {
+	after, err := changeCreature(ctx, currentUser(r), history.Update, filter, update, upsert && !conditional)
+	switch {
+	case err == errChanged, err == mongo.ErrNoDocuments && conditional:
+		preconditionFailedResponse(w, enc)
+		return err
+	case err == mongo.ErrNoDocuments:
+	case err != nil:
 		log.Println(err)
 		sendErrorResponse(w, enc, databaseError,
 			"There was an error updating the entry in the database.",
 			http.StatusInternalServerError,
 		)
-		return
+		return err
+	default:
+		w.Header().Set("ETag", etag(versionOf(after)))
 	}
 
 	w.WriteHeader(http.StatusOK)
+	return nil
 }
 
 // SetHitPoints is the handler that sets the hitpoints of the requested creature
@@ -478,9 +837,7 @@ func SetHitPoints(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	f := bson.M{
-		"name": playerName,
-	}
+	f := creatureFilter(r)
 	u := bson.M{
 		"$set": bson.M{
 			"hit_points": value,
@@ -533,9 +890,7 @@ func SetLevel(w http.ResponseWriter, r *http.Request) {
 
 	proficiencyBonus := creature.ProficiencyBonusPerLevel[value]
 
-	f := bson.M{
-		"name": playerName,
-	}
+	f := unchangedFilter(player)
 	u := bson.M{
 		"$set": bson.M{
 			"level":             value,
@@ -551,7 +906,8 @@ func SetLevel(w http.ResponseWriter, r *http.Request) {
 }
 
 // SetArmorClass is the handler that sets the armor class of the requested
-// creature to the provided value.
+// creature to the provided value. The armor class stops being calculated from
+// the creature's armor, until ClearArmorClass is used.
 func SetArmorClass(w http.ResponseWriter, r *http.Request) {
 	enc := json.NewEncoder(w)
 
@@ -574,12 +930,242 @@ func SetArmorClass(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	f := bson.M{
-		"name": playerName,
+	f := creatureFilter(r)
+	u := bson.M{
+		"$set": bson.M{
+			"armor_class":    value,
+			"defense.manual": true,
+		}}
+
+	setNoUpsert(w, r, enc, f, u)
+}
+
+// SetMaximumHitPoints is the handler that sets the maximum hitpoints of the
+// requested creature to the provided value.
+func SetMaximumHitPoints(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	v := vars["number"]
+	value, err := strconv.Atoi(v)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid value",
+			"Please provide a valid numeric value.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	f := creatureFilter(r)
+	u := bson.M{
+		"$set": bson.M{
+			"maximum_hit_points": value,
+		}}
+
+	setNoUpsert(w, r, enc, f, u)
+}
+
+// SetExhaustion is the handler that sets the exhaustion level of the requested
+// creature to the provided value.
+func SetExhaustion(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	v := vars["number"]
+	value, err := strconv.Atoi(v)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid value",
+			"Please provide a valid numeric value.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	if creature.ExhaustionOutOfRange(value) {
+		sendErrorResponse(w, enc,
+			"exhaustion value out of range",
+			"Please provide a value within range.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	f := creatureFilter(r)
+	u := bson.M{
+		"$set": bson.M{
+			"exhaustion": value,
+		}}
+
+	setNoUpsert(w, r, enc, f, u)
+}
+
+// SetClass is the handler that sets the levels of the requested creature in
+// the provided class. The level of the creature becomes the sum of its class
+// levels and its hit dice of that class are refilled.
+func SetClass(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	class := strings.ToLower(vars["class"])
+	if !classes.Valid(class) {
+		sendErrorResponse(w, enc,
+			"invalid class name",
+			"Please provide a valid class name.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	v := vars["number"]
+	value, err := strconv.Atoi(v)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid value",
+			"Please provide a valid numeric value.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	player, err := getPlayer(w, r)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"Could not fetch the player.",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
 	}
+
+	levels := classes.Classes{}
+	level := value
+	for c, l := range player.Classes {
+		if c != class {
+			levels[c] = l
+			level += l
+		}
+	}
+	if value > 0 {
+		levels[class] = value
+	}
+	player.Classes = levels
+	if creature.OutOfRange(level) {
+		sendErrorResponse(w, enc,
+			"level value out of range",
+			"Plase provide a value within range.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	proficiencyBonus := creature.ProficiencyBonusPerLevel[level]
+
+	set := bson.M{
+		"level":             level,
+		"armor_class":       calculateArmorClass(player),
+		"proficiency_bonus": proficiencyBonus,
+		"passive_perception": calculatePassivePerception(
+			*player,
+			player.Abilities.Wisdom,
+			proficiencyBonus,
+		),
+	}
+
+	f := unchangedFilter(player)
+	var u bson.M
+	if value == 0 {
+		u = bson.M{
+			"$set": set,
+			"$unset": bson.M{
+				"classes." + class:  "",
+				"hit_dice." + class: "",
+			}}
+	} else {
+		set["classes."+class] = value
+		set["hit_dice."+class] = value
+		u = bson.M{
+			"$set": set,
+		}
+	}
+	if player.Spellcasting != nil {
+		player.Spellcasting.Recalculate(player.Classes)
+		set["spellcasting"] = player.Spellcasting
+	}
+
+	setNoUpsert(w, r, enc, f, u)
+}
+
+// SetResource is the handler that sets the maximum uses of a resource of the
+// requested creature and fully recharges it. The recharge query decides whether
+// it recharges after a short or only after a long rest.
+func SetResource(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	resource := strings.ToLower(vars["resource"])
+	v := vars["number"]
+	value, err := strconv.Atoi(v)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid value",
+			"Please provide a valid numeric value.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	recharge := r.FormValue("recharge")
+	if recharge == "" {
+		recharge = creature.LongRest
+	}
+	if recharge != creature.ShortRest && recharge != creature.LongRest {
+		sendErrorResponse(w, enc,
+			"invalid recharge",
+			"A resource recharges either after a short or a long rest.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	f := creatureFilter(r)
 	u := bson.M{
 		"$set": bson.M{
-			"armor_class": value,
+			"resources." + resource: creature.Resource{
+				Current:  value,
+				Maximum:  value,
+				Recharge: recharge,
+			},
 		}}
 
 	setNoUpsert(w, r, enc, f, u)
@@ -607,26 +1193,10 @@ func validSkill(s string) bool {
 	}
 }
 
-func setUpsert(w http.ResponseWriter, r *http.Request, enc *json.Encoder, filter, update interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-
-	playersCollection := playersDatabase.Collection(players)
-
-	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-	defer cancel()
-
-	opts := options.Update().SetUpsert(true)
-	_, err := playersCollection.UpdateOne(ctx, filter, update, opts)
-	if err != nil {
-		log.Println(err)
-		sendErrorResponse(w, enc, databaseError,
-			"There was an error updating the entry in the database.",
-			http.StatusInternalServerError,
-		)
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
+// setUpsert is like setNoUpsert, but creates the creature if it is missing and
+// no version of it is expected.
+func setUpsert(w http.ResponseWriter, r *http.Request, enc *json.Encoder, filter, update bson.M) {
+	updateCreature(w, r, enc, filter, update, true)
 }
 
 // SetSkill is the handler that sets the requested skill of a player to the
@@ -681,9 +1251,7 @@ func SetSkill(w http.ResponseWriter, r *http.Request) {
 		modifier = player.CharismaModifier
 	}
 
-	f := bson.M{
-		"name": playerName,
-	}
+	f := unchangedFilter(player)
 	u := bson.M{
 		"$set": bson.M{
 			"skills." + skill: bson.M{
@@ -746,9 +1314,7 @@ func SetSave(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	f := bson.M{
-		"name": playerName,
-	}
+	f := unchangedFilter(player)
 
 	var modifier int
 	switch save {
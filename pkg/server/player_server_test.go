@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestNameable tests that the names that cannot be told apart from IDs or
+// paths cannot be given to creatures.
+func TestNameable(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"Goblin", true},
+		{"Zoë Do'Urden", true},
+		{"", false},
+		{" Goblin", false},
+		{"Goblin/Boss", false},
+		{"5f0c1a2b3c4d5e6f7a8b9c0d", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nameable(tt.name); got != tt.want {
+				t.Errorf("nameable(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCreatureFilter tests that the routes of each kind of creature filter by
+// that kind and by the campaign of the route.
+func TestCreatureFilter(t *testing.T) {
+	id, _ := primitive.ObjectIDFromHex("5f0c1a2b3c4d5e6f7a8b9c0d")
+
+	tests := []struct {
+		name string
+		path string
+		want bson.M
+	}{
+		{"Player", "/api/v1/player/Merry", bson.M{"name": "Merry", "campaign": nil, "kind": bson.M{"$in": bson.A{creature.Player, nil}}}},
+		{"Monster", "/api/v1/monsters/Goblin", bson.M{"name": "Goblin", "campaign": nil, "kind": creature.Monster}},
+		{"NPC", "/api/v1/npcs/Barliman", bson.M{"name": "Barliman", "campaign": nil, "kind": creature.NPC}},
+		{"Campaign player", "/api/v1/campaigns/Fellowship/players/Merry", bson.M{"name": "Merry", "campaign": "Fellowship", "kind": bson.M{"$in": bson.A{creature.Player, nil}}}},
+		{"Campaign query", "/api/v1/monsters/Goblin?campaign=Phandelver", bson.M{"name": "Goblin", "campaign": "Phandelver", "kind": creature.Monster}},
+		{"Encoded name", "/api/v1/npcs/Zo%C3%AB%20Do'Urden", bson.M{"name": "Zoë Do'Urden", "campaign": nil, "kind": creature.NPC}},
+		{"ID", "/api/v1/monsters/5f0c1a2b3c4d5e6f7a8b9c0d", bson.M{"_id": id, "campaign": nil, "kind": creature.Monster}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bson.M
+			r := mux.NewRouter()
+			for prefix, kind := range map[string]string{
+				"/api/v1/player":                       creature.Player,
+				"/api/v1/monsters":                     creature.Monster,
+				"/api/v1/npcs":                         creature.NPC,
+				"/api/v1/campaigns/{campaign}/players": creature.Player,
+			} {
+				sub := r.PathPrefix(prefix).Subrouter()
+				sub.Use(kindMiddleware(kind))
+				sub.HandleFunc("/{name}", func(w http.ResponseWriter, r *http.Request) {
+					got = creatureFilter(r)
+				})
+			}
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("creatureFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
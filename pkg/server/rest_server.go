@@ -0,0 +1,182 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/aakordas/creature_manager/pkg/classes"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/rest"
+	"github.com/aakordas/creature_manager/pkg/spellcasting"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// restResponse models the state of a creature after it rested.
+type restResponse struct {
+	HitPointsRegained int                           `json:"hit_points_regained" bson:"hit_points_regained"`
+	HitPoints         int                           `json:"hit_points" bson:"hit_points"`
+	Rolls             []int                         `json:"rolls,omitempty" bson:"rolls,omitempty"` // The results of the spent hit dice.
+	HitDice           map[string]int                `json:"hit_dice,omitempty" bson:"hit_dice,omitempty"`
+	Resources         map[string]*creature.Resource `json:"resources,omitempty" bson:"resources,omitempty"`
+	Exhaustion        int                           `json:"exhaustion" bson:"exhaustion"`
+
+	Spellcasting *spellcasting.Spellcasting `json:"spellcasting,omitempty" bson:"spellcasting,omitempty"`
+}
+
+// rollHitDie rolls a hit die with the provided number of sides.
+func rollHitDie(sides int) int {
+	return chooseDice(sides)()
+}
+
+// saveRest stores the state of the player after resting and responds with it.
+func saveRest(w http.ResponseWriter, r *http.Request, enc *json.Encoder, player *creature.Creature, hitPoints int, rolls []int) {
+	f := unchangedFilter(player)
+	set := bson.M{
+		"hit_points": player.CurrentHitPoints,
+		"hit_dice":   player.HitDice,
+		"resources":  player.Resources,
+		"exhaustion": player.Exhaustion,
+	}
+	if player.Spellcasting != nil {
+		set["spellcasting"] = player.Spellcasting
+	}
+	u := bson.M{
+		"$set": set,
+	}
+
+	if err := setNoUpsert(w, r, enc, f, u); err != nil {
+		return
+	}
+
+	jsonEncode(w, enc, restResponse{
+		HitPointsRegained: player.CurrentHitPoints - hitPoints,
+		HitPoints:         player.CurrentHitPoints,
+		Rolls:             rolls,
+		HitDice:           player.HitDice,
+		Resources:         player.Resources,
+		Exhaustion:        player.Exhaustion,
+		Spellcasting:      player.Spellcasting,
+	})
+}
+
+// restError is the error that gets returned when the query of a rest is
+// invalid.
+type restError struct {
+	Message string
+}
+
+func (e restError) Error() string {
+	return e.Message
+}
+
+// restQueries are the queries of a short rest that are no classes.
+var restQueries = map[string]bool{
+	"campaign": true,
+}
+
+// hitDiceQuery returns the number of hit dice to spend per class, from the
+// queries of the request named after the classes.
+func hitDiceQuery(r *http.Request) (map[string]int, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, restError{"Please provide the hit dice to spend per class."}
+	}
+
+	spend := make(map[string]int)
+	for class := range r.Form {
+		if restQueries[class] {
+			continue
+		}
+		if !classes.Valid(class) {
+			return nil, restError{"Please provide a valid class name."}
+		}
+		n, err := strconv.Atoi(r.Form.Get(class))
+		if err != nil || n < 0 {
+			return nil, restError{"Please provide a valid number of hit dice."}
+		}
+		spend[class] = n
+	}
+
+	return spend, nil
+}
+
+// ShortRest is the handler that makes the requested creature take a short
+// rest. The number of hit dice to spend is provided per class as a query, e.g.
+// ?fighter=2&wizard=1.
+func ShortRest(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	spend, err := hitDiceQuery(r)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid hit dice",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	player, err := getPlayer(w, r)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"Could not fetch the player.",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	hitPoints := player.CurrentHitPoints
+	rolls, err := rest.ShortRest(player, spend, rollHitDie)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"not enough hit dice",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	saveRest(w, r, enc, player, hitPoints, rolls)
+}
+
+// LongRest is the handler that makes the requested creature take a long rest.
+func LongRest(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	player, err := getPlayer(w, r)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"Could not fetch the player.",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	hitPoints := player.CurrentHitPoints
+	rest.LongRest(player)
+
+	saveRest(w, r, enc, player, hitPoints, nil)
+}
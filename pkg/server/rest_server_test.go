@@ -0,0 +1,35 @@
+package server
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestHitDiceQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    map[string]int
+		wantErr bool
+	}{
+		{"None", "", map[string]int{}, false},
+		{"Classes", "?fighter=2&wizard=1", map[string]int{"fighter": 2, "wizard": 1}, false},
+		{"Campaign", "?campaign=x&fighter=1", map[string]int{"fighter": 1}, false},
+		{"Invalid class", "?jester=1", nil, true},
+		{"Invalid number", "?fighter=two", nil, true},
+		{"Negative number", "?fighter=-1", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/v1/player/Merry/rest/short"+tt.query, nil)
+			got, err := hitDiceQuery(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("hitDiceQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("hitDiceQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aakordas/creature_manager/pkg/campaign"
+	"github.com/aakordas/creature_manager/pkg/rolls"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var rollsCollection = "rolls"
+
+// maximumHistory indicates the maximum number of rolls returned from the history
+// of a campaign.
+const maximumHistory = 100
+
+// rollFeed passes the rolls of each campaign to the users who follow its live
+// feed. It only reaches the users connected to this server.
+type rollFeed struct {
+	sync.Mutex
+	subscribers map[string]map[chan rolls.Roll]struct{}
+}
+
+var feed = rollFeed{
+	subscribers: map[string]map[chan rolls.Roll]struct{}{},
+}
+
+// subscribe returns a channel that receives the rolls of the provided campaign.
+func (f *rollFeed) subscribe(name string) chan rolls.Roll {
+	f.Lock()
+	defer f.Unlock()
+
+	ch := make(chan rolls.Roll, 16)
+	if f.subscribers[name] == nil {
+		f.subscribers[name] = map[chan rolls.Roll]struct{}{}
+	}
+	f.subscribers[name][ch] = struct{}{}
+
+	return ch
+}
+
+// unsubscribe stops passing the rolls of the provided campaign to ch.
+func (f *rollFeed) unsubscribe(name string, ch chan rolls.Roll) {
+	f.Lock()
+	defer f.Unlock()
+
+	delete(f.subscribers[name], ch)
+	if len(f.subscribers[name]) == 0 {
+		delete(f.subscribers, name)
+	}
+}
+
+// publish passes the roll to everyone who follows its campaign. Subscribers
+// that fall behind miss the roll, instead of holding everyone else back.
+func (f *rollFeed) publish(roll rolls.Roll) {
+	f.Lock()
+	defer f.Unlock()
+
+	for ch := range f.subscribers[roll.Campaign] {
+		select {
+		case ch <- roll:
+		default:
+		}
+	}
+}
+
+// visibilityErrResponse writes an error response about an invalid visibility
+// passed to w.
+func visibilityErrResponse(w http.ResponseWriter, message string) {
+	errResponse := errorResponse{
+		"invalid visibility",
+		message,
+	}
+	w.WriteHeader(http.StatusNotAcceptable)
+	enc := json.NewEncoder(w)
+	jsonEncode(w, enc, errResponse)
+}
+
+// recordRoll stores a roll in the history of the campaign in the campaign query
+// of the request and passes it to its live feed. The visibility query controls
+// who sees the roll, public by default. It returns the roll the way the roller
+// sees it.
+func recordRoll(w http.ResponseWriter, r *http.Request, sides, count, result int) (rolls.Roll, bool) {
+	visibility := r.FormValue("visibility")
+	if visibility == "" {
+		visibility = rolls.Public
+	}
+	if !rolls.ValidVisibility(visibility) {
+		visibilityErrResponse(w, "Please provide public, gm, blind or self as the visibility.")
+		return rolls.Roll{}, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	user := currentUser(r)
+	name := r.FormValue("campaign")
+	role := campaignRole(ctx, user, name)
+	if role != campaign.DungeonMaster && role != campaign.Player {
+		forbiddenResponse(w, "Only the dungeon master and the players of the campaign can roll in it.")
+		return rolls.Roll{}, false
+	}
+
+	roll := rolls.Roll{
+		ID:         primitive.NewObjectID().Hex(),
+		Campaign:   name,
+		User:       user,
+		Count:      count,
+		Sides:      sides,
+		Result:     result,
+		Visibility: visibility,
+		Time:       time.Now(),
+	}
+
+	col := playersDatabase.Collection(rollsCollection)
+	if _, err := col.InsertOne(ctx, roll); err != nil {
+		log.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		sendErrorResponse(w, json.NewEncoder(w), databaseError,
+			"There was an error inserting the new entry in the database",
+			http.StatusInternalServerError,
+		)
+		return rolls.Roll{}, false
+	}
+	feed.publish(roll)
+
+	view, _ := roll.View(user, role == campaign.DungeonMaster)
+	return view, true
+}
+
+// visibleRolls returns the filter that matches the rolls of the requested
+// campaign that the user who made the request sees.
+func visibleRolls(r *http.Request) bson.M {
+	f := bson.M{
+		"campaign": mux.Vars(r)["campaign"],
+	}
+	if requestRole(r) != campaign.DungeonMaster {
+		f["$or"] = bson.A{
+			bson.M{"visibility": rolls.Public},
+			bson.M{"revealed": true},
+			bson.M{"user": currentUser(r)},
+		}
+	}
+
+	return f
+}
+
+// GetRolls is the handler that returns the latest rolls of a campaign that the
+// user who made the request sees, newest first.
+func GetRolls(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(rollsCollection)
+
+	opts := options.Find().SetSort(bson.M{"time": -1}).SetLimit(maximumHistory)
+	cursor, err := col.Find(ctx, visibleRolls(r), opts)
+	history := []rolls.Roll{}
+	if err == nil {
+		err = cursor.All(ctx, &history)
+	}
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	user := currentUser(r)
+	dm := requestRole(r) == campaign.DungeonMaster
+	result := make([]rolls.Roll, 0, len(history))
+	for _, roll := range history {
+		if view, ok := roll.View(user, dm); ok {
+			result = append(result, view)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, result)
+}
+
+// RollFeed is the handler that streams the rolls of a campaign, as server-sent
+// events, while they are made and revealed. Each user receives only the rolls
+// they see. The stream ends with the write timeout of the server, after which
+// clients like EventSource reconnect on their own.
+func RollFeed(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		sendErrorResponse(w, json.NewEncoder(w), serverError,
+			"The server cannot stream the rolls.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	name := mux.Vars(r)["campaign"]
+	user := currentUser(r)
+	dm := requestRole(r) == campaign.DungeonMaster
+
+	ch := feed.subscribe(name)
+	defer feed.unsubscribe(name, ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case roll := <-ch:
+			view, ok := roll.View(user, dm)
+			if !ok {
+				continue
+			}
+			data, err := json.Marshal(view)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+
+			fmt.Fprintf(w, "event: roll\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
+// RevealRoll is the handler that makes a hidden roll of a campaign public, both
+// in its history and in its live feed.
+func RevealRoll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	f := bson.M{
+		"_id":      vars["roll"],
+		"campaign": vars["campaign"],
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(rollsCollection)
+
+	var roll rolls.Roll
+	if err := col.FindOne(ctx, f).Decode(&roll); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		sendErrorResponse(w, enc,
+			"roll not found",
+			"No roll found with id "+vars["roll"],
+			http.StatusNotFound,
+		)
+		return
+	}
+
+	if err := roll.Reveal(currentUser(r), requestRole(r) == campaign.DungeonMaster); err != nil {
+		sendErrorResponse(w, enc,
+			"invalid reveal",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	_, err := col.UpdateOne(ctx, f, bson.M{
+		"$set": bson.M{
+			"revealed": true,
+		}})
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error updating the entry in the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+	feed.publish(roll)
+
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, roll)
+}
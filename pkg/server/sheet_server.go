@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/sheet"
+)
+
+// sheetTypes are the media types a character sheet is served as, in the order
+// they are preferred when the request accepts several of them equally.
+var sheetTypes = []string{
+	"text/markdown",
+	"text/html",
+	"text/plain",
+	"application/json",
+}
+
+// negotiate returns the offered media type the provided Accept header prefers,
+// or an empty string if it accepts none of them. Each offer takes the quality
+// of the most specific range that matches it, and the first offer wins among
+// equals. A missing header accepts anything.
+func negotiate(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, specificity := 0.0, -1
+		for _, r := range strings.Split(accept, ",") {
+			mediaRange, rq := parseMediaRange(r)
+			if s := matches(mediaRange, offer); s > specificity {
+				q, specificity = rq, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+
+	return best
+}
+
+// parseMediaRange returns the media range of an element of an Accept header
+// and its quality.
+func parseMediaRange(r string) (string, float64) {
+	params := strings.Split(r, ";")
+
+	q := 1.0
+	for _, p := range params[1:] {
+		p = strings.TrimSpace(p)
+		if strings.HasPrefix(p, "q=") {
+			v, err := strconv.ParseFloat(p[2:], 64)
+			if err != nil {
+				v = 0
+			}
+			q = v
+		}
+	}
+
+	return strings.ToLower(strings.TrimSpace(params[0])), q
+}
+
+// matches returns how specific the provided media range is, if it matches the
+// provided media type: 2 for the type itself, 1 for its subtypes and 0 for
+// anything. It returns -1 if it does not match.
+func matches(mediaRange, mediaType string) int {
+	switch {
+	case mediaRange == mediaType:
+		return 2
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mediaType, mediaRange[:len(mediaRange)-1]):
+		return 1
+	default:
+		return -1
+	}
+}
+
+// GetSheet is the handler that returns the character sheet of the specified
+// creature, as Markdown, HTML, plain text or JSON, as the Accept header of the
+// request prefers.
+func GetSheet(w http.ResponseWriter, r *http.Request) {
+	c, err := getPlayer(w, r)
+	if err != nil || !revealed(w, r, c) {
+		return
+	}
+
+	enc := json.NewEncoder(w)
+	mediaType := negotiate(r.Header.Get("Accept"), sheetTypes)
+	if mediaType == "" {
+		w.WriteHeader(http.StatusNotAcceptable)
+		sendErrorResponse(w, enc,
+			"not acceptable",
+			"The sheet can be served as "+strings.Join(sheetTypes, ", ")+".",
+			http.StatusNotAcceptable,
+		)
+		return
+	}
+
+	s := sheet.New(*c)
+	if mediaType == "application/json" {
+		w.WriteHeader(http.StatusOK)
+		jsonEncode(w, enc, s)
+		return
+	}
+
+	var body string
+	if mediaType == "text/html" {
+		body, err = sheet.HTML(s)
+	} else {
+		body, err = sheet.Markdown(s)
+	}
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(body))
+}
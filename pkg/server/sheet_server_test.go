@@ -0,0 +1,28 @@
+package server
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{"Missing", "", "text/markdown"},
+		{"Anything", "*/*", "text/markdown"},
+		{"HTML", "text/html", "text/html"},
+		{"Browser", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "text/html"},
+		{"Preferred JSON", "text/html;q=0.5, application/json", "application/json"},
+		{"Text without Markdown", "text/*, text/markdown;q=0", "text/html"},
+		{"Case", "Text/Plain", "text/plain"},
+		{"Not acceptable", "image/png", ""},
+		{"Refused", "application/json;q=0", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := negotiate(tt.accept, sheetTypes); got != tt.want {
+				t.Errorf("negotiate(%q) = %q, want %q", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,363 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/abilities"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/saves"
+	"github.com/aakordas/creature_manager/pkg/spellcasting"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const notASpellcasterError = "not a spellcaster"
+
+// spellcastingResponse models the spellcasting information of a creature,
+// along with the values derived from its casting ability.
+type spellcastingResponse struct {
+	*spellcasting.Spellcasting `bson:",inline"`
+
+	SaveDC      int `json:"save_dc" bson:"save_dc"`
+	AttackBonus int `json:"attack_bonus" bson:"attack_bonus"`
+}
+
+// castResponse models the result of casting a spell.
+type castResponse struct {
+	Spell                string               `json:"spell" bson:"spell"`
+	Level                int                  `json:"level" bson:"level"`
+	Slots                []spellcasting.Slots `json:"slots,omitempty" bson:"slots,omitempty"`
+	PactSlots            *spellcasting.Slots  `json:"pact_slots,omitempty" bson:"pact_slots,omitempty"`
+	Concentration        string               `json:"concentration,omitempty" bson:"concentration,omitempty"`
+	DroppedConcentration string               `json:"dropped_concentration,omitempty" bson:"dropped_concentration,omitempty"` // The spell the creature stopped concentrating on.
+}
+
+// concentrationCheck prompts for the Constitution saving throw a creature has
+// to make to keep concentrating on a spell.
+type concentrationCheck struct {
+	Spell     string `json:"spell" bson:"spell"`
+	DC        int    `json:"dc" bson:"dc"`
+	SaveBonus int    `json:"save_bonus" bson:"save_bonus"`
+}
+
+// getSpellcaster returns the requested creature, making sure it can cast
+// spells.
+func getSpellcaster(w http.ResponseWriter, r *http.Request, enc *json.Encoder) (*creature.Creature, bool) {
+	player, err := getPlayer(w, r)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"Could not fetch the player.",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return nil, false
+	}
+	if player.Spellcasting == nil {
+		sendErrorResponse(w, enc, notASpellcasterError,
+			"The creature has no spellcasting ability set.",
+			http.StatusBadRequest,
+		)
+		return nil, false
+	}
+
+	return player, true
+}
+
+// SetSpellcasting is the handler that sets the spellcasting ability of the
+// requested creature, making it a spellcaster with spell slots derived from its
+// class levels.
+func SetSpellcasting(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	ability := strings.ToLower(vars["ability"])
+	if !validAbility(ability) {
+		sendErrorResponse(w, enc,
+			"invalid ability name",
+			"Please provide a valid ability name.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	player, err := getPlayer(w, r)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"Could not fetch the player.",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	s := player.Spellcasting
+	if s == nil {
+		s = &spellcasting.Spellcasting{}
+	}
+	s.Ability = ability
+	s.Recalculate(player.Classes)
+
+	f := unchangedFilter(player)
+	u := bson.M{
+		"$set": bson.M{
+			"spellcasting": s,
+		}}
+
+	setNoUpsert(w, r, enc, f, u)
+}
+
+// GetSpellcasting is the handler that returns the spellcasting information of
+// a player in the database.
+func GetSpellcasting(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	player, ok := getSpellcaster(w, r, enc)
+	if !ok || !revealed(w, r, player) {
+		return
+	}
+
+	modifier := player.Abilities.Modifier(player.Spellcasting.Ability)
+
+	w.WriteHeader(http.StatusFound)
+	jsonEncode(w, enc, spellcastingResponse{
+		Spellcasting: player.Spellcasting,
+		SaveDC:       spellcasting.SaveDC(player.ProficiencyBonus, modifier),
+		AttackBonus:  spellcasting.AttackBonus(player.ProficiencyBonus, modifier),
+	})
+}
+
+// setSpellList adds the requested spell to, or removes it from, one of the
+// spell lists of a creature.
+func setSpellList(w http.ResponseWriter, r *http.Request, list string, add bool) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	spell := strings.TrimSpace(vars["spell"])
+	if spell == "" {
+		sendErrorResponse(w, enc,
+			"invalid spell name",
+			"Please provide a valid spell name.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	if _, ok := getSpellcaster(w, r, enc); !ok {
+		return
+	}
+
+	operator := "$addToSet"
+	if !add {
+		operator = "$pull"
+	}
+
+	f := creatureFilter(r)
+	u := bson.M{
+		operator: bson.M{
+			"spellcasting." + list: spell,
+		}}
+
+	setNoUpsert(w, r, enc, f, u)
+}
+
+// AddKnownSpell is the handler that adds a spell to the known spells of the
+// requested creature.
+func AddKnownSpell(w http.ResponseWriter, r *http.Request) {
+	setSpellList(w, r, "known", true)
+}
+
+// RemoveKnownSpell is the handler that removes a spell from the known spells of
+// the requested creature.
+func RemoveKnownSpell(w http.ResponseWriter, r *http.Request) {
+	setSpellList(w, r, "known", false)
+}
+
+// PrepareSpell is the handler that adds a spell to the prepared spells of the
+// requested creature.
+func PrepareSpell(w http.ResponseWriter, r *http.Request) {
+	setSpellList(w, r, "prepared", true)
+}
+
+// UnprepareSpell is the handler that removes a spell from the prepared spells
+// of the requested creature.
+func UnprepareSpell(w http.ResponseWriter, r *http.Request) {
+	setSpellList(w, r, "prepared", false)
+}
+
+// expend uses a spell slot of the provided level and, if the spell requires
+// concentration, makes the creature concentrate on it. A spell, if provided,
+// has to be one the creature knows or has prepared. It responds with the
+// remaining slots.
+func expend(w http.ResponseWriter, r *http.Request, enc *json.Encoder, spell string, level int, concentration bool) {
+	player, ok := getSpellcaster(w, r, enc)
+	if !ok {
+		return
+	}
+	s := player.Spellcasting
+
+	if spell != "" && !s.CanCast(spell) {
+		sendErrorResponse(w, enc,
+			"cannot cast spell",
+			"The creature neither knows nor has prepared "+spell+".",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	// Cantrips do not need a spell slot.
+	if level > 0 {
+		if err := s.Expend(level); err != nil {
+			sendErrorResponse(w, enc,
+				"cannot expend slot",
+				err.Error(),
+				http.StatusBadRequest,
+			)
+			return
+		}
+	}
+
+	response := castResponse{
+		Spell: spell,
+		Level: level,
+	}
+	if concentration {
+		if s.Concentration != "" {
+			response.DroppedConcentration = s.Concentration
+		}
+		s.Concentration = spell
+	}
+	response.Slots = s.Slots
+	response.PactSlots = s.PactSlots
+	response.Concentration = s.Concentration
+
+	f := unchangedFilter(player)
+	u := bson.M{
+		"$set": bson.M{
+			"spellcasting": s,
+		}}
+
+	if err := setNoUpsert(w, r, enc, f, u); err != nil {
+		return
+	}
+
+	jsonEncode(w, enc, response)
+}
+
+// ExpendSlot is the handler that uses a spell slot of the requested level of a
+// creature.
+func ExpendSlot(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	level, err := strconv.Atoi(vars["number"])
+	if err != nil || level == 0 || spellcasting.OutOfRange(level) {
+		sendErrorResponse(w, enc,
+			"invalid spell level",
+			"Please provide a spell level within range.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	expend(w, r, enc, "", level, false)
+}
+
+// CastSpell is the handler that casts the requested spell, which the creature
+// has to know or have prepared. The level query decides the slot that gets
+// used, while concentration=true makes the creature concentrate on the spell,
+// dropping any previous one.
+func CastSpell(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	spell := strings.TrimSpace(vars["spell"])
+	if spell == "" {
+		sendErrorResponse(w, enc,
+			"invalid spell name",
+			"Please provide a valid spell name.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	level, err := strconv.Atoi(r.FormValue("level"))
+	if err != nil || spellcasting.OutOfRange(level) {
+		sendErrorResponse(w, enc,
+			"invalid spell level",
+			"Please provide a spell level within range.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	concentration := r.FormValue("concentration") == "true"
+
+	expend(w, r, enc, spell, level, concentration)
+}
+
+// EndConcentration is the handler that makes the requested creature stop
+// concentrating on its spell.
+func EndConcentration(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	playerName := vars["name"]
+	if playerName == "" {
+		sendErrorResponse(w, enc, invalidPlayerNameError,
+			"A player's name should contain only characters.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	f := creatureFilter(r)
+	u := bson.M{
+		"$unset": bson.M{
+			"spellcasting.concentration": "",
+		}}
+
+	setNoUpsert(w, r, enc, f, u)
+}
+
+// constitutionSave returns the bonus of the creature to Constitution saving
+// throws.
+func constitutionSave(c *creature.Creature) int {
+	if v, ok := c.SavingThrows[saves.Constitution]; ok {
+		return v
+	}
+
+	return c.Abilities.Modifier(abilities.Constitution)
+}
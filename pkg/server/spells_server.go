@@ -0,0 +1,299 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/spells"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var spellsCollection = "spells"
+
+// spellRoutes properly initializes the routes for the spell catalogue part of
+// the server.
+func spellRoutes(r *mux.Router) *mux.Router {
+	var (
+		spell = "{spell:[a-zA-Z' -]+}"
+	)
+
+	api := r.PathPrefix("/api/v1/").Subrouter()
+	api.Use(requireUser)
+
+	api.HandleFunc("/spells", GetSpells).Methods(http.MethodGet)
+	api.HandleFunc("/spells/"+spell, GetSpell).Methods(http.MethodGet)
+	api.HandleFunc("/spells/"+spell, AddSpell).Methods(http.MethodPut)
+	api.HandleFunc("/spells/"+spell, DeleteSpell).Methods(http.MethodDelete)
+
+	return r
+}
+
+// loadSpells stores the SRD spells in the database, so that they can be
+// searched along with any homebrew ones.
+func loadSpells(db *mongo.Database) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*contextTimeout)
+	defer cancel()
+
+	col := db.Collection(spellsCollection)
+
+	_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"name": 1},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	opts := options.Replace().SetUpsert(true)
+	for _, s := range spells.SRD {
+		_, err := col.ReplaceOne(ctx, bson.M{"name": s.Name}, s, opts)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+	}
+}
+
+// spellFilter creates the database filter from the queries of the request.
+// Spells can be filtered by class, level, school and some text contained in
+// their name or description.
+func spellFilter(r *http.Request) (bson.M, error) {
+	filter := bson.M{}
+
+	if class := r.FormValue("class"); class != "" {
+		filter["classes"] = strings.ToLower(class)
+	}
+	if school := r.FormValue("school"); school != "" {
+		filter["school"] = strings.ToLower(school)
+	}
+	if level := r.FormValue("level"); level != "" {
+		l, err := strconv.Atoi(level)
+		if err != nil {
+			return nil, err
+		}
+		filter["level"] = l
+	}
+	if q := r.FormValue("q"); q != "" {
+		pattern := bson.M{
+			"$regex":   regexp.QuoteMeta(q),
+			"$options": "i",
+		}
+		filter["$or"] = bson.A{
+			bson.M{"name": pattern},
+			bson.M{"description": pattern},
+		}
+	}
+
+	return filter, nil
+}
+
+// GetSpells is the handler that searches the spell catalogue.
+func GetSpells(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	filter, err := spellFilter(r)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid spell level",
+			"Please provide a valid numeric value for the level.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(spellsCollection)
+
+	opts := options.Find().SetSort(bson.D{
+		{Key: "level", Value: 1},
+		{Key: "name", Value: 1},
+	})
+	cursor, err := col.Find(ctx, filter, opts)
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	result := []spells.Spell{}
+	if err := cursor.All(ctx, &result); err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"An error was encountered while accessing the database.",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	jsonEncode(w, enc, result)
+}
+
+// GetSpell is the handler that returns a single spell of the catalogue.
+func GetSpell(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	name := vars["spell"]
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(spellsCollection)
+
+	var spell spells.Spell
+	err := col.FindOne(ctx, bson.M{"name": name}).Decode(&spell)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		jsonEncode(w, enc, emptyResponse{})
+		return
+	}
+
+	w.WriteHeader(http.StatusFound)
+	jsonEncode(w, enc, spell)
+}
+
+// mayChangeSpell checks whether the user may replace or delete the provided
+// homebrew spell. That is left to the user who made it and, for the spells
+// made for a campaign, to its dungeon master. Spells without a maker, like the
+// ones stored before the accounts, can only be read.
+func mayChangeSpell(ctx context.Context, user string, s *spells.Spell) bool {
+	if s.Owner != "" && s.Owner == user {
+		return true
+	}
+
+	return s.Campaign != "" && isDungeonMaster(ctx, user, s.Campaign)
+}
+
+// AddSpell is the handler that adds a homebrew spell to the catalogue, or
+// replaces an existing homebrew spell. The spell is provided as JSON in the
+// body of the request. SRD spells cannot be replaced, and homebrew ones only by
+// the user who made them or the dungeon master of their campaign.
+func AddSpell(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	user := currentUser(r)
+
+	var spell spells.Spell
+	if err := json.NewDecoder(r.Body).Decode(&spell); err != nil {
+		sendErrorResponse(w, enc,
+			"invalid spell",
+			"Please provide the spell as a JSON object.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	spell.Name = vars["spell"]
+	spell.School = strings.ToLower(spell.School)
+	for i, c := range spell.Classes {
+		spell.Classes[i] = strings.ToLower(c)
+	}
+	spell.Homebrew = true
+	spell.Campaign = r.FormValue("campaign")
+	spell.Owner = user
+	if err := spell.Validate(); err != nil {
+		sendErrorResponse(w, enc,
+			"invalid spell",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	if spell.Campaign != "" && campaignRole(ctx, user, spell.Campaign) == "" {
+		forbiddenResponse(w, "Only the users of the campaign may add spells to it.")
+		return
+	}
+
+	col := playersDatabase.Collection(spellsCollection)
+
+	var existing spells.Spell
+	err := col.FindOne(ctx, bson.M{"name": spell.Name}).Decode(&existing)
+	if err == nil && !existing.Homebrew {
+		sendErrorResponse(w, enc,
+			"spell exists",
+			"An SRD spell with the provided name already exists.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+	if err == nil {
+		if !mayChangeSpell(ctx, user, &existing) {
+			forbiddenResponse(w, "Only the user who made the spell may replace it.")
+			return
+		}
+		spell.Campaign = existing.Campaign
+		spell.Owner = existing.Owner
+	}
+
+	opts := options.Replace().SetUpsert(true)
+	_, err = col.ReplaceOne(ctx, bson.M{"name": spell.Name}, spell, opts)
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error inserting the new entry in the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// DeleteSpell is the handler that removes a homebrew spell from the catalogue.
+// Only the user who made the spell, or the dungeon master of its campaign, may
+// remove it.
+func DeleteSpell(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+
+	vars := mux.Vars(r)
+	filter := bson.M{
+		"name":     vars["spell"],
+		"homebrew": true,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	col := playersDatabase.Collection(spellsCollection)
+
+	var existing spells.Spell
+	err := col.FindOne(ctx, filter).Decode(&existing)
+	if err == nil && !mayChangeSpell(ctx, currentUser(r), &existing) {
+		forbiddenResponse(w, "Only the user who made the spell may delete it.")
+		return
+	}
+
+	_, err = col.DeleteOne(ctx, filter)
+	if err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error deleting the entry from the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// etag returns the entity tag of the provided version of a creature.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatch returns the version of the creature the request expects, from its
+// If-Match header, and whether it expects any. A header that is not the tag of
+// a single version expects one that never matches, apart from "*".
+func ifMatch(r *http.Request) (int, bool) {
+	h := strings.TrimSpace(r.Header.Get("If-Match"))
+	if h == "" || h == "*" {
+		return 0, false
+	}
+
+	if len(h) < 2 || h[0] != '"' || h[len(h)-1] != '"' {
+		return -1, true
+	}
+	v, err := strconv.Atoi(h[1 : len(h)-1])
+	if err != nil || v < 0 {
+		return -1, true
+	}
+
+	return v, true
+}
+
+// versionFilter returns the filter that matches creatures of the provided
+// version. Creatures stored before versions existed are of version 0.
+func versionFilter(version int) interface{} {
+	if version == 0 {
+		return bson.M{
+			"$in": bson.A{0, nil},
+		}
+	}
+
+	return version
+}
+
+// conditionalFilter adds the version the request expects, if any, to the
+// provided filter, unless the filter already expects one.
+func conditionalFilter(r *http.Request, f bson.M) bson.M {
+	if _, ok := f["version"]; ok {
+		return f
+	}
+	if v, ok := ifMatch(r); ok {
+		f["version"] = versionFilter(v)
+	}
+
+	return f
+}
+
+// unchangedFilter returns the filter that matches the provided creature, as
+// long as it has not changed since it was read.
+func unchangedFilter(c *creature.Creature) bson.M {
+	return bson.M{
+		"_id":     c.ID,
+		"version": versionFilter(c.Version),
+	}
+}
+
+// versioned adds the increment of the version of the creature to the provided
+// update, so that every change gives the creature a new version.
+func versioned(update bson.M) bson.M {
+	u := bson.M{}
+	for k, v := range update {
+		u[k] = v
+	}
+
+	inc := bson.M{"version": 1}
+	if i, ok := update["$inc"].(bson.M); ok {
+		for k, v := range i {
+			inc[k] = v
+		}
+	}
+	u["$inc"] = inc
+
+	return u
+}
+
+// preconditionFailedResponse writes the response to a change that was meant
+// for another version of the creature.
+func preconditionFailedResponse(w http.ResponseWriter, enc *json.Encoder) {
+	w.WriteHeader(http.StatusPreconditionFailed)
+	sendErrorResponse(w, enc,
+		"precondition failed",
+		"The creature was changed in the meantime. Please fetch it again and retry.",
+		http.StatusPreconditionFailed,
+	)
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   int
+		wantOK bool
+	}{
+		{"Missing", "", 0, false},
+		{"Any", "*", 0, false},
+		{"Version", `"3"`, 3, true},
+		{"Version zero", ` "0" `, 0, true},
+		{"Weak tag", `W/"3"`, -1, true},
+		{"Unquoted", "3", -1, true},
+		{"Many tags", `"3", "4"`, -1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/api/v1/player/Merry/level/2", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+
+			got, ok := ifMatch(r)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ifMatch() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestVersioned(t *testing.T) {
+	tests := []struct {
+		name   string
+		update bson.M
+		want   bson.M
+	}{
+		{
+			name:   "Set",
+			update: bson.M{"$set": bson.M{"level": 2}},
+			want:   bson.M{"$set": bson.M{"level": 2}, "$inc": bson.M{"version": 1}},
+		},
+		{
+			name:   "Increment",
+			update: bson.M{"$inc": bson.M{"hit_points": -3}},
+			want:   bson.M{"$inc": bson.M{"hit_points": -3, "version": 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := versioned(tt.update); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("versioned() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
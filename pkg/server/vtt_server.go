@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/vtt"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// tabletops maps the virtual tabletops to the functions that convert their
+// characters from and to creatures.
+var tabletops = map[string]struct {
+	read  func([]byte) (creature.Creature, error)
+	write func(creature.Creature) ([]byte, error)
+}{
+	"foundry": {vtt.FoundryCreature, vtt.FoundryActor},
+	"roll20":  {vtt.Roll20Creature, vtt.Roll20Character},
+}
+
+// ExportTabletop is the handler that returns the specified creature as a
+// character of the virtual tabletop in the path, ready to be imported there.
+func ExportTabletop(w http.ResponseWriter, r *http.Request) {
+	c, err := getPlayer(w, r)
+	if err != nil || !revealed(w, r, c) {
+		return
+	}
+
+	data, err := tabletops[mux.Vars(r)["tabletop"]].write(*c)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="`+c.ID.Hex()+`.json"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// ImportTabletop is the handler that creates the specified creature from the
+// character of the virtual tabletop in the path, which is the body of the
+// request. The creature takes the name of the path and the kind of the route,
+// whatever the character says.
+func ImportTabletop(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	name := mux.Vars(r)["name"]
+	if !validCreatureName(w, enc, name) {
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid character",
+			"The body of the request could not be read.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	c, err := tabletops[mux.Vars(r)["tabletop"]].read(data)
+	if err != nil {
+		sendErrorResponse(w, enc,
+			"invalid character",
+			err.Error(),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	campaign := campaignOf(r)
+	if campaign != "" && !campaignExists(ctx, campaign) {
+		w.WriteHeader(http.StatusNotFound)
+		sendErrorResponse(w, enc,
+			"campaign not found",
+			"No campaign found with name "+campaign,
+			http.StatusNotFound,
+		)
+		return
+	}
+
+	playersCollection := playersDatabase.Collection(players)
+	if err := findPlayer(ctx, playersCollection, campaign, name).Err(); err != mongo.ErrNoDocuments {
+		sendErrorResponse(w, enc,
+			"player exists",
+			"A player with the provided name already exists in the database.",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	c.ID = primitive.NewObjectID()
+	c.Name = name
+	c.Kind = creatureKind(r)
+	c.Campaign = campaign
+	c.Owner = currentUser(r)
+	if _, err := playersCollection.InsertOne(ctx, c); err != nil {
+		log.Println(err)
+		sendErrorResponse(w, enc, databaseError,
+			"There was an error inserting the new entry in the database",
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	jsonEncode(w, enc, c)
+}
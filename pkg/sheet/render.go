@@ -0,0 +1,150 @@
+package sheet
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+)
+
+// markdownEscaper escapes the characters that Markdown would otherwise take
+// for formatting, along with the pipes of the tables.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+	">", `\>`,
+	"#", `\#`,
+	"|", `\|`,
+	"\n", " ",
+)
+
+// functions are the functions both templates use.
+var functions = map[string]interface{}{
+	"md":   markdownEscaper.Replace,
+	"join": strings.Join,
+}
+
+var markdownTemplate = template.Must(template.New("markdown").Funcs(functions).Parse(
+	`# {{md .Name}}
+{{if .Classes}}
+**{{md .Classes}}** (level {{.Level}})
+{{else if .Level}}
+**Level {{.Level}}**
+{{end}}
+| Hit Points | Armor Class | Proficiency Bonus | Passive Perception |{{if .Speed}} Speed |{{end}}
+|:---:|:---:|:---:|:---:|{{if .Speed}}:---:|{{end}}
+| {{.HitPoints}}/{{.MaximumHitPoints}} | {{.ArmorClass}} | {{.ProficiencyBonus}} | {{.PassivePerception}} |{{if .Speed}} {{.Speed}} |{{end}}
+{{if .Conditions}}
+**Conditions:** {{md (join .Conditions ", ")}}
+{{end}}
+## Abilities
+
+| Ability | Score | Modifier | Saving Throw |
+|:---|:---:|:---:|:---:|
+{{range $i, $a := .Abilities}}{{with index $.Saves $i}}| {{$a.Name}} | {{$a.Score}} | {{$a.Modifier}} | {{if .Proficient}}**{{.Bonus}}**{{else}}{{.Bonus}}{{end}} |
+{{end}}{{end}}
+## Skills
+
+| Skill | Bonus |
+|:---|:---:|
+{{range .Skills}}| {{.Name}} | {{if .Proficient}}**{{.Bonus}}**{{else}}{{.Bonus}}{{end}} |
+{{end}}{{range .Features}}
+## {{.Title}}
+{{range .Features}}
+- **{{md .Name}}.** {{md .Description}}{{end}}
+{{end}}{{if or .Items .Currency}}
+## Inventory
+{{range .Items}}
+- {{md .Name}}{{if ne .Quantity 1}} ×{{.Quantity}}{{end}}{{if .Notes}} ({{.Notes}}){{end}}{{end}}{{if .Currency}}
+- **Coins:** {{.Currency}}{{end}}
+{{end}}{{with .Spellcasting}}
+## Spellcasting
+
+**Ability:** {{.Ability}}, **Save DC:** {{.SaveDC}}, **Attack Bonus:** {{.AttackBonus}}
+{{if .Slots}}
+**Slots:** {{join .Slots ", "}}
+{{end}}{{if .Known}}
+**Known:** {{md (join .Known ", ")}}
+{{end}}{{if .Prepared}}
+**Prepared:** {{md (join .Prepared ", ")}}
+{{end}}{{if .Concentration}}
+**Concentrating on:** {{md .Concentration}}
+{{end}}{{end}}`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(functions).Parse(
+	`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>
+<style>
+body { font-family: Georgia, serif; max-width: 50em; margin: 2em auto; color: #222; }
+h1 { margin-bottom: 0; }
+h2 { border-bottom: 2px solid #922610; color: #922610; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.75em; text-align: center; }
+th:first-child, td:first-child { text-align: left; }
+.proficient { font-weight: bold; }
+.stats td { min-width: 6em; }
+@media print { body { margin: 0; } }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+{{if .Classes}}<p><strong>{{.Classes}}</strong> (level {{.Level}})</p>
+{{else if .Level}}<p><strong>Level {{.Level}}</strong></p>
+{{end}}<table class="stats">
+<tr><th>Hit Points</th><th>Armor Class</th><th>Proficiency Bonus</th><th>Passive Perception</th>{{if .Speed}}<th>Speed</th>{{end}}</tr>
+<tr><td>{{.HitPoints}}/{{.MaximumHitPoints}}</td><td>{{.ArmorClass}}</td><td>{{.ProficiencyBonus}}</td><td>{{.PassivePerception}}</td>{{if .Speed}}<td>{{.Speed}}</td>{{end}}</tr>
+</table>
+{{if .Conditions}}<p><strong>Conditions:</strong> {{join .Conditions ", "}}</p>
+{{end}}<h2>Abilities</h2>
+<table>
+<tr><th>Ability</th><th>Score</th><th>Modifier</th><th>Saving Throw</th></tr>
+{{range $i, $a := .Abilities}}{{with index $.Saves $i}}<tr><td>{{$a.Name}}</td><td>{{$a.Score}}</td><td>{{$a.Modifier}}</td><td{{if .Proficient}} class="proficient"{{end}}>{{.Bonus}}</td></tr>
+{{end}}{{end}}</table>
+<h2>Skills</h2>
+<table>
+<tr><th>Skill</th><th>Bonus</th></tr>
+{{range .Skills}}<tr{{if .Proficient}} class="proficient"{{end}}><td>{{.Name}}</td><td>{{.Bonus}}</td></tr>
+{{end}}</table>
+{{range .Features}}<h2>{{.Title}}</h2>
+<ul>
+{{range .Features}}<li><strong>{{.Name}}.</strong> {{.Description}}</li>
+{{end}}</ul>
+{{end}}{{if or .Items .Currency}}<h2>Inventory</h2>
+<ul>
+{{range .Items}}<li>{{.Name}}{{if ne .Quantity 1}} ×{{.Quantity}}{{end}}{{if .Notes}} ({{.Notes}}){{end}}</li>
+{{end}}{{if .Currency}}<li><strong>Coins:</strong> {{.Currency}}</li>
+{{end}}</ul>
+{{end}}{{with .Spellcasting}}<h2>Spellcasting</h2>
+<p><strong>Ability:</strong> {{.Ability}}, <strong>Save DC:</strong> {{.SaveDC}}, <strong>Attack Bonus:</strong> {{.AttackBonus}}</p>
+{{if .Slots}}<p><strong>Slots:</strong> {{join .Slots ", "}}</p>
+{{end}}{{if .Known}}<p><strong>Known:</strong> {{join .Known ", "}}</p>
+{{end}}{{if .Prepared}}<p><strong>Prepared:</strong> {{join .Prepared ", "}}</p>
+{{end}}{{if .Concentration}}<p><strong>Concentrating on:</strong> {{.Concentration}}</p>
+{{end}}{{end}}</body>
+</html>
+`))
+
+// Markdown returns the sheet as Markdown, which reads well both as plain text
+// and once rendered, like in a chat.
+func Markdown(s Sheet) (string, error) {
+	var b bytes.Buffer
+	err := markdownTemplate.Execute(&b, s)
+
+	return b.String(), err
+}
+
+// HTML returns the sheet as a self-contained HTML page, styled for printing.
+func HTML(s Sheet) (string, error) {
+	var b bytes.Buffer
+	err := htmlTemplate.Execute(&b, s)
+
+	return b.String(), err
+}
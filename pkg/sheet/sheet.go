@@ -0,0 +1,389 @@
+package sheet
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/abilities"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/inventory"
+	"github.com/aakordas/creature_manager/pkg/skills"
+	"github.com/aakordas/creature_manager/pkg/spellcasting"
+)
+
+// Sheet holds what a character sheet shows of a creature, ready to be
+// rendered.
+type Sheet struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Campaign string `json:"campaign,omitempty"`
+	Classes  string `json:"classes,omitempty"` // Like "Fighter 3, Wizard 2".
+	Level    int    `json:"level"`
+
+	HitPoints         int    `json:"hit_points"`
+	MaximumHitPoints  int    `json:"maximum_hit_points"`
+	ArmorClass        int    `json:"armor_class"`
+	ProficiencyBonus  string `json:"proficiency_bonus"`
+	PassivePerception int    `json:"passive_perception"`
+	Speed             string `json:"speed,omitempty"` // Like "30 ft., fly 60 ft.".
+
+	Abilities  []Ability `json:"abilities"`
+	Saves      []Bonus   `json:"saving_throws"`
+	Skills     []Bonus   `json:"skills"`
+	Conditions []string  `json:"conditions,omitempty"`
+
+	Features []Section `json:"features,omitempty"`
+
+	Items    []Item `json:"items,omitempty"`
+	Currency string `json:"currency,omitempty"` // Like "15 gp, 3 sp".
+
+	Spellcasting *Spellcasting `json:"spellcasting,omitempty"`
+}
+
+// Ability holds an ability score and its modifier.
+type Ability struct {
+	Name     string `json:"name"`
+	Score    int    `json:"score"`
+	Modifier string `json:"modifier"`
+}
+
+// Bonus holds the bonus of a saving throw or a skill, and whether the creature
+// is proficient in it.
+type Bonus struct {
+	Name       string `json:"name"`
+	Bonus      string `json:"bonus"`
+	Proficient bool   `json:"proficient"`
+}
+
+// Section is a titled group of features, like the actions of a creature.
+type Section struct {
+	Title    string    `json:"title"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a single entry of a section.
+type Feature struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Item is a single entry of the inventory.
+type Item struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+	Notes    string `json:"notes,omitempty"` // Like "equipped, attuned".
+}
+
+// Spellcasting holds the spellcasting of a caster.
+type Spellcasting struct {
+	Ability       string   `json:"ability"`
+	SaveDC        int      `json:"save_dc"`
+	AttackBonus   string   `json:"attack_bonus"`
+	Slots         []string `json:"slots,omitempty"` // Like "1st: 3/4", the remaining out of the maximum.
+	Known         []string `json:"known,omitempty"`
+	Prepared      []string `json:"prepared,omitempty"`
+	Concentration string   `json:"concentration,omitempty"`
+}
+
+// abilityNames are the abilities in the order a sheet shows them.
+var abilityNames = []string{
+	abilities.Strength,
+	abilities.Dexterity,
+	abilities.Constitution,
+	abilities.Intelligence,
+	abilities.Wisdom,
+	abilities.Charisma,
+}
+
+// skillNames are the skills in the order a sheet shows them.
+var skillNames = []string{
+	skills.Acrobatics,
+	skills.AnimalHandling,
+	skills.Arcana,
+	skills.Athletics,
+	skills.Deception,
+	skills.History,
+	skills.Insight,
+	skills.Intimidation,
+	skills.Investigation,
+	skills.Medicine,
+	skills.Nature,
+	skills.Perception,
+	skills.Performance,
+	skills.Persuasion,
+	skills.Religion,
+	skills.SleightOfHand,
+	skills.Stealth,
+	skills.Survival,
+}
+
+// New returns the sheet of the provided creature.
+func New(c creature.Creature) Sheet {
+	s := Sheet{
+		Name:              c.Name,
+		Kind:              c.Kind,
+		Campaign:          c.Campaign,
+		Classes:           classList(c),
+		Level:             c.Level,
+		HitPoints:         c.CurrentHitPoints,
+		MaximumHitPoints:  c.MaximumHitPoints,
+		ArmorClass:        c.ArmorClass,
+		ProficiencyBonus:  Signed(c.ProficiencyBonus),
+		PassivePerception: c.PassivePerception,
+		Speed:             speed(c.Speed),
+		Features:          features(c),
+		Currency:          currency(c.Currency),
+	}
+
+	for _, a := range abilityNames {
+		modifier := c.Abilities.Modifier(a)
+
+		s.Abilities = append(s.Abilities, Ability{Label(a), c.Abilities.Score(a), Signed(modifier)})
+
+		bonus, proficient := c.SavingThrows[a]
+		if !proficient {
+			bonus = modifier
+		}
+		s.Saves = append(s.Saves, Bonus{Label(a), Signed(bonus), proficient})
+	}
+
+	for _, name := range skillNames {
+		bonus := c.Abilities.Modifier(skills.SkillToAbility[name])
+		sk, proficient := c.Skills[name]
+		if proficient && sk != nil {
+			bonus = sk.Value
+		}
+		s.Skills = append(s.Skills, Bonus{Label(name), Signed(bonus), proficient})
+	}
+
+	for _, condition := range c.Conditions {
+		name := Label(condition.Name)
+		if condition.Duration > 0 {
+			name += " (" + plural(condition.Duration, "turn") + ")"
+		}
+		s.Conditions = append(s.Conditions, name)
+	}
+
+	for _, item := range c.Items {
+		s.Items = append(s.Items, Item{item.Name, item.Quantity, itemNotes(item)})
+	}
+
+	if c.Spellcasting != nil {
+		s.Spellcasting = spells(c)
+	}
+
+	return s
+}
+
+// Signed returns the provided bonus with its sign, like +2 or -1.
+func Signed(n int) string {
+	if n < 0 {
+		return strconv.Itoa(n)
+	}
+
+	return "+" + strconv.Itoa(n)
+}
+
+// Label returns the provided name, like sleight_of_hand, the way a sheet shows
+// it, like Sleight of Hand.
+func Label(name string) string {
+	words := strings.Fields(strings.ReplaceAll(name, "_", " "))
+	for i, w := range words {
+		if i > 0 && (w == "of" || w == "and" || w == "the") {
+			continue
+		}
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+
+	return strings.Join(words, " ")
+}
+
+// Ordinal returns the provided number as an ordinal, like 1st or 2nd.
+func Ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+
+	return strconv.Itoa(n) + suffix
+}
+
+// plural returns the provided count of the provided noun.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+
+	return strconv.Itoa(n) + " " + noun + "s"
+}
+
+// classList returns the classes of the provided creature with their levels,
+// sorted by name.
+func classList(c creature.Creature) string {
+	names := make([]string, 0, len(c.Classes))
+	for name := range c.Classes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		names[i] = Label(name) + " " + strconv.Itoa(c.Classes[name])
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// speed returns the provided speeds, with walking first and the rest sorted
+// by name.
+func speed(speeds map[string]int) string {
+	modes := make([]string, 0, len(speeds))
+	for mode := range speeds {
+		if mode != "walk" {
+			modes = append(modes, mode)
+		}
+	}
+	sort.Strings(modes)
+
+	var s []string
+	if walk, ok := speeds["walk"]; ok {
+		s = append(s, strconv.Itoa(walk)+" ft.")
+	}
+	for _, mode := range modes {
+		s = append(s, mode+" "+strconv.Itoa(speeds[mode])+" ft.")
+	}
+
+	return strings.Join(s, ", ")
+}
+
+// features returns the traits, actions and resources of the provided creature,
+// leaving out the empty sections.
+func features(c creature.Creature) []Section {
+	var sections []Section
+	add := func(title string, fs []Feature) {
+		if len(fs) > 0 {
+			sections = append(sections, Section{title, fs})
+		}
+	}
+
+	add("Traits", actions(c.Traits))
+
+	as := actions(c.Actions)
+	if c.Multiattack != "" {
+		as = append([]Feature{{"Multiattack", c.Multiattack}}, as...)
+	}
+	add("Actions", as)
+	add("Reactions", actions(c.Reactions))
+	add("Legendary Actions", actions(c.LegendaryActions))
+
+	names := make([]string, 0, len(c.Resources))
+	for name := range c.Resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var resources []Feature
+	for _, name := range names {
+		r := c.Resources[name]
+		if r == nil {
+			continue
+		}
+		description := strconv.Itoa(r.Current) + "/" + strconv.Itoa(r.Maximum)
+		switch r.Recharge {
+		case creature.ShortRest:
+			description += ", recharges after a short or long rest"
+		case creature.LongRest:
+			description += ", recharges after a long rest"
+		}
+		resources = append(resources, Feature{Label(name), description})
+	}
+	add("Resources", resources)
+
+	return sections
+}
+
+// actions returns the provided actions as features.
+func actions(as []creature.Action) []Feature {
+	var fs []Feature
+	for _, a := range as {
+		name := a.Name
+		if a.Cost > 1 {
+			name += " (costs " + strconv.Itoa(a.Cost) + " actions)"
+		}
+		fs = append(fs, Feature{name, a.Description})
+	}
+
+	return fs
+}
+
+// itemNotes returns whether the provided item is equipped or attuned.
+func itemNotes(item inventory.Item) string {
+	var notes []string
+	if item.Equipped {
+		notes = append(notes, "equipped")
+	}
+	if item.Attuned {
+		notes = append(notes, "attuned")
+	} else if item.RequiresAttunement {
+		notes = append(notes, "requires attunement")
+	}
+
+	return strings.Join(notes, ", ")
+}
+
+// currency returns the coins of the provided currency, from the most valuable
+// one, leaving out the ones there are none of.
+func currency(c inventory.Currency) string {
+	var coins []string
+	for _, coin := range []struct {
+		n    int
+		name string
+	}{
+		{c.Platinum, inventory.Platinum},
+		{c.Gold, inventory.Gold},
+		{c.Electrum, inventory.Electrum},
+		{c.Silver, inventory.Silver},
+		{c.Copper, inventory.Copper},
+	} {
+		if coin.n != 0 {
+			coins = append(coins, strconv.Itoa(coin.n)+" "+coin.name)
+		}
+	}
+
+	return strings.Join(coins, ", ")
+}
+
+// spells returns the spellcasting of the provided caster.
+func spells(c creature.Creature) *Spellcasting {
+	sc := c.Spellcasting
+	modifier := c.Abilities.Modifier(sc.Ability)
+
+	s := &Spellcasting{
+		Ability:       Label(sc.Ability),
+		SaveDC:        spellcasting.SaveDC(c.ProficiencyBonus, modifier),
+		AttackBonus:   Signed(spellcasting.AttackBonus(c.ProficiencyBonus, modifier)),
+		Known:         sc.Known,
+		Prepared:      sc.Prepared,
+		Concentration: sc.Concentration,
+	}
+
+	slots := func(prefix string, sl spellcasting.Slots) string {
+		return prefix + Ordinal(sl.Level) + ": " + strconv.Itoa(sl.Maximum-sl.Expended) + "/" + strconv.Itoa(sl.Maximum)
+	}
+	for _, sl := range sc.Slots {
+		if sl.Maximum > 0 {
+			s.Slots = append(s.Slots, slots("", sl))
+		}
+	}
+	if sc.PactSlots != nil && sc.PactSlots.Maximum > 0 {
+		s.Slots = append(s.Slots, slots("Pact ", *sc.PactSlots))
+	}
+
+	return s
+}
@@ -0,0 +1,176 @@
+package sheet
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aakordas/creature_manager/pkg/abilities"
+	"github.com/aakordas/creature_manager/pkg/conditions"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/inventory"
+	"github.com/aakordas/creature_manager/pkg/saves"
+	"github.com/aakordas/creature_manager/pkg/skills"
+	"github.com/aakordas/creature_manager/pkg/spellcasting"
+)
+
+func merry() creature.Creature {
+	c := creature.Creature{
+		Name:              "Merry",
+		Kind:              creature.Player,
+		Level:             3,
+		CurrentHitPoints:  20,
+		MaximumHitPoints:  24,
+		ArmorClass:        14,
+		ProficiencyBonus:  2,
+		PassivePerception: 11,
+		Classes:           map[string]int{"wizard": 1, "rogue": 2},
+		Speed:             map[string]int{"climb": 15, "walk": 25},
+		Abilities: abilities.Abilities{
+			Dexterity: 16, DexterityModifier: 3,
+			Intelligence: 14, IntelligenceModifier: 2,
+			Wisdom: 12, WisdomModifier: 1,
+		},
+		SavingThrows: saves.SavingThrows{saves.Dexterity: 5},
+		Skills:       skills.Skills{},
+		Conditions:   []conditions.Condition{{Name: conditions.Poisoned, Duration: 2}},
+		Resources:    map[string]*creature.Resource{"arcane_recovery": {Current: 0, Maximum: 1, Recharge: creature.LongRest}},
+		Inventory: inventory.Inventory{
+			Items:    []inventory.Item{{Name: "Dagger", Quantity: 2, Equipped: true}, {Name: "Horn of Buckland", Quantity: 1}},
+			Currency: inventory.Currency{Gold: 15, Silver: 3},
+		},
+		Spellcasting: &spellcasting.Spellcasting{
+			Ability: abilities.Intelligence,
+			Slots:   []spellcasting.Slots{{Level: 1, Maximum: 2, Expended: 1}},
+			Known:   []string{"Magic Missile", "Shield"},
+		},
+	}
+	c.Skills.Set(skills.Stealth, 5)
+
+	return c
+}
+
+func TestNew(t *testing.T) {
+	s := New(merry())
+
+	if s.Classes != "Rogue 2, Wizard 1" {
+		t.Errorf("Classes = %q, want %q", s.Classes, "Rogue 2, Wizard 1")
+	}
+	if s.Speed != "25 ft., climb 15 ft." {
+		t.Errorf("Speed = %q, want %q", s.Speed, "25 ft., climb 15 ft.")
+	}
+	if want := (Ability{"Dexterity", 16, "+3"}); s.Abilities[1] != want {
+		t.Errorf("Abilities[1] = %+v, want %+v", s.Abilities[1], want)
+	}
+	if want := (Bonus{"Dexterity", "+5", true}); s.Saves[1] != want {
+		t.Errorf("Saves[1] = %+v, want %+v", s.Saves[1], want)
+	}
+	if want := (Bonus{"Wisdom", "+1", false}); s.Saves[4] != want {
+		t.Errorf("Saves[4] = %+v, want %+v", s.Saves[4], want)
+	}
+	if len(s.Skills) != len(skills.SkillToAbility) {
+		t.Errorf("len(Skills) = %d, want %d", len(s.Skills), len(skills.SkillToAbility))
+	}
+	for _, want := range []Bonus{
+		{"Sleight of Hand", "+3", false},
+		{"Stealth", "+5", true},
+	} {
+		for _, got := range s.Skills {
+			if got.Name == want.Name && got != want {
+				t.Errorf("skill %s = %+v, want %+v", want.Name, got, want)
+			}
+		}
+	}
+	if want := []string{"Poisoned (2 turns)"}; !reflect.DeepEqual(s.Conditions, want) {
+		t.Errorf("Conditions = %v, want %v", s.Conditions, want)
+	}
+	if want := []Section{{"Resources", []Feature{{"Arcane Recovery", "0/1, recharges after a long rest"}}}}; !reflect.DeepEqual(s.Features, want) {
+		t.Errorf("Features = %+v, want %+v", s.Features, want)
+	}
+	if want := []Item{{"Dagger", 2, "equipped"}, {"Horn of Buckland", 1, ""}}; !reflect.DeepEqual(s.Items, want) {
+		t.Errorf("Items = %+v, want %+v", s.Items, want)
+	}
+	if s.Currency != "15 gp, 3 sp" {
+		t.Errorf("Currency = %q, want %q", s.Currency, "15 gp, 3 sp")
+	}
+	want := &Spellcasting{
+		Ability:     "Intelligence",
+		SaveDC:      12,
+		AttackBonus: "+4",
+		Slots:       []string{"1st: 1/2"},
+		Known:       []string{"Magic Missile", "Shield"},
+	}
+	if !reflect.DeepEqual(s.Spellcasting, want) {
+		t.Errorf("Spellcasting = %+v, want %+v", s.Spellcasting, want)
+	}
+}
+
+func TestLabel(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"strength", "Strength"},
+		{"animal_handling", "Animal Handling"},
+		{"sleight_of_hand", "Sleight of Hand"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Label(tt.name); got != tt.want {
+			t.Errorf("Label(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	for n, want := range map[int]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 21: "21st"} {
+		if got := Ordinal(n); got != want {
+			t.Errorf("Ordinal(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	c := merry()
+	c.Name = "Merry *the* | Magnificent"
+
+	got, err := Markdown(New(c))
+	if err != nil {
+		t.Fatalf("Markdown() error = %v", err)
+	}
+	for _, want := range []string{
+		`# Merry \*the\* \| Magnificent`,
+		"| 20/24 | 14 | +2 | 11 | 25 ft., climb 15 ft. |",
+		"| Dexterity | 16 | +3 | **+5** |",
+		"| Stealth | **+5** |",
+		"- Dagger ×2 (equipped)",
+		"**Slots:** 1st: 1/2",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Markdown() does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	c := merry()
+	c.Name = "<script>alert(1)</script>"
+
+	got, err := HTML(New(c))
+	if err != nil {
+		t.Fatalf("HTML() error = %v", err)
+	}
+	if strings.Contains(got, "<script>") {
+		t.Errorf("HTML() does not escape the name:\n%s", got)
+	}
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<style>",
+		`<tr class="proficient"><td>Stealth</td>`,
+		"<li>Dagger ×2 (equipped)</li>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("HTML() does not contain %q:\n%s", want, got)
+		}
+	}
+}
@@ -73,3 +73,12 @@ var SkillToAbility = map[string]string{
 
 // Skills is the collection of skills the creature is proficient in.
 type Skills map[string]*skill
+
+// Set sets the value of the provided skill, marking the creature as proficient
+// in it. The skill is expected to be valid.
+func (s Skills) Set(name string, value int) {
+	s[name] = &skill{
+		Value:    value,
+		Modifier: SkillToAbility[name],
+	}
+}
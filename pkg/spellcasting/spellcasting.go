@@ -0,0 +1,276 @@
+package spellcasting
+
+import (
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/classes"
+)
+
+// Slots holds the spell slots of a single spell level.
+type Slots struct {
+	Level    int `json:"level" bson:"level"`
+	Maximum  int `json:"maximum" bson:"maximum"`
+	Expended int `json:"expended" bson:"expended"`
+}
+
+// Spellcasting holds everything a caster tracks about its spells.
+type Spellcasting struct {
+	Ability   string   `json:"ability" bson:"ability"` // The ability the creature casts its spells with.
+	Slots     []Slots  `json:"slots,omitempty" bson:"slots,omitempty"`
+	PactSlots *Slots   `json:"pact_slots,omitempty" bson:"pact_slots,omitempty"` // The slots of the Pact Magic feature, recharged after a short rest.
+	Known     []string `json:"known,omitempty" bson:"known,omitempty"`
+	Prepared  []string `json:"prepared,omitempty" bson:"prepared,omitempty"`
+
+	Concentration string `json:"concentration,omitempty" bson:"concentration,omitempty"` // The spell the creature is concentrating on.
+}
+
+// maximumSpellLevel indicates the highest level a spell can have.
+const maximumSpellLevel = 9
+
+// fullCasters are the classes that use the full spellcaster progression.
+var fullCasters = map[string]bool{
+	classes.Bard:     true,
+	classes.Cleric:   true,
+	classes.Druid:    true,
+	classes.Sorcerer: true,
+	classes.Wizard:   true,
+}
+
+// halfCasters are the classes that gain spell slots at half the rate of a
+// full caster.
+var halfCasters = map[string]bool{
+	classes.Paladin: true,
+	classes.Ranger:  true,
+}
+
+// SlotsPerLevel maps a caster level to the number of spell slots per spell
+// level. It is both the full caster and the multiclass spellcaster table.
+var SlotsPerLevel = map[int][maximumSpellLevel]int{
+	1:  {2},
+	2:  {3},
+	3:  {4, 2},
+	4:  {4, 3},
+	5:  {4, 3, 2},
+	6:  {4, 3, 3},
+	7:  {4, 3, 3, 1},
+	8:  {4, 3, 3, 2},
+	9:  {4, 3, 3, 3, 1},
+	10: {4, 3, 3, 3, 2},
+	11: {4, 3, 3, 3, 2, 1},
+	12: {4, 3, 3, 3, 2, 1},
+	13: {4, 3, 3, 3, 2, 1, 1},
+	14: {4, 3, 3, 3, 2, 1, 1},
+	15: {4, 3, 3, 3, 2, 1, 1, 1},
+	16: {4, 3, 3, 3, 2, 1, 1, 1},
+	17: {4, 3, 3, 3, 2, 1, 1, 1, 1},
+	18: {4, 3, 3, 3, 3, 1, 1, 1, 1},
+	19: {4, 3, 3, 3, 3, 2, 1, 1, 1},
+	20: {4, 3, 3, 3, 3, 2, 2, 1, 1},
+}
+
+// pactMagic holds the number of pact slots and their level.
+type pactMagic struct {
+	count int
+	level int
+}
+
+// PactSlotsPerLevel maps a warlock level to its pact slots.
+var PactSlotsPerLevel = map[int]pactMagic{
+	1:  {1, 1},
+	2:  {2, 1},
+	3:  {2, 2},
+	4:  {2, 2},
+	5:  {2, 3},
+	6:  {2, 3},
+	7:  {2, 4},
+	8:  {2, 4},
+	9:  {2, 5},
+	10: {2, 5},
+	11: {3, 5},
+	12: {3, 5},
+	13: {3, 5},
+	14: {3, 5},
+	15: {3, 5},
+	16: {3, 5},
+	17: {4, 5},
+	18: {4, 5},
+	19: {4, 5},
+	20: {4, 5},
+}
+
+// CasterLevel returns the caster level of a creature with the provided class
+// levels. A creature with a single half caster class rounds up, while a
+// multiclass creature adds half of those levels rounded down.
+func CasterLevel(c classes.Classes) int {
+	level, casters, half := 0, 0, 0
+	for class, levels := range c {
+		switch {
+		case fullCasters[class]:
+			level += levels
+			casters++
+		case halfCasters[class]:
+			level += levels / 2
+			half = levels
+			casters++
+		}
+	}
+
+	if casters == 1 && half > 1 {
+		return (half + 1) / 2
+	}
+
+	return level
+}
+
+// SpellSlots returns the spell slots of a creature with the provided class
+// levels. Pact magic is not included.
+func SpellSlots(c classes.Classes) []Slots {
+	table, ok := SlotsPerLevel[CasterLevel(c)]
+	if !ok {
+		return nil
+	}
+
+	var slots []Slots
+	for i, n := range table {
+		if n == 0 {
+			break
+		}
+		slots = append(slots, Slots{Level: i + 1, Maximum: n})
+	}
+
+	return slots
+}
+
+// PactSlots returns the pact magic slots of a creature with the provided class
+// levels, or nil if it has no warlock levels.
+func PactSlots(c classes.Classes) *Slots {
+	p, ok := PactSlotsPerLevel[c[classes.Warlock]]
+	if !ok {
+		return nil
+	}
+
+	return &Slots{Level: p.level, Maximum: p.count}
+}
+
+// Recalculate updates the slots of the caster after its class levels changed.
+// Already expended slots stay expended.
+func (s *Spellcasting) Recalculate(c classes.Classes) {
+	expended := make(map[int]int)
+	for _, slot := range s.Slots {
+		expended[slot.Level] = slot.Expended
+	}
+
+	s.Slots = SpellSlots(c)
+	for i := range s.Slots {
+		s.Slots[i].Expended = expended[s.Slots[i].Level]
+		if s.Slots[i].Expended > s.Slots[i].Maximum {
+			s.Slots[i].Expended = s.Slots[i].Maximum
+		}
+	}
+
+	pact := PactSlots(c)
+	if pact != nil && s.PactSlots != nil {
+		pact.Expended = s.PactSlots.Expended
+		if pact.Expended > pact.Maximum {
+			pact.Expended = pact.Maximum
+		}
+	}
+	s.PactSlots = pact
+}
+
+// Error is the error that gets returned when a spell cannot be cast.
+type Error struct {
+	Message string
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
+
+var (
+	errInvalidLevel = Error{"Please provide a valid spell level."}
+	errNoSlots      = Error{"There are no spell slots left of the requested level."}
+)
+
+// OutOfRange checks whether the provided value is a valid spell level. Cantrips
+// are of level 0.
+func OutOfRange(v int) bool {
+	if v >= 0 && v <= maximumSpellLevel {
+		return false
+	}
+
+	return true
+}
+
+// CanCast checks whether the caster knows or has prepared the provided spell.
+// Spell names are compared regardless of case.
+func (s *Spellcasting) CanCast(spell string) bool {
+	for _, list := range [][]string{s.Known, s.Prepared} {
+		for _, name := range list {
+			if strings.EqualFold(name, spell) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Expend uses a spell slot of the provided level. Pact slots are used only when
+// there is no regular slot available.
+func (s *Spellcasting) Expend(level int) error {
+	if OutOfRange(level) || level == 0 {
+		return errInvalidLevel
+	}
+
+	for i := range s.Slots {
+		if s.Slots[i].Level == level && s.Slots[i].Expended < s.Slots[i].Maximum {
+			s.Slots[i].Expended++
+			return nil
+		}
+	}
+
+	if s.PactSlots != nil && s.PactSlots.Level == level && s.PactSlots.Expended < s.PactSlots.Maximum {
+		s.PactSlots.Expended++
+		return nil
+	}
+
+	return errNoSlots
+}
+
+// Restore recharges the spell slots of the caster. A short rest only recharges
+// the pact slots.
+func (s *Spellcasting) Restore(long bool) {
+	if s.PactSlots != nil {
+		s.PactSlots.Expended = 0
+	}
+	if !long {
+		return
+	}
+
+	for i := range s.Slots {
+		s.Slots[i].Expended = 0
+	}
+}
+
+// SaveDC returns the spell save DC of a caster with the provided proficiency
+// bonus and casting ability modifier.
+func SaveDC(proficiencyBonus, modifier int) int {
+	return 8 + proficiencyBonus + modifier
+}
+
+// AttackBonus returns the spell attack bonus of a caster with the provided
+// proficiency bonus and casting ability modifier.
+func AttackBonus(proficiencyBonus, modifier int) int {
+	return proficiencyBonus + modifier
+}
+
+// ConcentrationDC returns the DC of the Constitution saving throw a creature
+// has to make to keep concentrating after taking the provided damage.
+func ConcentrationDC(damage int) int {
+	if damage/2 > 10 {
+		return damage / 2
+	}
+
+	return 10
+}
@@ -0,0 +1,127 @@
+package spellcasting
+
+import (
+	"testing"
+
+	"github.com/aakordas/creature_manager/pkg/classes"
+)
+
+func TestCasterLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		classes classes.Classes
+		want    int
+	}{
+		{"No caster", classes.Classes{classes.Fighter: 5}, 0},
+		{"Full caster", classes.Classes{classes.Wizard: 5}, 5},
+		{"First level half caster", classes.Classes{classes.Paladin: 1}, 0},
+		{"Single half caster rounds up", classes.Classes{classes.Paladin: 5}, 3},
+		{"Multiclass half caster rounds down", classes.Classes{classes.Paladin: 5, classes.Sorcerer: 2}, 4},
+		{"Two half casters", classes.Classes{classes.Paladin: 3, classes.Ranger: 3}, 2},
+		{"Warlock is not counted", classes.Classes{classes.Warlock: 5, classes.Cleric: 1}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CasterLevel(tt.classes); got != tt.want {
+				t.Errorf("CasterLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPactSlots(t *testing.T) {
+	if got := PactSlots(classes.Classes{classes.Wizard: 3}); got != nil {
+		t.Errorf("PactSlots() = %v, want nil", got)
+	}
+
+	got := PactSlots(classes.Classes{classes.Warlock: 5})
+	if got == nil || got.Level != 3 || got.Maximum != 2 {
+		t.Errorf("PactSlots() = %v, want two third level slots", got)
+	}
+}
+
+func TestExpend(t *testing.T) {
+	s := &Spellcasting{}
+	s.Recalculate(classes.Classes{classes.Wizard: 3, classes.Warlock: 1})
+
+	tests := []struct {
+		name    string
+		level   int
+		wantErr bool
+	}{
+		{"Cantrip", 0, true},
+		{"First level", 1, false},
+		{"Second level", 2, false},
+		{"Third level", 3, true},
+		{"Second level again", 2, false},
+		{"No second level left", 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Expend(tt.level); (err != nil) != tt.wantErr {
+				t.Errorf("Expend() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Four first level slots and a first level pact slot.
+	for i := 0; i < 4; i++ {
+		if err := s.Expend(1); err != nil {
+			t.Fatalf("Expend() error = %v after %v first level slots", err, i+1)
+		}
+	}
+	if err := s.Expend(1); err == nil {
+		t.Errorf("Expend() did not run out of first level slots.")
+	}
+
+	s.Restore(false)
+	if s.PactSlots.Expended != 0 || s.Slots[0].Expended == 0 {
+		t.Errorf("Restore() after a short rest recharged the wrong slots.")
+	}
+	s.Restore(true)
+	if s.Slots[0].Expended != 0 {
+		t.Errorf("Restore() after a long rest did not recharge every slot.")
+	}
+}
+
+func TestCanCast(t *testing.T) {
+	s := &Spellcasting{
+		Known:    []string{"Mage Hand", "Shield"},
+		Prepared: []string{"Cure Wounds"},
+	}
+
+	tests := []struct {
+		spell string
+		want  bool
+	}{
+		{"Shield", true},
+		{"Cure Wounds", true},
+		{"mage hand", true},
+		{"Fireball", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spell, func(t *testing.T) {
+			if got := s.CanCast(tt.spell); got != tt.want {
+				t.Errorf("CanCast() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConcentrationDC(t *testing.T) {
+	tests := []struct {
+		damage int
+		want   int
+	}{
+		{1, 10},
+		{21, 10},
+		{22, 11},
+		{50, 25},
+	}
+	for _, tt := range tests {
+		if got := ConcentrationDC(tt.damage); got != tt.want {
+			t.Errorf("ConcentrationDC(%v) = %v, want %v", tt.damage, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package spells
+
+import (
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/classes"
+)
+
+// Spell models a spell of the game.
+type Spell struct {
+	Name          string   `json:"name" bson:"name"`
+	Level         int      `json:"level" bson:"level"` // Cantrips are of level 0.
+	School        string   `json:"school" bson:"school"`
+	CastingTime   string   `json:"casting_time" bson:"casting_time"`
+	Range         string   `json:"range" bson:"range"`
+	Components    []string `json:"components" bson:"components"`
+	Material      string   `json:"material,omitempty" bson:"material,omitempty"` // The material components, if any.
+	Duration      string   `json:"duration" bson:"duration"`
+	Concentration bool     `json:"concentration" bson:"concentration"`
+	Ritual        bool     `json:"ritual" bson:"ritual"`
+	Classes       []string `json:"classes" bson:"classes"`
+	Description   string   `json:"description" bson:"description"`
+	Homebrew      bool     `json:"homebrew" bson:"homebrew"`
+	Campaign      string   `json:"campaign,omitempty" bson:"campaign,omitempty"` // The campaign the homebrew spell was made for, if any.
+	Owner         string   `json:"owner,omitempty" bson:"owner,omitempty"`       // The user who made the homebrew spell, if any.
+}
+
+const (
+	// Abjuration means the Abjuration school of magic.
+	Abjuration = "abjuration"
+	// Conjuration means the Conjuration school of magic.
+	Conjuration = "conjuration"
+	// Divination means the Divination school of magic.
+	Divination = "divination"
+	// Enchantment means the Enchantment school of magic.
+	Enchantment = "enchantment"
+	// Evocation means the Evocation school of magic.
+	Evocation = "evocation"
+	// Illusion means the Illusion school of magic.
+	Illusion = "illusion"
+	// Necromancy means the Necromancy school of magic.
+	Necromancy = "necromancy"
+	// Transmutation means the Transmutation school of magic.
+	Transmutation = "transmutation"
+)
+
+const (
+	// Verbal means the spell has a verbal component.
+	Verbal = "V"
+	// Somatic means the spell has a somatic component.
+	Somatic = "S"
+	// Material means the spell has a material component.
+	Material = "M"
+)
+
+// ValidSchool checks if the provided value is a valid school of magic.
+func ValidSchool(s string) bool {
+	switch strings.ToLower(s) {
+	case Abjuration, Conjuration, Divination, Enchantment,
+		Evocation, Illusion, Necromancy, Transmutation:
+		return true
+	default:
+		return false
+	}
+}
+
+// InvalidSpellError is the error that gets returned when a spell is missing
+// some of its required information.
+type InvalidSpellError struct {
+	Field string // The field that is invalid.
+}
+
+func (e InvalidSpellError) Error() string {
+	return "The spell has an invalid " + e.Field + "."
+}
+
+// Validate checks that the spell has everything a spell needs.
+func (s Spell) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return InvalidSpellError{"name"}
+	}
+	if s.Level < 0 || s.Level > 9 {
+		return InvalidSpellError{"level"}
+	}
+	if !ValidSchool(s.School) {
+		return InvalidSpellError{"school"}
+	}
+	for _, c := range s.Components {
+		if c != Verbal && c != Somatic && c != Material {
+			return InvalidSpellError{"components"}
+		}
+	}
+	for _, c := range s.Classes {
+		if !classes.Valid(c) {
+			return InvalidSpellError{"class"}
+		}
+	}
+
+	return nil
+}
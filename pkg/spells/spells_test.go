@@ -0,0 +1,49 @@
+package spells
+
+import "testing"
+
+func TestSpell_Validate(t *testing.T) {
+	valid := Spell{
+		Name:       "Frost Fingers",
+		Level:      1,
+		School:     Evocation,
+		Components: []string{Verbal, Somatic},
+		Classes:    []string{"wizard"},
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(s *Spell)
+		wantErr bool
+	}{
+		{"Valid spell", func(s *Spell) {}, false},
+		{"Cantrip", func(s *Spell) { s.Level = 0 }, false},
+		{"Missing name", func(s *Spell) { s.Name = " " }, true},
+		{"Level too high", func(s *Spell) { s.Level = 10 }, true},
+		{"Unknown school", func(s *Spell) { s.School = "pyromancy" }, true},
+		{"Unknown component", func(s *Spell) { s.Components = []string{"X"} }, true},
+		{"Unknown class", func(s *Spell) { s.Classes = []string{"peasant"} }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid
+			tt.modify(&s)
+			if err := s.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Spell.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSRD(t *testing.T) {
+	names := make(map[string]bool)
+	for _, s := range SRD {
+		if err := s.Validate(); err != nil {
+			t.Errorf("SRD spell %v: %v", s.Name, err)
+		}
+		if names[s.Name] {
+			t.Errorf("SRD spell %v appears more than once.", s.Name)
+		}
+		names[s.Name] = true
+	}
+}
@@ -0,0 +1,465 @@
+package spells
+
+import "github.com/aakordas/creature_manager/pkg/classes"
+
+// SRD holds the spells of the System Reference Document that get loaded in the
+// database when the server starts.
+var SRD = []Spell{
+	{
+		Name:        "Acid Splash",
+		Level:       0,
+		School:      Conjuration,
+		CastingTime: "1 action",
+		Range:       "60 feet",
+		Components:  []string{Verbal, Somatic},
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Sorcerer, classes.Wizard},
+		Description: "Hurls a bubble of acid at one or two creatures within 5 feet of each other. A target must succeed on a Dexterity saving throw or take 1d6 acid damage.",
+	},
+	{
+		Name:        "Fire Bolt",
+		Level:       0,
+		School:      Evocation,
+		CastingTime: "1 action",
+		Range:       "120 feet",
+		Components:  []string{Verbal, Somatic},
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Sorcerer, classes.Wizard},
+		Description: "Make a ranged spell attack against a creature or object. On a hit, the target takes 1d10 fire damage.",
+	},
+	{
+		Name:        "Light",
+		Level:       0,
+		School:      Evocation,
+		CastingTime: "1 action",
+		Range:       "Touch",
+		Components:  []string{Verbal, Material},
+		Material:    "a firefly or phosphorescent moss",
+		Duration:    "1 hour",
+		Classes:     []string{classes.Bard, classes.Cleric, classes.Sorcerer, classes.Wizard},
+		Description: "An object you touch sheds bright light in a 20-foot radius and dim light for an additional 20 feet.",
+	},
+	{
+		Name:        "Mage Hand",
+		Level:       0,
+		School:      Conjuration,
+		CastingTime: "1 action",
+		Range:       "30 feet",
+		Components:  []string{Verbal, Somatic},
+		Duration:    "1 minute",
+		Classes:     []string{classes.Bard, classes.Sorcerer, classes.Warlock, classes.Wizard},
+		Description: "A spectral, floating hand appears that can manipulate objects, open containers and carry up to 10 pounds.",
+	},
+	{
+		Name:        "Sacred Flame",
+		Level:       0,
+		School:      Evocation,
+		CastingTime: "1 action",
+		Range:       "60 feet",
+		Components:  []string{Verbal, Somatic},
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Cleric},
+		Description: "Radiance descends on a creature you can see. It must succeed on a Dexterity saving throw or take 1d8 radiant damage.",
+	},
+	{
+		Name:        "Eldritch Blast",
+		Level:       0,
+		School:      Evocation,
+		CastingTime: "1 action",
+		Range:       "120 feet",
+		Components:  []string{Verbal, Somatic},
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Warlock},
+		Description: "A beam of crackling energy streaks toward a creature. Make a ranged spell attack; on a hit the target takes 1d10 force damage.",
+	},
+	{
+		Name:        "Vicious Mockery",
+		Level:       0,
+		School:      Enchantment,
+		CastingTime: "1 action",
+		Range:       "60 feet",
+		Components:  []string{Verbal},
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Bard},
+		Description: "A creature that can hear you must succeed on a Wisdom saving throw or take 1d4 psychic damage and have disadvantage on its next attack roll.",
+	},
+	{
+		Name:          "Bless",
+		Level:         1,
+		School:        Enchantment,
+		CastingTime:   "1 action",
+		Range:         "30 feet",
+		Components:    []string{Verbal, Somatic, Material},
+		Material:      "a sprinkling of holy water",
+		Duration:      "Up to 1 minute",
+		Concentration: true,
+		Classes:       []string{classes.Cleric, classes.Paladin},
+		Description:   "Up to three creatures add a d4 to their attack rolls and saving throws for the duration.",
+	},
+	{
+		Name:        "Burning Hands",
+		Level:       1,
+		School:      Evocation,
+		CastingTime: "1 action",
+		Range:       "Self (15-foot cone)",
+		Components:  []string{Verbal, Somatic},
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Sorcerer, classes.Wizard},
+		Description: "Each creature in a 15-foot cone makes a Dexterity saving throw, taking 3d6 fire damage on a failure or half as much on a success.",
+	},
+	{
+		Name:        "Cure Wounds",
+		Level:       1,
+		School:      Evocation,
+		CastingTime: "1 action",
+		Range:       "Touch",
+		Components:  []string{Verbal, Somatic},
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Bard, classes.Cleric, classes.Druid, classes.Paladin, classes.Ranger},
+		Description: "A creature you touch regains hit points equal to 1d8 + your spellcasting ability modifier.",
+	},
+	{
+		Name:          "Detect Magic",
+		Level:         1,
+		School:        Divination,
+		CastingTime:   "1 action",
+		Range:         "Self",
+		Components:    []string{Verbal, Somatic},
+		Duration:      "Up to 10 minutes",
+		Concentration: true,
+		Ritual:        true,
+		Classes:       []string{classes.Bard, classes.Cleric, classes.Druid, classes.Paladin, classes.Ranger, classes.Sorcerer, classes.Wizard},
+		Description:   "You sense the presence of magic within 30 feet of you and can see a faint aura around visible magical creatures or objects.",
+	},
+	{
+		Name:        "Healing Word",
+		Level:       1,
+		School:      Evocation,
+		CastingTime: "1 bonus action",
+		Range:       "60 feet",
+		Components:  []string{Verbal},
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Bard, classes.Cleric, classes.Druid},
+		Description: "A creature you can see regains hit points equal to 1d4 + your spellcasting ability modifier.",
+	},
+	{
+		Name:          "Hunter's Mark",
+		Level:         1,
+		School:        Divination,
+		CastingTime:   "1 bonus action",
+		Range:         "90 feet",
+		Components:    []string{Verbal},
+		Duration:      "Up to 1 hour",
+		Concentration: true,
+		Classes:       []string{classes.Ranger},
+		Description:   "You mark a creature as your quarry and deal an extra 1d6 damage to it whenever you hit it with a weapon attack.",
+	},
+	{
+		Name:        "Mage Armor",
+		Level:       1,
+		School:      Abjuration,
+		CastingTime: "1 action",
+		Range:       "Touch",
+		Components:  []string{Verbal, Somatic, Material},
+		Material:    "a piece of cured leather",
+		Duration:    "8 hours",
+		Classes:     []string{classes.Sorcerer, classes.Wizard},
+		Description: "A willing creature not wearing armor has its base AC become 13 + its Dexterity modifier until the spell ends.",
+	},
+	{
+		Name:        "Magic Missile",
+		Level:       1,
+		School:      Evocation,
+		CastingTime: "1 action",
+		Range:       "120 feet",
+		Components:  []string{Verbal, Somatic},
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Sorcerer, classes.Wizard},
+		Description: "Three glowing darts of magical force each hit a creature you can see, dealing 1d4 + 1 force damage.",
+	},
+	{
+		Name:        "Shield",
+		Level:       1,
+		School:      Abjuration,
+		CastingTime: "1 reaction",
+		Range:       "Self",
+		Components:  []string{Verbal, Somatic},
+		Duration:    "1 round",
+		Classes:     []string{classes.Sorcerer, classes.Wizard},
+		Description: "An invisible barrier grants you a +5 bonus to AC until the start of your next turn, including against the triggering attack.",
+	},
+	{
+		Name:        "Sleep",
+		Level:       1,
+		School:      Enchantment,
+		CastingTime: "1 action",
+		Range:       "90 feet",
+		Components:  []string{Verbal, Somatic, Material},
+		Material:    "a pinch of fine sand, rose petals, or a cricket",
+		Duration:    "1 minute",
+		Classes:     []string{classes.Bard, classes.Sorcerer, classes.Wizard},
+		Description: "Roll 5d8; creatures within 20 feet of a point fall unconscious in ascending order of current hit points until the total is spent.",
+	},
+	{
+		Name:        "Thunderwave",
+		Level:       1,
+		School:      Evocation,
+		CastingTime: "1 action",
+		Range:       "Self (15-foot cube)",
+		Components:  []string{Verbal, Somatic},
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Bard, classes.Druid, classes.Sorcerer, classes.Wizard},
+		Description: "Each creature in a 15-foot cube makes a Constitution saving throw, taking 2d8 thunder damage and being pushed 10 feet on a failure.",
+	},
+	{
+		Name:          "Hex",
+		Level:         1,
+		School:        Enchantment,
+		CastingTime:   "1 bonus action",
+		Range:         "90 feet",
+		Components:    []string{Verbal, Somatic, Material},
+		Material:      "the petrified eye of a newt",
+		Duration:      "Up to 1 hour",
+		Concentration: true,
+		Classes:       []string{classes.Warlock},
+		Description:   "You curse a creature, dealing an extra 1d6 necrotic damage whenever you hit it and giving it disadvantage on checks of one ability.",
+	},
+	{
+		Name:          "Hold Person",
+		Level:         2,
+		School:        Enchantment,
+		CastingTime:   "1 action",
+		Range:         "60 feet",
+		Components:    []string{Verbal, Somatic, Material},
+		Material:      "a small, straight piece of iron",
+		Duration:      "Up to 1 minute",
+		Concentration: true,
+		Classes:       []string{classes.Bard, classes.Cleric, classes.Druid, classes.Sorcerer, classes.Warlock, classes.Wizard},
+		Description:   "A humanoid you can see must succeed on a Wisdom saving throw or be paralyzed for the duration, repeating the save at the end of each of its turns.",
+	},
+	{
+		Name:          "Invisibility",
+		Level:         2,
+		School:        Illusion,
+		CastingTime:   "1 action",
+		Range:         "Touch",
+		Components:    []string{Verbal, Somatic, Material},
+		Material:      "an eyelash encased in gum arabic",
+		Duration:      "Up to 1 hour",
+		Concentration: true,
+		Classes:       []string{classes.Bard, classes.Sorcerer, classes.Warlock, classes.Wizard},
+		Description:   "A creature you touch becomes invisible until the spell ends or it attacks or casts a spell.",
+	},
+	{
+		Name:        "Misty Step",
+		Level:       2,
+		School:      Conjuration,
+		CastingTime: "1 bonus action",
+		Range:       "Self",
+		Components:  []string{Verbal},
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Sorcerer, classes.Warlock, classes.Wizard},
+		Description: "Briefly surrounded by silvery mist, you teleport up to 30 feet to an unoccupied space you can see.",
+	},
+	{
+		Name:        "Scorching Ray",
+		Level:       2,
+		School:      Evocation,
+		CastingTime: "1 action",
+		Range:       "120 feet",
+		Components:  []string{Verbal, Somatic},
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Sorcerer, classes.Wizard},
+		Description: "You create three rays of fire. Make a ranged spell attack for each ray; on a hit the target takes 2d6 fire damage.",
+	},
+	{
+		Name:        "Spiritual Weapon",
+		Level:       2,
+		School:      Evocation,
+		CastingTime: "1 bonus action",
+		Range:       "60 feet",
+		Components:  []string{Verbal, Somatic},
+		Duration:    "1 minute",
+		Classes:     []string{classes.Cleric},
+		Description: "A floating spectral weapon makes a melee spell attack, dealing 1d8 + your spellcasting ability modifier force damage on a hit.",
+	},
+	{
+		Name:        "Counterspell",
+		Level:       3,
+		School:      Abjuration,
+		CastingTime: "1 reaction",
+		Range:       "60 feet",
+		Components:  []string{Somatic},
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Sorcerer, classes.Warlock, classes.Wizard},
+		Description: "You interrupt a creature casting a spell. A spell of 3rd level or lower fails; a higher level spell requires an ability check.",
+	},
+	{
+		Name:        "Dispel Magic",
+		Level:       3,
+		School:      Abjuration,
+		CastingTime: "1 action",
+		Range:       "120 feet",
+		Components:  []string{Verbal, Somatic},
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Bard, classes.Cleric, classes.Druid, classes.Paladin, classes.Sorcerer, classes.Warlock, classes.Wizard},
+		Description: "Any spell of 3rd level or lower on the target ends. Higher level spells require an ability check.",
+	},
+	{
+		Name:        "Fireball",
+		Level:       3,
+		School:      Evocation,
+		CastingTime: "1 action",
+		Range:       "150 feet",
+		Components:  []string{Verbal, Somatic, Material},
+		Material:    "a tiny ball of bat guano and sulfur",
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Sorcerer, classes.Wizard},
+		Description: "A bright streak blossoms into an explosion. Each creature in a 20-foot radius sphere makes a Dexterity saving throw, taking 8d6 fire damage on a failure or half as much on a success.",
+	},
+	{
+		Name:          "Fly",
+		Level:         3,
+		School:        Transmutation,
+		CastingTime:   "1 action",
+		Range:         "Touch",
+		Components:    []string{Verbal, Somatic, Material},
+		Material:      "a wing feather from any bird",
+		Duration:      "Up to 10 minutes",
+		Concentration: true,
+		Classes:       []string{classes.Sorcerer, classes.Warlock, classes.Wizard},
+		Description:   "A willing creature you touch gains a flying speed of 60 feet for the duration.",
+	},
+	{
+		Name:        "Lightning Bolt",
+		Level:       3,
+		School:      Evocation,
+		CastingTime: "1 action",
+		Range:       "Self (100-foot line)",
+		Components:  []string{Verbal, Somatic, Material},
+		Material:    "a bit of fur and a rod of amber, crystal, or glass",
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Sorcerer, classes.Wizard},
+		Description: "Each creature in a 100-foot line makes a Dexterity saving throw, taking 8d6 lightning damage on a failure or half as much on a success.",
+	},
+	{
+		Name:        "Revivify",
+		Level:       3,
+		School:      Necromancy,
+		CastingTime: "1 action",
+		Range:       "Touch",
+		Components:  []string{Verbal, Somatic, Material},
+		Material:    "diamonds worth 300 gp, which the spell consumes",
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Cleric, classes.Paladin},
+		Description: "A creature that has died within the last minute returns to life with 1 hit point.",
+	},
+	{
+		Name:          "Spirit Guardians",
+		Level:         3,
+		School:        Conjuration,
+		CastingTime:   "1 action",
+		Range:         "Self (15-foot radius)",
+		Components:    []string{Verbal, Somatic, Material},
+		Material:      "a holy symbol",
+		Duration:      "Up to 10 minutes",
+		Concentration: true,
+		Classes:       []string{classes.Cleric},
+		Description:   "Spirits protect you. Enemies in the area have their speed halved and take 3d8 radiant or necrotic damage on a failed Wisdom saving throw.",
+	},
+	{
+		Name:          "Banishment",
+		Level:         4,
+		School:        Abjuration,
+		CastingTime:   "1 action",
+		Range:         "60 feet",
+		Components:    []string{Verbal, Somatic, Material},
+		Material:      "an item distasteful to the target",
+		Duration:      "Up to 1 minute",
+		Concentration: true,
+		Classes:       []string{classes.Cleric, classes.Paladin, classes.Sorcerer, classes.Warlock, classes.Wizard},
+		Description:   "A creature must succeed on a Charisma saving throw or be banished to a harmless demiplane for the duration.",
+	},
+	{
+		Name:          "Polymorph",
+		Level:         4,
+		School:        Transmutation,
+		CastingTime:   "1 action",
+		Range:         "60 feet",
+		Components:    []string{Verbal, Somatic, Material},
+		Material:      "a caterpillar cocoon",
+		Duration:      "Up to 1 hour",
+		Concentration: true,
+		Classes:       []string{classes.Bard, classes.Druid, classes.Sorcerer, classes.Wizard},
+		Description:   "A creature must succeed on a Wisdom saving throw or be transformed into a beast whose challenge rating is no higher than its level.",
+	},
+	{
+		Name:        "Cone of Cold",
+		Level:       5,
+		School:      Evocation,
+		CastingTime: "1 action",
+		Range:       "Self (60-foot cone)",
+		Components:  []string{Verbal, Somatic, Material},
+		Material:    "a small crystal or glass cone",
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Sorcerer, classes.Wizard},
+		Description: "Each creature in a 60-foot cone makes a Constitution saving throw, taking 8d8 cold damage on a failure or half as much on a success.",
+	},
+	{
+		Name:        "Raise Dead",
+		Level:       5,
+		School:      Necromancy,
+		CastingTime: "1 hour",
+		Range:       "Touch",
+		Components:  []string{Verbal, Somatic, Material},
+		Material:    "a diamond worth at least 500 gp, which the spell consumes",
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Bard, classes.Cleric, classes.Paladin},
+		Description: "You return a creature that has been dead for no longer than 10 days to life with 1 hit point.",
+	},
+	{
+		Name:        "Disintegrate",
+		Level:       6,
+		School:      Transmutation,
+		CastingTime: "1 action",
+		Range:       "60 feet",
+		Components:  []string{Verbal, Somatic, Material},
+		Material:    "a lodestone and a pinch of dust",
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Sorcerer, classes.Wizard},
+		Description: "A thin green ray strikes a target, which must succeed on a Dexterity saving throw or take 10d6 + 40 force damage.",
+	},
+	{
+		Name:        "Finger of Death",
+		Level:       7,
+		School:      Necromancy,
+		CastingTime: "1 action",
+		Range:       "60 feet",
+		Components:  []string{Verbal, Somatic},
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Sorcerer, classes.Warlock, classes.Wizard},
+		Description: "A creature must make a Constitution saving throw, taking 7d8 + 30 necrotic damage on a failure or half as much on a success.",
+	},
+	{
+		Name:        "Power Word Stun",
+		Level:       8,
+		School:      Enchantment,
+		CastingTime: "1 action",
+		Range:       "60 feet",
+		Components:  []string{Verbal},
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Bard, classes.Sorcerer, classes.Warlock, classes.Wizard},
+		Description: "A creature with 150 hit points or fewer is stunned until it succeeds on a Constitution saving throw at the end of its turn.",
+	},
+	{
+		Name:        "Wish",
+		Level:       9,
+		School:      Conjuration,
+		CastingTime: "1 action",
+		Range:       "Self",
+		Components:  []string{Verbal},
+		Duration:    "Instantaneous",
+		Classes:     []string{classes.Sorcerer, classes.Wizard},
+		Description: "The mightiest spell a mortal can cast. You can duplicate any spell of 8th level or lower, or create another effect at the DM's discretion.",
+	},
+}
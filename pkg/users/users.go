@@ -0,0 +1,121 @@
+package users
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// Session means a token created by logging in, which expires.
+	Session = "session"
+	// API means a token created for scripts and tools, which lasts until it
+	// gets revoked.
+	API = "api"
+)
+
+// SessionDuration indicates how long a session lasts after logging in.
+const SessionDuration = 24 * time.Hour
+
+// MinimumPasswordLength indicates the minimum number of characters of a
+// password.
+const MinimumPasswordLength = 8
+
+// User models an account of the server.
+type User struct {
+	Name         string `json:"name" bson:"name"`
+	PasswordHash []byte `json:"-" bson:"password_hash"`
+}
+
+// Token models a bearer token of a user. Only the hash of the token is stored,
+// so that the stored tokens cannot be used to authenticate.
+type Token struct {
+	Hash    string    `json:"-" bson:"hash"`
+	User    string    `json:"user" bson:"user"`
+	Kind    string    `json:"kind" bson:"kind"`
+	Name    string    `json:"name" bson:"name"`                           // Tells the API tokens of a user apart.
+	Expires time.Time `json:"expires,omitempty" bson:"expires,omitempty"` // Zero for API tokens.
+}
+
+// Error is the error that gets returned when an account operation is invalid.
+type Error struct {
+	Message string
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
+
+var (
+	errInvalidName      = Error{"A user name should contain only letters, digits, dashes and underscores."}
+	errShortPassword    = Error{"A password should contain at least 8 characters."}
+	errWrongCredentials = Error{"The user name or the password is wrong."}
+)
+
+// validName matches the valid user names.
+var validName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
+
+// New creates a user with the provided name and password. Only the hash of the
+// password is kept.
+func New(name, password string) (User, error) {
+	if !validName.MatchString(name) {
+		return User{}, errInvalidName
+	}
+	if len(password) < MinimumPasswordLength {
+		return User{}, errShortPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return User{}, err
+	}
+
+	return User{Name: name, PasswordHash: hash}, nil
+}
+
+// Authenticate checks whether the provided password is the one of the user.
+func (u User) Authenticate(password string) error {
+	if bcrypt.CompareHashAndPassword(u.PasswordHash, []byte(password)) != nil {
+		return errWrongCredentials
+	}
+
+	return nil
+}
+
+// HashToken returns the hash under which the provided token is stored.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewToken creates a token of the provided kind for the user. It returns the
+// secret that authenticates the user, along with the token to store. Sessions
+// expire SessionDuration after now.
+func NewToken(user, kind, name string, now time.Time) (string, Token, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", Token{}, err
+	}
+	secret := hex.EncodeToString(b)
+
+	t := Token{
+		Hash: HashToken(secret),
+		User: user,
+		Kind: kind,
+		Name: name,
+	}
+	if kind == Session {
+		t.Expires = now.Add(SessionDuration)
+	}
+
+	return secret, t, nil
+}
+
+// Expired checks whether the token has expired by now.
+func (t Token) Expired(now time.Time) bool {
+	return !t.Expires.IsZero() && !now.Before(t.Expires)
+}
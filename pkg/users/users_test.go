@@ -0,0 +1,77 @@
+package users
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		wantErr  error
+	}{
+		{"Valid user", "gandalf_the-grey", "you shall not pass", nil},
+		{"Empty name", "", "you shall not pass", errInvalidName},
+		{"Spaces in name", "gandalf the grey", "you shall not pass", errInvalidName},
+		{"Short password", "gandalf", "mellon", errShortPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := New(tt.user, tt.password)
+			if err != tt.wantErr {
+				t.Fatalf("New() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if string(u.PasswordHash) == tt.password {
+				t.Errorf("New() kept the password in plain text")
+			}
+		})
+	}
+}
+
+func TestUser_Authenticate(t *testing.T) {
+	u, err := New("frodo", "second breakfast")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := u.Authenticate("second breakfast"); err != nil {
+		t.Errorf("User.Authenticate() error = %v, want nil", err)
+	}
+	if err := u.Authenticate("elevenses"); err != errWrongCredentials {
+		t.Errorf("User.Authenticate() error = %v, want %v", err, errWrongCredentials)
+	}
+}
+
+func TestNewToken(t *testing.T) {
+	now := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	secret, session, err := NewToken("sam", Session, "", now)
+	if err != nil {
+		t.Fatalf("NewToken() error = %v", err)
+	}
+	if session.Hash != HashToken(secret) || session.Hash == secret {
+		t.Errorf("NewToken() hash = %v, want the hash of the secret", session.Hash)
+	}
+	if session.Expired(now.Add(SessionDuration - time.Second)) {
+		t.Errorf("Token.Expired() = true before the session ends")
+	}
+	if !session.Expired(now.Add(SessionDuration)) {
+		t.Errorf("Token.Expired() = false after the session ends")
+	}
+
+	other, api, err := NewToken("sam", API, "scripts", now)
+	if err != nil {
+		t.Fatalf("NewToken() error = %v", err)
+	}
+	if other == secret {
+		t.Errorf("NewToken() returned the same secret twice")
+	}
+	if api.Expired(now.AddDate(10, 0, 0)) {
+		t.Errorf("Token.Expired() = true for an API token")
+	}
+}
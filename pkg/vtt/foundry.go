@@ -0,0 +1,871 @@
+package vtt
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/abilities"
+	"github.com/aakordas/creature_manager/pkg/armor"
+	"github.com/aakordas/creature_manager/pkg/classes"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/damage"
+	"github.com/aakordas/creature_manager/pkg/inventory"
+	"github.com/aakordas/creature_manager/pkg/saves"
+	"github.com/aakordas/creature_manager/pkg/skills"
+	"github.com/aakordas/creature_manager/pkg/spellcasting"
+	"github.com/aakordas/creature_manager/pkg/weapons"
+)
+
+// Actor models a character or an NPC of the dnd5e system of Foundry VTT, as
+// it gets exported to JSON. Only what a creature keeps track of is modelled,
+// and the rest is left out.
+type Actor struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"` // Either character or npc.
+	Data  actorData   `json:"data"`
+	Items []actorItem `json:"items"`
+}
+
+type actorData struct {
+	Abilities  map[string]foundryAbility  `json:"abilities"`
+	Attributes foundryAttributes          `json:"attributes"`
+	Details    foundryDetails             `json:"details"`
+	Traits     foundryTraits              `json:"traits"`
+	Currency   inventory.Currency         `json:"currency"`
+	Skills     map[string]foundrySkill    `json:"skills"`
+	Spells     map[string]foundrySlots    `json:"spells,omitempty"`
+	Resources  map[string]foundryResource `json:"resources,omitempty"`
+}
+
+type foundryAbility struct {
+	Value      int     `json:"value"`
+	Proficient float64 `json:"proficient"`
+}
+
+type foundryAttributes struct {
+	AC struct {
+		Value int `json:"value"`
+	} `json:"ac"`
+	HP struct {
+		Value   int    `json:"value"`
+		Max     int    `json:"max"`
+		Formula string `json:"formula,omitempty"`
+	} `json:"hp"`
+	Movement     map[string]interface{} `json:"movement,omitempty"`
+	Speed        *foundrySpeed          `json:"speed,omitempty"` // Replaced by the movement in later versions.
+	Senses       map[string]interface{} `json:"senses,omitempty"`
+	Prof         int                    `json:"prof"`
+	Spellcasting string                 `json:"spellcasting"`
+	Exhaustion   int                    `json:"exhaustion"`
+}
+
+type foundrySpeed struct {
+	Value string `json:"value"`
+}
+
+type foundryDetails struct {
+	XP struct {
+		Value int `json:"value"`
+	} `json:"xp"`
+	CR        *float64    `json:"cr,omitempty"`
+	Type      foundryType `json:"type,omitempty"`
+	Alignment string      `json:"alignment,omitempty"`
+}
+
+// foundryType is the type of an NPC, which earlier versions store as a string
+// and later ones as an object with the string in its value.
+type foundryType string
+
+// UnmarshalJSON implements the json.Unmarshaler interface for foundryType.
+func (t *foundryType) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*t = foundryType(s)
+		return nil
+	}
+
+	var v struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*t = foundryType(v.Value)
+
+	return nil
+}
+
+type foundryTraits struct {
+	Size      string       `json:"size"`
+	DI        foundryTrait `json:"di"`
+	DR        foundryTrait `json:"dr"`
+	DV        foundryTrait `json:"dv"`
+	CI        foundryTrait `json:"ci"`
+	Languages foundryTrait `json:"languages"`
+}
+
+type foundryTrait struct {
+	Value  []string `json:"value"`
+	Custom string   `json:"custom"`
+}
+
+type foundrySkill struct {
+	Value   float64 `json:"value"` // The multiplier of the proficiency bonus, like 2 for expertise.
+	Ability string  `json:"ability"`
+	Passive int     `json:"passive,omitempty"`
+}
+
+type foundrySlots struct {
+	Value int `json:"value"` // The remaining slots.
+	Max   int `json:"max"`
+	Level int `json:"level,omitempty"` // Only for the pact slots.
+}
+
+type foundryResource struct {
+	Value int    `json:"value"`
+	Max   int    `json:"max"`
+	SR    bool   `json:"sr"`
+	LR    bool   `json:"lr"`
+	Label string `json:"label"`
+}
+
+type actorItem struct {
+	Name string   `json:"name"`
+	Type string   `json:"type"`
+	Data itemData `json:"data"`
+}
+
+type itemData struct {
+	Description *foundryDescription `json:"description,omitempty"`
+
+	Quantity   int     `json:"quantity,omitempty"`
+	Weight     float64 `json:"weight,omitempty"`
+	Price      float64 `json:"price,omitempty"` // In gold pieces.
+	Equipped   bool    `json:"equipped,omitempty"`
+	Attunement int     `json:"attunement,omitempty"` // 1 if the item requires attunement, 2 if the creature is attuned to it.
+
+	Armor    *foundryArmor `json:"armor,omitempty"`
+	Strength int           `json:"strength,omitempty"`
+	Stealth  bool          `json:"stealth,omitempty"`
+
+	Damage     *foundryDamage  `json:"damage,omitempty"`
+	Range      *foundryRange   `json:"range,omitempty"`
+	WeaponType string          `json:"weaponType,omitempty"` // Like simpleM or martialR.
+	Properties map[string]bool `json:"properties,omitempty"`
+
+	Level       *int                `json:"level,omitempty"`
+	Preparation *foundryPreparation `json:"preparation,omitempty"`
+
+	Levels      int `json:"levels,omitempty"`
+	HitDiceUsed int `json:"hitDiceUsed,omitempty"`
+
+	Activation *foundryActivation `json:"activation,omitempty"`
+}
+
+type foundryDescription struct {
+	Value string `json:"value"`
+}
+
+type foundryArmor struct {
+	Type  string `json:"type"`
+	Value int    `json:"value"`
+}
+
+type foundryDamage struct {
+	Parts     [][]string `json:"parts"`
+	Versatile string     `json:"versatile"`
+}
+
+type foundryRange struct {
+	Value int    `json:"value,omitempty"`
+	Long  int    `json:"long,omitempty"`
+	Units string `json:"units"`
+}
+
+type foundryPreparation struct {
+	Mode     string `json:"mode"`
+	Prepared bool   `json:"prepared"`
+}
+
+type foundryActivation struct {
+	Type string `json:"type"`
+	Cost int    `json:"cost,omitempty"`
+}
+
+const (
+	foundryCharacter = "character"
+	foundryNPC       = "npc"
+)
+
+// foundryAbilities maps the abilities to their abbreviations in Foundry VTT.
+var foundryAbilities = map[string]string{
+	abilities.Strength:     "str",
+	abilities.Dexterity:    "dex",
+	abilities.Constitution: "con",
+	abilities.Intelligence: "int",
+	abilities.Wisdom:       "wis",
+	abilities.Charisma:     "cha",
+}
+
+// foundrySkills maps the skills to their abbreviations in Foundry VTT.
+var foundrySkills = map[string]string{
+	skills.Acrobatics:     "acr",
+	skills.AnimalHandling: "ani",
+	skills.Arcana:         "arc",
+	skills.Athletics:      "ath",
+	skills.Deception:      "dec",
+	skills.History:        "his",
+	skills.Insight:        "ins",
+	skills.Intimidation:   "itm",
+	skills.Investigation:  "inv",
+	skills.Medicine:       "med",
+	skills.Nature:         "nat",
+	skills.Perception:     "prc",
+	skills.Performance:    "prf",
+	skills.Persuasion:     "per",
+	skills.Religion:       "rel",
+	skills.SleightOfHand:  "slt",
+	skills.Stealth:        "ste",
+	skills.Survival:       "sur",
+}
+
+// foundrySizes maps the sizes to their abbreviations in Foundry VTT.
+var foundrySizes = map[string]string{
+	"tiny":       "tiny",
+	"small":      "sm",
+	"medium":     "med",
+	"large":      "lg",
+	"huge":       "huge",
+	"gargantuan": "grg",
+}
+
+// foundryProperties maps the weapon properties to their abbreviations in
+// Foundry VTT. Ranged weapons are told apart by their weapon type instead.
+var foundryProperties = map[string]string{
+	weapons.Finesse:   "fin",
+	weapons.Versatile: "ver",
+	weapons.Thrown:    "thr",
+	weapons.Light:     "lgt",
+	weapons.Heavy:     "hvy",
+	weapons.TwoHanded: "two",
+	weapons.Reach:     "rch",
+	weapons.Loading:   "lod",
+}
+
+// foundrySenses are the senses Foundry VTT keeps the range of.
+var foundrySenses = []string{"blindsight", "darkvision", "tremorsense", "truesight"}
+
+// inverse returns the provided map with its keys and values swapped.
+func inverse(m map[string]string) map[string]string {
+	inv := make(map[string]string, len(m))
+	for k, v := range m {
+		inv[v] = k
+	}
+
+	return inv
+}
+
+// FoundryCreature turns the provided Foundry VTT actor JSON into a creature.
+func FoundryCreature(data []byte) (creature.Creature, error) {
+	var a Actor
+	if err := json.Unmarshal(data, &a); err != nil {
+		return creature.Creature{}, errInvalidJSON
+	}
+
+	return a.Creature()
+}
+
+// FoundryActor returns the provided creature as Foundry VTT actor JSON.
+func FoundryActor(c creature.Creature) ([]byte, error) {
+	return json.MarshalIndent(NewActor(c), "", "  ")
+}
+
+// Creature turns the actor into a creature. Characters become players and
+// NPCs become monsters.
+func (a Actor) Creature() (creature.Creature, error) {
+	if strings.TrimSpace(a.Name) == "" {
+		return creature.Creature{}, errMissingName
+	}
+
+	d := a.Data
+	c := creature.Creature{
+		Name:             a.Name,
+		Kind:             creature.Player,
+		CurrentHitPoints: d.Attributes.HP.Value,
+		MaximumHitPoints: d.Attributes.HP.Max,
+		HitPointsDice:    d.Attributes.HP.Formula,
+		ArmorClass:       d.Attributes.AC.Value,
+		Exhaustion:       d.Attributes.Exhaustion,
+		Size:             inverse(foundrySizes)[d.Traits.Size],
+		Speed:            foundryMovement(d.Attributes),
+		Senses:           foundrySensesText(d.Attributes.Senses),
+		Languages:        strings.Join(append(d.Traits.Languages.Value, splitList(d.Traits.Languages.Custom)...), ", "),
+		Inventory:        inventory.Inventory{Currency: d.Currency},
+		Defenses: damage.Defenses{
+			Resistances:         damageTypes(d.Traits.DR.Value),
+			Vulnerabilities:     damageTypes(d.Traits.DV.Value),
+			Immunities:          damageTypes(d.Traits.DI.Value),
+			ConditionImmunities: conditionNames(d.Traits.CI.Value),
+		},
+	}
+
+	if a.Type == foundryNPC {
+		cr := ""
+		if d.Details.CR != nil {
+			cr = challengeRating(*d.Details.CR)
+			if !creature.ValidChallengeRating(cr) {
+				return creature.Creature{}, errInvalidCR
+			}
+		}
+		c.Kind = creature.Monster
+		c.Level = 1
+		c.Defense = armor.Defense{Manual: true}
+		c.ChallengeRating = cr
+		c.ExperienceValue = d.Details.XP.Value
+		c.Type = strings.ToLower(string(d.Details.Type))
+		c.Alignment = d.Details.Alignment
+		c.ProficiencyBonus = creature.ProficiencyBonusForChallengeRating(cr)
+	} else {
+		c.ExperiencePoints = d.Details.XP.Value
+	}
+
+	abbreviations := inverse(foundryAbilities)
+	c.SavingThrows = saves.SavingThrows{}
+	for abbreviation, ability := range d.Abilities {
+		name, ok := abbreviations[abbreviation]
+		if !ok {
+			continue
+		}
+		score := ability.Value
+		if abilities.OutOfRange(score) {
+			score = 10
+		}
+		c.Abilities.Set(name, score)
+	}
+
+	spells := foundryItems(&c, a.Items)
+
+	if c.Kind == creature.Player {
+		c.Level = 0
+		for _, level := range c.Classes {
+			c.Level += level
+		}
+		if creature.OutOfRange(c.Level) {
+			c.Level = 1
+		}
+		c.ProficiencyBonus = creature.ProficiencyBonusPerLevel[c.Level]
+	}
+	if d.Attributes.Prof > 0 {
+		c.ProficiencyBonus = d.Attributes.Prof
+	}
+
+	for abbreviation, ability := range d.Abilities {
+		if name, ok := abbreviations[abbreviation]; ok && ability.Proficient > 0 {
+			c.SavingThrows[name] = c.Abilities.Modifier(name) + c.ProficiencyBonus
+		}
+	}
+
+	c.Skills = skills.Skills{}
+	skillNames := inverse(foundrySkills)
+	for abbreviation, skill := range d.Skills {
+		name, ok := skillNames[abbreviation]
+		if !ok || skill.Value <= 0 {
+			continue
+		}
+		c.Skills.Set(name, c.Abilities.Modifier(skills.SkillToAbility[name])+int(math.Floor(skill.Value*float64(c.ProficiencyBonus))))
+	}
+
+	c.PassivePerception = 10 + c.WisdomModifier
+	if s, ok := c.Skills[skills.Perception]; ok {
+		c.PassivePerception = 10 + s.Value
+	}
+	if p := d.Skills[foundrySkills[skills.Perception]].Passive; p > 0 {
+		c.PassivePerception = p
+	}
+
+	c.Resources = foundryResources(d.Resources)
+	c.Spellcasting = foundrySpellcasting(d, spells)
+
+	return c, nil
+}
+
+// foundryMovement returns the speeds of the provided attributes.
+func foundryMovement(attributes foundryAttributes) map[string]int {
+	if attributes.Movement == nil {
+		if attributes.Speed == nil {
+			return nil
+		}
+		return parseSpeed(attributes.Speed.Value)
+	}
+
+	speed := map[string]int{}
+	for _, mode := range speedModes {
+		if feet, ok := attributes.Movement[mode].(float64); ok && feet > 0 {
+			speed[mode] = int(feet)
+		}
+	}
+	if len(speed) == 0 {
+		return nil
+	}
+
+	return speed
+}
+
+// foundrySensesText returns the provided senses like "darkvision 60 ft.",
+// followed by the special ones.
+func foundrySensesText(senses map[string]interface{}) string {
+	var s []string
+	for _, sense := range foundrySenses {
+		if feet, ok := senses[sense].(float64); ok && feet > 0 {
+			s = append(s, sense+" "+strconv.Itoa(int(feet))+" ft.")
+		}
+	}
+	if special, ok := senses["special"].(string); ok && special != "" {
+		s = append(s, special)
+	}
+
+	return strings.Join(s, ", ")
+}
+
+// foundryItems stores the classes, features and inventory of the provided
+// items in the creature. It returns the spells, which are stored along with
+// the spell slots.
+func foundryItems(c *creature.Creature, items []actorItem) (spells []actorItem) {
+	for _, item := range items {
+		description := ""
+		if item.Data.Description != nil {
+			description = plainText(item.Data.Description.Value)
+		}
+
+		switch item.Type {
+		case "class":
+			class := strings.ToLower(item.Name)
+			if !classes.Valid(class) {
+				continue
+			}
+			if c.Classes == nil {
+				c.Classes = classes.Classes{}
+				c.HitDice = map[string]int{}
+			}
+			c.Classes[class] = item.Data.Levels
+			c.HitDice[class] = item.Data.Levels - item.Data.HitDiceUsed
+		case "spell":
+			spells = append(spells, item)
+		case "feat":
+			action := creature.Action{Name: item.Name, Description: description}
+			activation := ""
+			if item.Data.Activation != nil {
+				activation = item.Data.Activation.Type
+				if item.Data.Activation.Cost > 1 {
+					action.Cost = item.Data.Activation.Cost
+				}
+			}
+			switch {
+			case activation == "action" && strings.EqualFold(item.Name, "Multiattack"):
+				c.Multiattack = description
+			case activation == "action":
+				c.Actions = append(c.Actions, creature.Action{Name: action.Name, Description: action.Description})
+			case activation == "reaction":
+				c.Reactions = append(c.Reactions, creature.Action{Name: action.Name, Description: action.Description})
+			case activation == "legendary":
+				c.LegendaryActions = append(c.LegendaryActions, action)
+			default:
+				c.Traits = append(c.Traits, creature.Action{Name: action.Name, Description: action.Description})
+			}
+		case "weapon", "equipment", "consumable", "tool", "loot", "backpack":
+			c.Items = append(c.Items, foundryInventoryItem(item))
+		}
+	}
+
+	return spells
+}
+
+// foundryInventoryItem turns the provided item into an item of the inventory.
+func foundryInventoryItem(item actorItem) inventory.Item {
+	d := item.Data
+	i := inventory.Item{
+		Name:               item.Name,
+		Weight:             d.Weight,
+		Quantity:           d.Quantity,
+		Value:              int(math.Round(d.Price * float64(inventory.CopperPerCoin[inventory.Gold]))),
+		Equipped:           d.Equipped,
+		RequiresAttunement: d.Attunement > 0,
+		Attuned:            d.Attunement == 2,
+	}
+
+	if d.Armor != nil && armor.ValidType(d.Armor.Type) {
+		i.Armor = &armor.Armor{
+			Type:                d.Armor.Type,
+			BaseClass:           d.Armor.Value,
+			StrengthRequirement: d.Strength,
+			StealthDisadvantage: d.Stealth,
+		}
+	}
+
+	if item.Type == "weapon" {
+		w := &weapons.Weapon{Category: weapons.Simple}
+		if strings.HasPrefix(d.WeaponType, weapons.Martial) {
+			w.Category = weapons.Martial
+		}
+		if d.Damage != nil {
+			if len(d.Damage.Parts) > 0 && len(d.Damage.Parts[0]) == 2 {
+				w.Damage = foundryDice(d.Damage.Parts[0][0])
+				w.DamageType = d.Damage.Parts[0][1]
+			}
+			w.VersatileDamage = foundryDice(d.Damage.Versatile)
+		}
+
+		properties := inverse(foundryProperties)
+		for abbreviation, ok := range d.Properties {
+			if p, known := properties[abbreviation]; known && ok {
+				w.Properties = append(w.Properties, p)
+			}
+		}
+		if strings.HasSuffix(d.WeaponType, "R") {
+			w.Properties = append(w.Properties, weapons.Ranged)
+		}
+		sort.Strings(w.Properties)
+
+		if d.Range != nil && d.Range.Value > 0 && d.Range.Units == "ft" {
+			w.Range = strconv.Itoa(d.Range.Value)
+			if d.Range.Long > 0 {
+				w.Range += "/" + strconv.Itoa(d.Range.Long)
+			}
+		}
+		i.Weapon = w
+	}
+
+	return i
+}
+
+// foundryDice returns the dice of the provided Foundry VTT formula, without
+// the modifier it adds, like 1d8 for "1d8 + @mod".
+func foundryDice(formula string) string {
+	return strings.TrimSpace(strings.Split(formula, "+ @mod")[0])
+}
+
+// foundryResources returns the resources of the provided Foundry VTT ones.
+func foundryResources(resources map[string]foundryResource) map[string]*creature.Resource {
+	rs := map[string]*creature.Resource{}
+	for _, r := range resources {
+		if r.Label == "" {
+			continue
+		}
+		recharge := ""
+		switch {
+		case r.SR:
+			recharge = creature.ShortRest
+		case r.LR:
+			recharge = creature.LongRest
+		}
+		rs[resourceName(r.Label)] = &creature.Resource{
+			Current:  r.Value,
+			Maximum:  r.Max,
+			Recharge: recharge,
+		}
+	}
+	if len(rs) == 0 {
+		return nil
+	}
+
+	return rs
+}
+
+// foundrySpellcasting returns the spellcasting of the provided actor data and
+// spells, if it has any.
+func foundrySpellcasting(d actorData, spells []actorItem) *spellcasting.Spellcasting {
+	s := &spellcasting.Spellcasting{
+		Ability: inverse(foundryAbilities)[d.Attributes.Spellcasting],
+	}
+	for level := 1; level <= 9; level++ {
+		slots, ok := d.Spells["spell"+strconv.Itoa(level)]
+		if ok && slots.Max > 0 {
+			s.Slots = append(s.Slots, spellcasting.Slots{Level: level, Maximum: slots.Max, Expended: slots.Max - slots.Value})
+		}
+	}
+	if pact, ok := d.Spells["pact"]; ok && pact.Max > 0 {
+		s.PactSlots = &spellcasting.Slots{Level: pact.Level, Maximum: pact.Max, Expended: pact.Max - pact.Value}
+	}
+	for _, spell := range spells {
+		s.Known = append(s.Known, spell.Name)
+		if p := spell.Data.Preparation; p != nil && (p.Prepared || p.Mode == "always") {
+			s.Prepared = append(s.Prepared, spell.Name)
+		}
+	}
+
+	if s.Ability == "" && s.Slots == nil && s.PactSlots == nil && s.Known == nil {
+		return nil
+	}
+
+	return s
+}
+
+// NewActor returns the provided creature as a Foundry VTT actor. Players
+// become characters, while monsters and NPCs become NPCs. Creatures without
+// an explicit armor class get theirs from the armor they wear in Foundry VTT
+// too, so the armor class is only a starting point.
+func NewActor(c creature.Creature) Actor {
+	a := Actor{
+		Name:  c.Name,
+		Type:  foundryCharacter,
+		Items: []actorItem{},
+	}
+
+	d := &a.Data
+	d.Abilities = map[string]foundryAbility{}
+	for name, abbreviation := range foundryAbilities {
+		_, proficient := c.SavingThrows[name]
+		d.Abilities[abbreviation] = foundryAbility{Value: c.Abilities.Score(name), Proficient: boolNumber(proficient)}
+	}
+
+	d.Attributes.AC.Value = c.ArmorClass
+	d.Attributes.HP.Value = c.CurrentHitPoints
+	d.Attributes.HP.Max = c.MaximumHitPoints
+	d.Attributes.HP.Formula = c.HitPointsDice
+	d.Attributes.Prof = c.ProficiencyBonus
+	d.Attributes.Exhaustion = c.Exhaustion
+	d.Attributes.Movement = map[string]interface{}{"units": "ft", "hover": false}
+	for _, mode := range speedModes {
+		d.Attributes.Movement[mode] = c.Speed[mode]
+	}
+	d.Attributes.Senses = map[string]interface{}{"units": "ft", "special": c.Senses}
+	for _, sense := range foundrySenses {
+		d.Attributes.Senses[sense] = 0
+	}
+
+	if c.Kind == creature.Monster || c.Kind == creature.NPC {
+		a.Type = foundryNPC
+		cr := challengeRatingNumber(c.ChallengeRating)
+		d.Details.CR = &cr
+		d.Details.XP.Value = c.ExperienceValue
+		d.Details.Type = foundryType(c.Type)
+		d.Details.Alignment = c.Alignment
+	} else {
+		d.Details.XP.Value = c.ExperiencePoints
+	}
+
+	d.Traits = foundryTraits{
+		Size:      foundrySizes[c.Size],
+		DI:        foundryTrait{Value: nonNil(c.Immunities)},
+		DR:        foundryTrait{Value: nonNil(c.Resistances)},
+		DV:        foundryTrait{Value: nonNil(c.Vulnerabilities)},
+		CI:        foundryTrait{Value: nonNil(c.ConditionImmunities)},
+		Languages: foundryTrait{Value: []string{}, Custom: c.Languages},
+	}
+	d.Currency = c.Currency
+
+	d.Skills = map[string]foundrySkill{}
+	for name, abbreviation := range foundrySkills {
+		ability := skills.SkillToAbility[name]
+		modifier := c.Abilities.Modifier(ability)
+
+		skill := foundrySkill{Ability: foundryAbilities[ability], Passive: 10 + modifier}
+		if s, ok := c.Skills[name]; ok && s != nil {
+			skill.Value = 1
+			if c.ProficiencyBonus > 0 && s.Value-modifier >= 2*c.ProficiencyBonus {
+				skill.Value = 2
+			}
+			skill.Passive = 10 + s.Value
+		}
+		if name == skills.Perception {
+			skill.Passive = c.PassivePerception
+		}
+		d.Skills[abbreviation] = skill
+	}
+
+	names := resourceNames(c)
+	if len(names) > 0 {
+		d.Resources = map[string]foundryResource{}
+	}
+	for i, key := range []string{"primary", "secondary", "tertiary"} {
+		if i >= len(names) {
+			break
+		}
+		r := c.Resources[names[i]]
+		d.Resources[key] = foundryResource{
+			Value: r.Current,
+			Max:   r.Maximum,
+			SR:    r.Recharge == creature.ShortRest,
+			LR:    r.Recharge == creature.ShortRest || r.Recharge == creature.LongRest,
+			Label: resourceLabel(names[i]),
+		}
+	}
+
+	classNames := make([]string, 0, len(c.Classes))
+	for class := range c.Classes {
+		classNames = append(classNames, class)
+	}
+	sort.Strings(classNames)
+	for _, class := range classNames {
+		levels := c.Classes[class]
+		used := 0
+		if unspent, ok := c.HitDice[class]; ok {
+			used = levels - unspent
+		}
+		a.Items = append(a.Items, actorItem{
+			Name: resourceLabel(class),
+			Type: "class",
+			Data: itemData{Levels: levels, HitDiceUsed: used},
+		})
+	}
+
+	feat := func(activation string, as []creature.Action) {
+		for _, action := range as {
+			item := actorItem{
+				Name: action.Name,
+				Type: "feat",
+				Data: itemData{Description: &foundryDescription{htmlText(action.Description)}},
+			}
+			if activation != "" {
+				item.Data.Activation = &foundryActivation{Type: activation, Cost: 1}
+				if action.Cost > 1 {
+					item.Data.Activation.Cost = action.Cost
+				}
+			}
+			a.Items = append(a.Items, item)
+		}
+	}
+	feat("", c.Traits)
+	if c.Multiattack != "" {
+		feat("action", []creature.Action{{Name: "Multiattack", Description: c.Multiattack}})
+	}
+	feat("action", c.Actions)
+	feat("reaction", c.Reactions)
+	feat("legendary", c.LegendaryActions)
+
+	for _, item := range c.Items {
+		a.Items = append(a.Items, foundryItem(item))
+	}
+
+	if c.Spellcasting != nil {
+		foundrySpells(&a, c.Spellcasting)
+	}
+
+	return a
+}
+
+// foundryItem returns the provided item of the inventory as a Foundry VTT item.
+func foundryItem(i inventory.Item) actorItem {
+	item := actorItem{
+		Name: i.Name,
+		Type: "loot",
+		Data: itemData{
+			Quantity: i.Quantity,
+			Weight:   i.Weight,
+			Price:    float64(i.Value) / float64(inventory.CopperPerCoin[inventory.Gold]),
+			Equipped: i.Equipped,
+		},
+	}
+	switch {
+	case i.Attuned:
+		item.Data.Attunement = 2
+	case i.RequiresAttunement:
+		item.Data.Attunement = 1
+	}
+
+	if i.Armor != nil {
+		item.Type = "equipment"
+		item.Data.Armor = &foundryArmor{Type: i.Armor.Type, Value: i.Armor.BaseClass}
+		item.Data.Strength = i.Armor.StrengthRequirement
+		item.Data.Stealth = i.Armor.StealthDisadvantage
+	}
+
+	if w := i.Weapon; w != nil {
+		item.Type = "weapon"
+
+		category := weapons.Simple
+		if w.Category == weapons.Martial {
+			category = weapons.Martial
+		}
+		item.Data.WeaponType = category + "M"
+		if w.Has(weapons.Ranged) {
+			item.Data.WeaponType = category + "R"
+		}
+
+		item.Data.Damage = &foundryDamage{Parts: [][]string{}}
+		if w.Damage != "" {
+			item.Data.Damage.Parts = append(item.Data.Damage.Parts, []string{w.Damage + " + @mod", w.DamageType})
+		}
+		if w.VersatileDamage != "" {
+			item.Data.Damage.Versatile = w.VersatileDamage + " + @mod"
+		}
+
+		item.Data.Properties = map[string]bool{}
+		for property, abbreviation := range foundryProperties {
+			item.Data.Properties[abbreviation] = w.Has(property)
+		}
+
+		item.Data.Range = &foundryRange{Units: "ft"}
+		if w.Range != "" {
+			r := strings.SplitN(w.Range, "/", 2)
+			item.Data.Range.Value, _ = strconv.Atoi(r[0])
+			if len(r) == 2 {
+				item.Data.Range.Long, _ = strconv.Atoi(r[1])
+			}
+		}
+	}
+
+	return item
+}
+
+// foundrySpells adds the provided spellcasting to the actor.
+func foundrySpells(a *Actor, s *spellcasting.Spellcasting) {
+	a.Data.Attributes.Spellcasting = foundryAbilities[s.Ability]
+
+	a.Data.Spells = map[string]foundrySlots{}
+	for _, slots := range s.Slots {
+		a.Data.Spells["spell"+strconv.Itoa(slots.Level)] = foundrySlots{Value: slots.Maximum - slots.Expended, Max: slots.Maximum}
+	}
+	if p := s.PactSlots; p != nil {
+		a.Data.Spells["pact"] = foundrySlots{Value: p.Maximum - p.Expended, Max: p.Maximum, Level: p.Level}
+	}
+
+	prepared := map[string]bool{}
+	for _, name := range s.Prepared {
+		prepared[name] = true
+	}
+	known := map[string]bool{}
+	spell := func(name string) {
+		if known[name] {
+			return
+		}
+		known[name] = true
+
+		level := spellLevel(name)
+		a.Items = append(a.Items, actorItem{
+			Name: name,
+			Type: "spell",
+			Data: itemData{
+				Level:       &level,
+				Preparation: &foundryPreparation{Mode: "prepared", Prepared: prepared[name]},
+			},
+		})
+	}
+	for _, name := range s.Known {
+		spell(name)
+	}
+	for _, name := range s.Prepared {
+		spell(name)
+	}
+}
+
+// boolNumber returns 1 for true and 0 for false.
+func boolNumber(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// nonNil returns the provided values, or an empty slice if there are none.
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
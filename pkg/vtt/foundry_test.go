@@ -0,0 +1,251 @@
+package vtt
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aakordas/creature_manager/pkg/abilities"
+	"github.com/aakordas/creature_manager/pkg/armor"
+	"github.com/aakordas/creature_manager/pkg/classes"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/damage"
+	"github.com/aakordas/creature_manager/pkg/inventory"
+	"github.com/aakordas/creature_manager/pkg/saves"
+	"github.com/aakordas/creature_manager/pkg/skills"
+	"github.com/aakordas/creature_manager/pkg/spellcasting"
+	"github.com/aakordas/creature_manager/pkg/weapons"
+)
+
+// sample returns the contents of the provided file of the testdata.
+func sample(t *testing.T, name string) []byte {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+// wizard returns a creature that uses most of what the converters support and
+// loses nothing on the way.
+func wizard() creature.Creature {
+	c := creature.Creature{
+		Name:             "Elminster",
+		Kind:             creature.Player,
+		CurrentHitPoints: 30,
+		MaximumHitPoints: 38,
+		Level:            5,
+		ExperiencePoints: 6500,
+		Classes:          classes.Classes{"wizard": 5},
+		HitDice:          map[string]int{"wizard": 3},
+		ProficiencyBonus: 3,
+		ArmorClass:       12,
+		SavingThrows:     saves.SavingThrows{abilities.Intelligence: 7, abilities.Wisdom: 4},
+		Skills:           skills.Skills{},
+		Spellcasting: &spellcasting.Spellcasting{
+			Ability:  abilities.Intelligence,
+			Slots:    []spellcasting.Slots{{Level: 1, Maximum: 4, Expended: 1}, {Level: 2, Maximum: 3}},
+			Known:    []string{"Fire Bolt", "Magic Missile", "Shield"},
+			Prepared: []string{"Fire Bolt", "Magic Missile"},
+		},
+		Inventory: inventory.Inventory{
+			Currency: inventory.Currency{Gold: 120, Silver: 4},
+			Items: []inventory.Item{
+				{Name: "Quarterstaff", Quantity: 1, Weight: 4, Equipped: true, Weapon: &weapons.Weapon{
+					Category:        weapons.Simple,
+					Damage:          "1d6",
+					DamageType:      damage.Bludgeoning,
+					VersatileDamage: "1d8",
+					Properties:      []string{weapons.Versatile},
+				}},
+				{Name: "Spellbook", Quantity: 1, Weight: 3},
+			},
+		},
+		Speed:  map[string]int{"walk": 30},
+		Traits: []creature.Action{{Name: "Arcane Recovery", Description: "Recover spell slots on a short rest."}},
+	}
+	for name, score := range map[string]int{
+		abilities.Strength:     9,
+		abilities.Dexterity:    14,
+		abilities.Constitution: 12,
+		abilities.Intelligence: 18,
+		abilities.Wisdom:       12,
+		abilities.Charisma:     10,
+	} {
+		c.Abilities.Set(name, score)
+	}
+	c.Skills.Set(skills.Arcana, 10)
+	c.Skills.Set(skills.History, 7)
+	c.PassivePerception = 11
+
+	return c
+}
+
+func TestFoundryCreature_Character(t *testing.T) {
+	c, err := FoundryCreature(sample(t, "foundry_character.json"))
+	if err != nil {
+		t.Fatalf("FoundryCreature() error = %v", err)
+	}
+
+	if c.Name != "Merry" || c.Kind != creature.Player || c.Size != "small" {
+		t.Errorf("Unexpected description: %v, %v, %v", c.Name, c.Kind, c.Size)
+	}
+	if c.CurrentHitPoints != 20 || c.MaximumHitPoints != 24 || c.ArmorClass != 14 {
+		t.Errorf("Unexpected hit points and armor class: %v/%v, %v", c.CurrentHitPoints, c.MaximumHitPoints, c.ArmorClass)
+	}
+	if !reflect.DeepEqual(c.Classes, classes.Classes{"rogue": 2, "wizard": 1}) || c.Level != 3 || c.ProficiencyBonus != 2 {
+		t.Errorf("Unexpected classes: %v, level %v, +%v", c.Classes, c.Level, c.ProficiencyBonus)
+	}
+	if !reflect.DeepEqual(c.HitDice, map[string]int{"rogue": 1, "wizard": 1}) {
+		t.Errorf("Unexpected hit dice: %v", c.HitDice)
+	}
+	want := saves.SavingThrows{abilities.Dexterity: 5, abilities.Intelligence: 4}
+	if !reflect.DeepEqual(c.SavingThrows, want) {
+		t.Errorf("Unexpected saving throws: %v, want %v", c.SavingThrows, want)
+	}
+	if s := c.Skills[skills.Stealth]; s == nil || s.Value != 7 {
+		t.Errorf("Unexpected stealth: %v", s)
+	}
+	if len(c.Skills) != 3 || c.PassivePerception != 13 {
+		t.Errorf("Unexpected skills: %v, passive perception %v", c.Skills, c.PassivePerception)
+	}
+	if c.Languages != "common, Halfling" || !reflect.DeepEqual(c.Speed, map[string]int{"walk": 25}) {
+		t.Errorf("Unexpected languages and speed: %q, %v", c.Languages, c.Speed)
+	}
+	if len(c.Traits) != 2 || c.Traits[0].Description != "Once per turn, you can deal an extra 1d6 damage." {
+		t.Errorf("Unexpected traits: %v", c.Traits)
+	}
+	if r := c.Resources["arcane_recovery"]; r == nil || r.Maximum != 1 || r.Recharge != creature.LongRest {
+		t.Errorf("Unexpected resources: %v", c.Resources)
+	}
+
+	if len(c.Items) != 5 || c.Currency.Gold != 15 || c.Currency.Silver != 3 {
+		t.Fatalf("Unexpected inventory: %v, %v", c.Items, c.Currency)
+	}
+	dagger := c.Items[0]
+	if dagger.Quantity != 2 || dagger.Value != 200 || dagger.Weapon == nil {
+		t.Fatalf("Unexpected dagger: %+v", dagger)
+	}
+	properties := []string{weapons.Finesse, weapons.Light, weapons.Thrown}
+	if !reflect.DeepEqual(dagger.Weapon.Properties, properties) || dagger.Weapon.Damage != "1d4" || dagger.Weapon.Range != "20/60" {
+		t.Errorf("Unexpected dagger: %+v", dagger.Weapon)
+	}
+	if bow := c.Items[1].Weapon; bow == nil || !bow.Has(weapons.Ranged) {
+		t.Errorf("Unexpected shortbow: %+v", bow)
+	}
+	if a := c.Items[2].Armor; a == nil || a.Type != armor.Light || a.BaseClass != 11 {
+		t.Errorf("Unexpected leather armor: %+v", a)
+	}
+	if cloak := c.Items[3]; !cloak.Attuned || !cloak.RequiresAttunement || cloak.Armor != nil {
+		t.Errorf("Unexpected cloak: %+v", cloak)
+	}
+
+	s := c.Spellcasting
+	if s == nil {
+		t.Fatal("Expected spellcasting")
+	}
+	if s.Ability != abilities.Intelligence || !reflect.DeepEqual(s.Slots, []spellcasting.Slots{{Level: 1, Maximum: 2, Expended: 1}}) {
+		t.Errorf("Unexpected spellcasting: %v, %v", s.Ability, s.Slots)
+	}
+	if len(s.Known) != 3 || !reflect.DeepEqual(s.Prepared, []string{"Fire Bolt", "Magic Missile"}) {
+		t.Errorf("Unexpected spells: %v, prepared %v", s.Known, s.Prepared)
+	}
+}
+
+func TestFoundryCreature_NPC(t *testing.T) {
+	c, err := FoundryCreature(sample(t, "foundry_npc.json"))
+	if err != nil {
+		t.Fatalf("FoundryCreature() error = %v", err)
+	}
+
+	if c.Kind != creature.Monster || c.Type != "dragon" || c.Size != "large" || c.Alignment != "chaotic evil" {
+		t.Errorf("Unexpected description: %v, %v, %v, %v", c.Kind, c.Type, c.Size, c.Alignment)
+	}
+	if c.ChallengeRating != "10" || c.ExperienceValue != 5900 || c.ProficiencyBonus != 4 {
+		t.Errorf("Unexpected challenge: %v, %v XP, +%v", c.ChallengeRating, c.ExperienceValue, c.ProficiencyBonus)
+	}
+	if c.ArmorClass != 18 || !c.Defense.Manual || c.HitPointsDice != "17d10 + 85" {
+		t.Errorf("Unexpected armor class and hit dice: %v, manual %v, %v", c.ArmorClass, c.Defense.Manual, c.HitPointsDice)
+	}
+	if c.SavingThrows[abilities.Constitution] != 9 || len(c.SavingThrows) != 4 {
+		t.Errorf("Unexpected saving throws: %v", c.SavingThrows)
+	}
+	if s := c.Skills[skills.Perception]; s == nil || s.Value != 8 || c.PassivePerception != 18 {
+		t.Errorf("Unexpected perception: %v, passive %v", s, c.PassivePerception)
+	}
+	if !reflect.DeepEqual(c.Speed, map[string]int{"walk": 40, "climb": 40, "fly": 80}) {
+		t.Errorf("Unexpected speed: %v", c.Speed)
+	}
+	if c.Senses != "blindsight 30 ft., darkvision 120 ft." || !reflect.DeepEqual(c.Immunities, []string{damage.Fire}) {
+		t.Errorf("Unexpected senses and immunities: %q, %v", c.Senses, c.Immunities)
+	}
+	if c.Multiattack == "" || len(c.Actions) != 2 || len(c.LegendaryActions) != 1 || c.LegendaryActions[0].Cost != 2 {
+		t.Errorf("Unexpected actions: %v, multiattack %q, legendary %v", c.Actions, c.Multiattack, c.LegendaryActions)
+	}
+	if d := c.Actions[1].Description; d != "The dragon exhales fire in a 30-foot cone.\nEach creature in that area must make a DC 17 Dexterity saving throw." {
+		t.Errorf("Unexpected description: %q", d)
+	}
+}
+
+func TestFoundryCreature_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"Empty", ""},
+		{"Not JSON", "dragon"},
+		{"No name", `{"type": "npc"}`},
+		{"Invalid challenge rating", `{"name": "Dragon", "type": "npc", "data": {"details": {"cr": 0.3}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FoundryCreature([]byte(tt.data)); err == nil {
+				t.Errorf("FoundryCreature() expected an error")
+			}
+		})
+	}
+}
+
+func TestFoundryActor_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"Character", "foundry_character.json"},
+		{"NPC", "foundry_npc.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := FoundryCreature(sample(t, tt.file))
+			if err != nil {
+				t.Fatalf("FoundryCreature() error = %v", err)
+			}
+			data, err := FoundryActor(want)
+			if err != nil {
+				t.Fatalf("FoundryActor() error = %v", err)
+			}
+			got, err := FoundryCreature(data)
+			if err != nil {
+				t.Fatalf("FoundryCreature() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("FoundryCreature() = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	want := wizard()
+	data, err := FoundryActor(want)
+	if err != nil {
+		t.Fatalf("FoundryActor() error = %v", err)
+	}
+	got, err := FoundryCreature(data)
+	if err != nil {
+		t.Fatalf("FoundryCreature() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FoundryCreature() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,893 @@
+package vtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/abilities"
+	"github.com/aakordas/creature_manager/pkg/armor"
+	"github.com/aakordas/creature_manager/pkg/classes"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/damage"
+	"github.com/aakordas/creature_manager/pkg/inventory"
+	"github.com/aakordas/creature_manager/pkg/saves"
+	"github.com/aakordas/creature_manager/pkg/skills"
+	"github.com/aakordas/creature_manager/pkg/spellcasting"
+	"github.com/aakordas/creature_manager/pkg/weapons"
+)
+
+// Character models a character of the D&D 5E by Roll20 sheet, as it gets
+// exported to JSON. The sheet keeps everything as attributes, with the lists,
+// like the inventory, in repeating sections whose attributes are named like
+// repeating_inventory_{row}_itemname.
+//
+// Roll20 keeps no value for items, no recharge for resources, no hit dice per
+// class and no senses or languages for characters, so these get lost.
+type Character struct {
+	SchemaVersion int           `json:"schema_version"`
+	Name          string        `json:"name"`
+	Attribs       []Attribute   `json:"attribs"`
+	Abilities     []interface{} `json:"abilities"` // The macros of the character, which are not converted.
+}
+
+// Attribute is a single attribute of a Roll20 character.
+type Attribute struct {
+	Name    string      `json:"name"`
+	Current roll20Value `json:"current"`
+	Max     roll20Value `json:"max"`
+	ID      string      `json:"id"`
+}
+
+// roll20Value is the value of an attribute, which Roll20 stores either as a
+// string or as a number.
+type roll20Value string
+
+// UnmarshalJSON implements the json.Unmarshaler interface for roll20Value.
+func (v *roll20Value) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*v = roll20Value(s)
+		return nil
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	switch raw := raw.(type) {
+	case float64:
+		*v = roll20Value(strconv.FormatFloat(raw, 'f', -1, 64))
+	case bool:
+		*v = "0"
+		if raw {
+			*v = "1"
+		}
+	default:
+		*v = ""
+	}
+
+	return nil
+}
+
+// roll20Sections maps the repeating sections that hold the lists of a creature
+// to the fields of their rows. The spells are kept in a section per level,
+// like spell-cantrip or spell-1.
+var roll20Sections = map[string][]string{
+	"inventory":   {"itemname", "itemcount", "itemweight", "equipped", "itemmodifiers"},
+	"traits":      {"name", "description"},
+	"npctrait":    {"name", "description"},
+	"npcaction":   {"name", "description"},
+	"npcreaction": {"name", "description"},
+	"npcaction-l": {"name", "description"},
+	"spell":       {"spellname", "spellprepared"},
+}
+
+// roll20Fields returns the fields of the rows of the provided section.
+func roll20Fields(section string) []string {
+	if strings.HasPrefix(section, "spell-") {
+		section = "spell"
+	}
+
+	return roll20Sections[section]
+}
+
+// roll20SpellSection returns the repeating section of the spells of the
+// provided level.
+func roll20SpellSection(level int) string {
+	if level == 0 {
+		return "spell-cantrip"
+	}
+
+	return "spell-" + strconv.Itoa(level)
+}
+
+// roll20Abbreviations maps the abilities to the abbreviations of the sheet.
+var roll20Abbreviations = map[string]string{
+	abilities.Strength:     "str",
+	abilities.Dexterity:    "dex",
+	abilities.Constitution: "con",
+	abilities.Intelligence: "int",
+	abilities.Wisdom:       "wis",
+	abilities.Charisma:     "cha",
+}
+
+// roll20Row is a row of a repeating section.
+type roll20Row map[string]string
+
+// roll20Sheet holds the attributes of a character, with the repeating ones
+// grouped into the rows of their section.
+type roll20Sheet struct {
+	attributes map[string]Attribute
+	sections   map[string][]roll20Row
+}
+
+// newRoll20Sheet groups the provided attributes, keeping the rows of each
+// section in the order they first appear.
+func newRoll20Sheet(attributes []Attribute) roll20Sheet {
+	s := roll20Sheet{
+		attributes: map[string]Attribute{},
+		sections:   map[string][]roll20Row{},
+	}
+	rows := map[string]roll20Row{}
+
+	for _, a := range attributes {
+		if !strings.HasPrefix(a.Name, "repeating_") {
+			if _, ok := s.attributes[a.Name]; !ok {
+				s.attributes[a.Name] = a
+			}
+			continue
+		}
+
+		rest := strings.TrimPrefix(a.Name, "repeating_")
+		i := strings.Index(rest, "_")
+		if i < 0 {
+			continue
+		}
+		section, rest := rest[:i], rest[i+1:]
+		for _, field := range roll20Fields(section) {
+			if !strings.HasSuffix(rest, "_"+field) {
+				continue
+			}
+			id := section + "_" + strings.TrimSuffix(rest, "_"+field)
+			row, ok := rows[id]
+			if !ok {
+				row = roll20Row{}
+				rows[id] = row
+				s.sections[section] = append(s.sections[section], row)
+			}
+			row[field] = string(a.Current)
+			break
+		}
+	}
+
+	return s
+}
+
+// get returns the current value of the provided attribute.
+func (s roll20Sheet) get(name string) string {
+	return strings.TrimSpace(string(s.attributes[name].Current))
+}
+
+// max returns the maximum value of the provided attribute.
+func (s roll20Sheet) max(name string) string {
+	return strings.TrimSpace(string(s.attributes[name].Max))
+}
+
+// number returns the current value of the provided attribute as a number, or
+// 0 if it is not one.
+func (s roll20Sheet) number(name string) int {
+	return roll20Number(s.get(name))
+}
+
+// flag checks whether the provided attribute is set, like the proficiency in
+// a skill, which is either 0 or a formula.
+func (s roll20Sheet) flag(name string) bool {
+	v := s.get(name)
+	return v != "" && v != "0"
+}
+
+// roll20Number returns the provided value as a number, or 0 if it is not one.
+func roll20Number(v string) int {
+	f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		return 0
+	}
+
+	return int(f)
+}
+
+// roll20Ability matches the ability of the spellcasting ability formula, like
+// @{intelligence_mod}+.
+var roll20Ability = regexp.MustCompile(`@\{([a-z]+)_mod\}`)
+
+// roll20Cost matches the cost of a legendary action in its name, like "Wing
+// Attack (Costs 2 Actions)".
+var roll20Cost = regexp.MustCompile(`^(.*?) \(Costs (\d+) Actions\)$`)
+
+// roll20Type matches the size, type and alignment of an NPC, like "Small
+// humanoid (goblinoid), neutral evil".
+var roll20Type = regexp.MustCompile(`(?i)^(tiny|small|medium|large|huge|gargantuan)?\s*([^,]*?)(?:,\s*(.*))?$`)
+
+// Roll20Creature turns the provided Roll20 character JSON into a creature.
+func Roll20Creature(data []byte) (creature.Creature, error) {
+	var ch Character
+	if err := json.Unmarshal(data, &ch); err != nil {
+		return creature.Creature{}, errInvalidJSON
+	}
+
+	return ch.Creature()
+}
+
+// Roll20Character returns the provided creature as Roll20 character JSON.
+func Roll20Character(c creature.Creature) ([]byte, error) {
+	return json.MarshalIndent(NewCharacter(c), "", "  ")
+}
+
+// Creature turns the character into a creature. Characters of the NPC sheet
+// become monsters and the rest become players.
+func (ch Character) Creature() (creature.Creature, error) {
+	if strings.TrimSpace(ch.Name) == "" {
+		return creature.Creature{}, errMissingName
+	}
+
+	s := newRoll20Sheet(ch.Attribs)
+	npc := s.flag("npc")
+
+	c := creature.Creature{
+		Name:              ch.Name,
+		Kind:              creature.Player,
+		CurrentHitPoints:  s.number("hp"),
+		MaximumHitPoints:  roll20Number(s.max("hp")),
+		ArmorClass:        s.number("ac"),
+		PassivePerception: s.number("passive_wisdom"),
+		Speed:             parseSpeed(s.get("speed")),
+		Inventory: inventory.Inventory{Currency: inventory.Currency{
+			Copper:   s.number("cp"),
+			Silver:   s.number("sp"),
+			Electrum: s.number("ep"),
+			Gold:     s.number("gp"),
+			Platinum: s.number("pp"),
+		}},
+	}
+
+	for ability := range roll20Abbreviations {
+		score := s.number(ability)
+		if abilities.OutOfRange(score) {
+			score = 10
+		}
+		c.Abilities.Set(ability, score)
+	}
+
+	if npc {
+		cr := s.get("npc_challenge")
+		if cr != "" && !creature.ValidChallengeRating(cr) {
+			return creature.Creature{}, errInvalidCR
+		}
+
+		c.Kind = creature.Monster
+		c.Level = 1
+		c.ArmorClass = s.number("npc_ac")
+		c.Defense = armor.Defense{Manual: true}
+		c.ChallengeRating = cr
+		c.ExperienceValue = s.number("npc_xp")
+		c.ProficiencyBonus = creature.ProficiencyBonusForChallengeRating(cr)
+		c.HitPointsDice = strings.ReplaceAll(s.get("npc_hpformula"), " ", "")
+		c.Speed = parseSpeed(s.get("npc_speed"))
+		c.Senses = s.get("npc_senses")
+		c.Languages = s.get("npc_languages")
+		c.Defenses = damage.Defenses{
+			Resistances:         damageTypes(splitList(s.get("npc_resistances"))),
+			Vulnerabilities:     damageTypes(splitList(s.get("npc_vulnerabilities"))),
+			Immunities:          damageTypes(splitList(s.get("npc_immunities"))),
+			ConditionImmunities: conditionNames(splitList(s.get("npc_condition_immunities"))),
+		}
+		if m := roll20Type.FindStringSubmatch(strings.TrimSpace(s.get("npc_type"))); m != nil {
+			c.Size = strings.ToLower(m[1])
+			c.Type = strings.ToLower(strings.TrimSpace(m[2]))
+			c.Alignment = strings.TrimSpace(m[3])
+		}
+	} else {
+		c.ExperiencePoints = s.number("experience")
+		roll20Classes(&c, s)
+	}
+	if pb := s.number("pb"); pb > 0 {
+		c.ProficiencyBonus = pb
+	}
+
+	c.SavingThrows = saves.SavingThrows{}
+	for ability, abbreviation := range roll20Abbreviations {
+		switch {
+		case npc && s.get("npc_"+abbreviation+"_save") != "":
+			c.SavingThrows[ability] = s.number("npc_" + abbreviation + "_save")
+		case !npc && s.flag(ability+"_save_prof"):
+			bonus := c.Abilities.Modifier(ability) + c.ProficiencyBonus
+			if s.get(ability+"_save_bonus") != "" {
+				bonus = s.number(ability + "_save_bonus")
+			}
+			c.SavingThrows[ability] = bonus
+		}
+	}
+
+	c.Skills = skills.Skills{}
+	for name, ability := range skills.SkillToAbility {
+		switch {
+		case npc && s.get("npc_"+name) != "":
+			c.Skills.Set(name, s.number("npc_"+name))
+		case !npc && s.flag(name+"_prof"):
+			multiplier := 1
+			if s.number(name+"_type") == 2 {
+				multiplier = 2
+			}
+			bonus := c.Abilities.Modifier(ability) + multiplier*c.ProficiencyBonus
+			if s.get(name+"_bonus") != "" {
+				bonus = s.number(name + "_bonus")
+			}
+			c.Skills.Set(name, bonus)
+		}
+	}
+
+	if c.PassivePerception == 0 {
+		c.PassivePerception = 10 + c.WisdomModifier
+		if p, ok := c.Skills[skills.Perception]; ok {
+			c.PassivePerception = 10 + p.Value
+		}
+	}
+
+	roll20Features(&c, s, npc)
+	for _, row := range s.sections["inventory"] {
+		c.Items = append(c.Items, roll20Item(row))
+	}
+	c.Resources = roll20Resources(s)
+	c.Spellcasting = roll20Spellcasting(s)
+
+	return c, nil
+}
+
+// roll20Classes stores the class and the multiclasses of the sheet in the
+// creature, along with its level.
+func roll20Classes(c *creature.Creature, s roll20Sheet) {
+	add := func(class string, level int) {
+		class = strings.ToLower(strings.TrimSpace(class))
+		if !classes.Valid(class) || level <= 0 {
+			return
+		}
+		if c.Classes == nil {
+			c.Classes = classes.Classes{}
+		}
+		c.Classes[class] += level
+	}
+
+	add(s.get("class"), s.number("base_level"))
+	for i := 1; i <= 3; i++ {
+		n := "multiclass" + strconv.Itoa(i)
+		if s.flag(n + "_flag") {
+			add(s.get(n), s.number(n+"_lvl"))
+		}
+	}
+
+	c.Level = s.number("level")
+	if creature.OutOfRange(c.Level) {
+		c.Level = 0
+		for _, level := range c.Classes {
+			c.Level += level
+		}
+	}
+	if creature.OutOfRange(c.Level) {
+		c.Level = 1
+	}
+	c.ProficiencyBonus = creature.ProficiencyBonusPerLevel[c.Level]
+}
+
+// roll20Features stores the traits and the actions of the sheet in the
+// creature.
+func roll20Features(c *creature.Creature, s roll20Sheet, npc bool) {
+	actions := func(section string) []creature.Action {
+		var as []creature.Action
+		for _, row := range s.sections[section] {
+			as = append(as, creature.Action{Name: row["name"], Description: row["description"]})
+		}
+		return as
+	}
+
+	if !npc {
+		c.Traits = actions("traits")
+		return
+	}
+
+	c.Traits = actions("npctrait")
+	for _, a := range actions("npcaction") {
+		if strings.EqualFold(a.Name, "Multiattack") {
+			c.Multiattack = a.Description
+			continue
+		}
+		c.Actions = append(c.Actions, a)
+	}
+	c.Reactions = actions("npcreaction")
+	for _, a := range actions("npcaction-l") {
+		if m := roll20Cost.FindStringSubmatch(a.Name); m != nil {
+			a.Name = m[1]
+			a.Cost, _ = strconv.Atoi(m[2])
+		}
+		c.LegendaryActions = append(c.LegendaryActions, a)
+	}
+}
+
+// roll20Modifiers returns the modifiers of an item, like "Item Type: Melee
+// Weapon, Damage: 1d8", by their lowercase name. A part without a name
+// continues the previous one, like the properties of "Properties: Finesse,
+// Light".
+func roll20Modifiers(s string) map[string]string {
+	modifiers := map[string]string{}
+	last := ""
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if i := strings.Index(part, ":"); i >= 0 {
+			last = strings.ToLower(strings.TrimSpace(part[:i]))
+			modifiers[last] = strings.TrimSpace(part[i+1:])
+		} else if last != "" && part != "" {
+			modifiers[last] += ", " + part
+		}
+	}
+
+	return modifiers
+}
+
+// roll20Item turns the provided row of the inventory into an item, along with
+// the armor or the weapon its modifiers describe.
+func roll20Item(row roll20Row) inventory.Item {
+	item := inventory.Item{
+		Name:     row["itemname"],
+		Quantity: roll20Number(row["itemcount"]),
+		Equipped: row["equipped"] == "1",
+	}
+	item.Weight, _ = strconv.ParseFloat(row["itemweight"], 64)
+
+	m := roll20Modifiers(row["itemmodifiers"])
+	itemType := strings.ToLower(m["item type"])
+	switch {
+	case strings.HasSuffix(itemType, "armor") || itemType == armor.Shield:
+		item.Armor = &armor.Armor{
+			Type:                strings.TrimSuffix(itemType, " armor"),
+			BaseClass:           roll20Number(m["ac"]),
+			StrengthRequirement: roll20Number(m["strength"]),
+			StealthDisadvantage: strings.EqualFold(m["stealth"], "disadvantage"),
+		}
+		if !armor.ValidType(item.Armor.Type) {
+			item.Armor = nil
+		}
+	case strings.HasSuffix(itemType, "weapon"):
+		w := &weapons.Weapon{
+			Damage:          m["damage"],
+			VersatileDamage: m["alternate damage"],
+			DamageType:      strings.ToLower(m["damage type"]),
+			Category:        weapons.Simple,
+			Range:           m["range"],
+		}
+		if strings.Contains(itemType, weapons.Martial) {
+			w.Category = weapons.Martial
+		}
+		for _, p := range splitList(m["properties"]) {
+			w.Properties = append(w.Properties, strings.ReplaceAll(strings.ToLower(p), " ", "_"))
+		}
+		if strings.Contains(itemType, weapons.Ranged) {
+			w.Properties = append(w.Properties, weapons.Ranged)
+		}
+		sort.Strings(w.Properties)
+		item.Weapon = w
+	}
+
+	return item
+}
+
+// roll20Resources returns the class resource and the other resource of the
+// sheet, if they are named.
+func roll20Resources(s roll20Sheet) map[string]*creature.Resource {
+	rs := map[string]*creature.Resource{}
+	for _, name := range []string{"class_resource", "other_resource"} {
+		label := s.get(name + "_name")
+		if label == "" {
+			continue
+		}
+		rs[resourceName(label)] = &creature.Resource{
+			Current: s.number(name),
+			Maximum: roll20Number(s.max(name)),
+		}
+	}
+	if len(rs) == 0 {
+		return nil
+	}
+
+	return rs
+}
+
+// roll20Spellcasting returns the spellcasting of the sheet, if it has any.
+func roll20Spellcasting(s roll20Sheet) *spellcasting.Spellcasting {
+	sc := &spellcasting.Spellcasting{}
+	if m := roll20Ability.FindStringSubmatch(s.get("spellcasting_ability")); m != nil {
+		sc.Ability = m[1]
+	}
+	for level := 1; level <= 9; level++ {
+		n := "lvl" + strconv.Itoa(level) + "_slots_"
+		if maximum := s.number(n + "total"); maximum > 0 {
+			sc.Slots = append(sc.Slots, spellcasting.Slots{
+				Level:    level,
+				Maximum:  maximum,
+				Expended: maximum - s.number(n+"expended"),
+			})
+		}
+	}
+	for level := 0; level <= 9; level++ {
+		for _, row := range s.sections[roll20SpellSection(level)] {
+			sc.Known = append(sc.Known, row["spellname"])
+			if row["spellprepared"] == "1" {
+				sc.Prepared = append(sc.Prepared, row["spellname"])
+			}
+		}
+	}
+
+	if sc.Ability == "" && sc.Slots == nil && sc.Known == nil {
+		return nil
+	}
+
+	return sc
+}
+
+// roll20Writer collects the attributes of a character, giving each a new ID.
+type roll20Writer struct {
+	attributes []Attribute
+	ids        int
+}
+
+// id returns a new ID, shaped like the ones of Roll20.
+func (w *roll20Writer) id() string {
+	w.ids++
+	return fmt.Sprintf("-CM%017d", w.ids)
+}
+
+// set adds the provided attribute.
+func (w *roll20Writer) set(name string, current interface{}) {
+	w.setMax(name, current, "")
+}
+
+// setMax adds the provided attribute with a maximum.
+func (w *roll20Writer) setMax(name string, current, max interface{}) {
+	w.attributes = append(w.attributes, Attribute{
+		Name:    name,
+		Current: roll20Value(fmt.Sprint(current)),
+		Max:     roll20Value(fmt.Sprint(max)),
+		ID:      w.id(),
+	})
+}
+
+// row adds a row with the provided fields to the provided repeating section.
+// The fields are added in the order of the section.
+func (w *roll20Writer) row(section string, fields roll20Row) {
+	id := w.id()
+	for _, field := range roll20Fields(section) {
+		if v, ok := fields[field]; ok {
+			w.set("repeating_"+section+"_"+id+"_"+field, v)
+		}
+	}
+}
+
+// NewCharacter returns the provided creature as a Roll20 character. Players
+// use the character sheet, while monsters and NPCs use the NPC one.
+func NewCharacter(c creature.Creature) Character {
+	npc := c.Kind == creature.Monster || c.Kind == creature.NPC
+	w := &roll20Writer{}
+
+	w.set("npc", boolFlag(npc))
+	for _, ability := range abilityOrder {
+		w.set(ability, c.Abilities.Score(ability))
+		w.set(ability+"_mod", c.Abilities.Modifier(ability))
+	}
+	w.setMax("hp", c.CurrentHitPoints, c.MaximumHitPoints)
+	w.set("pb", c.ProficiencyBonus)
+	w.set("passive_wisdom", c.PassivePerception)
+	for _, coin := range []string{inventory.Copper, inventory.Silver, inventory.Electrum, inventory.Gold, inventory.Platinum} {
+		w.set(coin, coinCount(c.Currency, coin))
+	}
+
+	if npc {
+		w.set("npc_ac", c.ArmorClass)
+		w.set("npc_challenge", c.ChallengeRating)
+		w.set("npc_xp", c.ExperienceValue)
+		w.set("npc_hpformula", c.HitPointsDice)
+		w.set("npc_speed", formatSpeed(c.Speed))
+		w.set("npc_senses", c.Senses)
+		w.set("npc_languages", c.Languages)
+		w.set("npc_resistances", strings.Join(c.Resistances, ", "))
+		w.set("npc_vulnerabilities", strings.Join(c.Vulnerabilities, ", "))
+		w.set("npc_immunities", strings.Join(c.Immunities, ", "))
+		w.set("npc_condition_immunities", strings.Join(c.ConditionImmunities, ", "))
+		w.set("npc_type", roll20NPCType(c))
+	} else {
+		w.set("ac", c.ArmorClass)
+		w.set("level", c.Level)
+		w.set("experience", c.ExperiencePoints)
+		w.set("speed", formatSpeed(c.Speed))
+		roll20WriteClasses(w, c)
+	}
+
+	for _, ability := range abilityOrder {
+		bonus, proficient := c.SavingThrows[ability]
+		switch {
+		case npc && proficient:
+			w.set("npc_"+roll20Abbreviations[ability]+"_save", bonus)
+		case !npc:
+			w.set(ability+"_save_prof", roll20Proficiency(proficient, "(@{pb})"))
+			if proficient {
+				w.set(ability+"_save_bonus", bonus)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(skills.SkillToAbility))
+	for name := range skills.SkillToAbility {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		skill, proficient := c.Skills[name]
+		proficient = proficient && skill != nil
+		switch {
+		case npc && proficient:
+			w.set("npc_"+name, skill.Value)
+		case !npc:
+			w.set(name+"_prof", roll20Proficiency(proficient, "(@{pb}*@{"+name+"_type})"))
+			if proficient {
+				multiplier := 1
+				if c.ProficiencyBonus > 0 && skill.Value-c.Abilities.Modifier(skills.SkillToAbility[name]) >= 2*c.ProficiencyBonus {
+					multiplier = 2
+				}
+				w.set(name+"_type", multiplier)
+				w.set(name+"_bonus", skill.Value)
+			}
+		}
+	}
+
+	roll20WriteFeatures(w, c, npc)
+	for _, item := range c.Items {
+		w.row("inventory", roll20Row{
+			"itemname":      item.Name,
+			"itemcount":     strconv.Itoa(item.Quantity),
+			"itemweight":    strconv.FormatFloat(item.Weight, 'f', -1, 64),
+			"equipped":      boolFlag(item.Equipped),
+			"itemmodifiers": roll20ItemModifiers(item),
+		})
+	}
+
+	names = resourceNames(c)
+	for i, name := range []string{"class_resource", "other_resource"} {
+		if i >= len(names) {
+			break
+		}
+		r := c.Resources[names[i]]
+		w.set(name+"_name", resourceLabel(names[i]))
+		w.setMax(name, r.Current, r.Maximum)
+	}
+
+	if sc := c.Spellcasting; sc != nil {
+		roll20WriteSpells(w, sc)
+	}
+
+	return Character{
+		SchemaVersion: 2,
+		Name:          c.Name,
+		Attribs:       w.attributes,
+		Abilities:     []interface{}{},
+	}
+}
+
+// abilityOrder are the abilities in the order of the sheet.
+var abilityOrder = []string{
+	abilities.Strength,
+	abilities.Dexterity,
+	abilities.Constitution,
+	abilities.Intelligence,
+	abilities.Wisdom,
+	abilities.Charisma,
+}
+
+// roll20NPCType returns the size, type and alignment of an NPC, like "Small
+// humanoid, neutral evil".
+func roll20NPCType(c creature.Creature) string {
+	var parts []string
+	if c.Size != "" {
+		parts = append(parts, strings.ToUpper(c.Size[:1])+c.Size[1:])
+	}
+	if c.Type != "" {
+		parts = append(parts, c.Type)
+	}
+
+	t := strings.Join(parts, " ")
+	if c.Alignment != "" {
+		t += ", " + c.Alignment
+	}
+
+	return t
+}
+
+// roll20WriteClasses adds the classes of the provided creature, with the one
+// of the most levels as the main class and the rest as multiclasses.
+func roll20WriteClasses(w *roll20Writer, c creature.Creature) {
+	names := make([]string, 0, len(c.Classes))
+	for class := range c.Classes {
+		names = append(names, class)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if c.Classes[names[i]] != c.Classes[names[j]] {
+			return c.Classes[names[i]] > c.Classes[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	for i, class := range names {
+		if i == 0 {
+			w.set("class", resourceLabel(class))
+			w.set("base_level", c.Classes[class])
+			continue
+		}
+		if i > 3 {
+			break
+		}
+		n := "multiclass" + strconv.Itoa(i)
+		w.set(n+"_flag", "1")
+		w.set(n, resourceLabel(class))
+		w.set(n+"_lvl", c.Classes[class])
+	}
+}
+
+// roll20WriteFeatures adds the traits and the actions of the provided
+// creature. Characters have only traits on the sheet, so their actions become
+// traits too.
+func roll20WriteFeatures(w *roll20Writer, c creature.Creature, npc bool) {
+	actions := func(section string, as []creature.Action) {
+		for _, a := range as {
+			name := a.Name
+			if a.Cost > 1 {
+				name += " (Costs " + strconv.Itoa(a.Cost) + " Actions)"
+			}
+			w.row(section, roll20Row{"name": name, "description": a.Description})
+		}
+	}
+
+	if !npc {
+		actions("traits", c.Traits)
+		actions("traits", c.Actions)
+		actions("traits", c.Reactions)
+		actions("traits", c.LegendaryActions)
+		return
+	}
+
+	actions("npctrait", c.Traits)
+	if c.Multiattack != "" {
+		actions("npcaction", []creature.Action{{Name: "Multiattack", Description: c.Multiattack}})
+	}
+	actions("npcaction", c.Actions)
+	actions("npcreaction", c.Reactions)
+	actions("npcaction-l", c.LegendaryActions)
+}
+
+// roll20ItemModifiers returns the modifiers of the provided item, like "Item
+// Type: Simple Melee Weapon, Damage: 1d4", that describe its armor or weapon.
+func roll20ItemModifiers(item inventory.Item) string {
+	var m []string
+	if a := item.Armor; a != nil {
+		if a.Type == armor.Shield {
+			m = append(m, "Item Type: Shield")
+		} else {
+			m = append(m, "Item Type: "+resourceLabel(a.Type)+" Armor")
+		}
+		m = append(m, "AC: "+strconv.Itoa(a.BaseClass))
+		if a.StrengthRequirement > 0 {
+			m = append(m, "Strength: "+strconv.Itoa(a.StrengthRequirement))
+		}
+		if a.StealthDisadvantage {
+			m = append(m, "Stealth: Disadvantage")
+		}
+	}
+
+	if wp := item.Weapon; wp != nil {
+		kind := "Melee"
+		var properties []string
+		for _, p := range wp.Properties {
+			if p == weapons.Ranged {
+				kind = "Ranged"
+				continue
+			}
+			properties = append(properties, resourceLabel(p))
+		}
+
+		category := weapons.Simple
+		if wp.Category == weapons.Martial {
+			category = weapons.Martial
+		}
+		m = append(m, "Item Type: "+resourceLabel(category)+" "+kind+" Weapon")
+		if wp.Damage != "" {
+			m = append(m, "Damage: "+wp.Damage)
+		}
+		if wp.DamageType != "" {
+			m = append(m, "Damage Type: "+wp.DamageType)
+		}
+		if wp.VersatileDamage != "" {
+			m = append(m, "Alternate Damage: "+wp.VersatileDamage)
+		}
+		if wp.Range != "" {
+			m = append(m, "Range: "+wp.Range)
+		}
+		if len(properties) > 0 {
+			m = append(m, "Properties: "+strings.Join(properties, ", "))
+		}
+	}
+
+	return strings.Join(m, ", ")
+}
+
+// roll20WriteSpells adds the provided spellcasting. The pact slots are left
+// out, since the sheet keeps them along with the rest.
+func roll20WriteSpells(w *roll20Writer, sc *spellcasting.Spellcasting) {
+	if sc.Ability != "" {
+		w.set("spellcasting_ability", "@{"+sc.Ability+"_mod}+")
+	}
+	for _, slots := range sc.Slots {
+		n := "lvl" + strconv.Itoa(slots.Level) + "_slots_"
+		w.set(n+"total", slots.Maximum)
+		w.set(n+"expended", slots.Maximum-slots.Expended)
+	}
+
+	prepared := map[string]bool{}
+	for _, name := range sc.Prepared {
+		prepared[name] = true
+	}
+	known := map[string]bool{}
+	for _, name := range append(append([]string{}, sc.Known...), sc.Prepared...) {
+		if known[name] {
+			continue
+		}
+		known[name] = true
+
+		w.row(roll20SpellSection(spellLevel(name)), roll20Row{
+			"spellname":     name,
+			"spellprepared": boolFlag(prepared[name]),
+		})
+	}
+}
+
+// roll20Proficiency returns the provided formula if proficient is set, or 0.
+func roll20Proficiency(proficient bool, formula string) string {
+	if proficient {
+		return formula
+	}
+
+	return "0"
+}
+
+// boolFlag returns "1" for true and "0" for false.
+func boolFlag(b bool) string {
+	if b {
+		return "1"
+	}
+
+	return "0"
+}
+
+// coinCount returns the number of the provided coin in the currency.
+func coinCount(c inventory.Currency, coin string) int {
+	switch coin {
+	case inventory.Copper:
+		return c.Copper
+	case inventory.Silver:
+		return c.Silver
+	case inventory.Electrum:
+		return c.Electrum
+	case inventory.Gold:
+		return c.Gold
+	default:
+		return c.Platinum
+	}
+}
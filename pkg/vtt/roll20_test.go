@@ -0,0 +1,186 @@
+package vtt
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aakordas/creature_manager/pkg/abilities"
+	"github.com/aakordas/creature_manager/pkg/armor"
+	"github.com/aakordas/creature_manager/pkg/classes"
+	"github.com/aakordas/creature_manager/pkg/conditions"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/saves"
+	"github.com/aakordas/creature_manager/pkg/skills"
+	"github.com/aakordas/creature_manager/pkg/spellcasting"
+	"github.com/aakordas/creature_manager/pkg/weapons"
+)
+
+func TestRoll20Creature_Character(t *testing.T) {
+	c, err := Roll20Creature(sample(t, "roll20_character.json"))
+	if err != nil {
+		t.Fatalf("Roll20Creature() error = %v", err)
+	}
+
+	if c.Name != "Brother Tomas" || c.Kind != creature.Player || c.ExperiencePoints != 2800 {
+		t.Errorf("Unexpected description: %v, %v, %v XP", c.Name, c.Kind, c.ExperiencePoints)
+	}
+	if c.CurrentHitPoints != 27 || c.MaximumHitPoints != 31 || c.ArmorClass != 18 {
+		t.Errorf("Unexpected hit points and armor class: %v/%v, %v", c.CurrentHitPoints, c.MaximumHitPoints, c.ArmorClass)
+	}
+	if c.StrengthModifier != 2 || c.WisdomModifier != 3 {
+		t.Errorf("Unexpected modifiers: strength %v, wisdom %v", c.StrengthModifier, c.WisdomModifier)
+	}
+	if !reflect.DeepEqual(c.Classes, classes.Classes{"cleric": 3, "fighter": 1}) || c.Level != 4 || c.ProficiencyBonus != 2 {
+		t.Errorf("Unexpected classes: %v, level %v, +%v", c.Classes, c.Level, c.ProficiencyBonus)
+	}
+	want := saves.SavingThrows{abilities.Wisdom: 5, abilities.Charisma: 3}
+	if !reflect.DeepEqual(c.SavingThrows, want) {
+		t.Errorf("Unexpected saving throws: %v, want %v", c.SavingThrows, want)
+	}
+	if s := c.Skills[skills.Religion]; s == nil || s.Value != 4 {
+		t.Errorf("Unexpected religion: %v", s)
+	}
+	if len(c.Skills) != 3 || c.PassivePerception != 15 {
+		t.Errorf("Unexpected skills: %v, passive perception %v", c.Skills, c.PassivePerception)
+	}
+	if len(c.Traits) != 2 || c.Traits[1].Name != "Second Wind" {
+		t.Errorf("Unexpected traits: %v", c.Traits)
+	}
+	if r := c.Resources["channel_divinity"]; r == nil || r.Current != 1 || r.Maximum != 1 {
+		t.Errorf("Unexpected resources: %v", c.Resources)
+	}
+
+	if len(c.Items) != 5 || c.Currency.Gold != 42 || c.Currency.Silver != 7 {
+		t.Fatalf("Unexpected inventory: %v, %v", c.Items, c.Currency)
+	}
+	mail := c.Items[0].Armor
+	if mail == nil || mail.Type != armor.Heavy || mail.BaseClass != 16 || mail.StrengthRequirement != 13 || !mail.StealthDisadvantage {
+		t.Errorf("Unexpected chain mail: %+v", mail)
+	}
+	hammer := c.Items[1].Weapon
+	if hammer == nil || hammer.Category != weapons.Martial || hammer.VersatileDamage != "1d10" || !hammer.Has(weapons.Versatile) {
+		t.Errorf("Unexpected warhammer: %+v", hammer)
+	}
+	crossbow := c.Items[2].Weapon
+	properties := []string{weapons.Loading, weapons.Ranged, weapons.TwoHanded}
+	if crossbow == nil || crossbow.Range != "80/320" || !reflect.DeepEqual(crossbow.Properties, properties) {
+		t.Errorf("Unexpected crossbow: %+v", crossbow)
+	}
+	if shield := c.Items[3].Armor; shield == nil || shield.Type != armor.Shield || shield.BaseClass != 2 {
+		t.Errorf("Unexpected shield: %+v", shield)
+	}
+	if water := c.Items[4]; water.Quantity != 2 || water.Equipped || water.Armor != nil || water.Weapon != nil {
+		t.Errorf("Unexpected holy water: %+v", water)
+	}
+
+	s := c.Spellcasting
+	if s == nil {
+		t.Fatal("Expected spellcasting")
+	}
+	slots := []spellcasting.Slots{{Level: 1, Maximum: 4, Expended: 2}, {Level: 2, Maximum: 2}}
+	if s.Ability != abilities.Wisdom || !reflect.DeepEqual(s.Slots, slots) {
+		t.Errorf("Unexpected spellcasting: %v, %v", s.Ability, s.Slots)
+	}
+	known := []string{"Sacred Flame", "Cure Wounds", "Bless", "Spiritual Weapon"}
+	if !reflect.DeepEqual(s.Known, known) || !reflect.DeepEqual(s.Prepared, []string{"Cure Wounds", "Spiritual Weapon"}) {
+		t.Errorf("Unexpected spells: %v, prepared %v", s.Known, s.Prepared)
+	}
+}
+
+func TestRoll20Creature_NPC(t *testing.T) {
+	c, err := Roll20Creature(sample(t, "roll20_npc.json"))
+	if err != nil {
+		t.Fatalf("Roll20Creature() error = %v", err)
+	}
+
+	if c.Kind != creature.Monster || c.Size != "small" || c.Type != "humanoid (goblinoid)" || c.Alignment != "neutral evil" {
+		t.Errorf("Unexpected description: %v, %v, %q, %q", c.Kind, c.Size, c.Type, c.Alignment)
+	}
+	if c.ChallengeRating != "1" || c.ExperienceValue != 200 || c.ProficiencyBonus != 2 {
+		t.Errorf("Unexpected challenge: %v, %v XP, +%v", c.ChallengeRating, c.ExperienceValue, c.ProficiencyBonus)
+	}
+	if c.ArmorClass != 17 || !c.Defense.Manual || c.HitPointsDice != "6d6" || c.MaximumHitPoints != 21 {
+		t.Errorf("Unexpected armor class and hit points: %v, manual %v, %v, %v", c.ArmorClass, c.Defense.Manual, c.HitPointsDice, c.MaximumHitPoints)
+	}
+	if !reflect.DeepEqual(c.SavingThrows, saves.SavingThrows{abilities.Dexterity: 4}) {
+		t.Errorf("Unexpected saving throws: %v", c.SavingThrows)
+	}
+	if s := c.Skills[skills.Stealth]; s == nil || s.Value != 6 || len(c.Skills) != 1 {
+		t.Errorf("Unexpected skills: %v", c.Skills)
+	}
+	if !reflect.DeepEqual(c.Speed, map[string]int{"walk": 30}) || c.Senses != "darkvision 60 ft." || c.Languages != "Common, Goblin" {
+		t.Errorf("Unexpected speed, senses and languages: %v, %q, %q", c.Speed, c.Senses, c.Languages)
+	}
+	if !reflect.DeepEqual(c.ConditionImmunities, []string{conditions.Charmed}) {
+		t.Errorf("Unexpected condition immunities: %v", c.ConditionImmunities)
+	}
+	if len(c.Traits) != 1 || c.Multiattack == "" || len(c.Actions) != 1 || len(c.Reactions) != 1 {
+		t.Errorf("Unexpected actions: %v, multiattack %q, traits %v, reactions %v", c.Actions, c.Multiattack, c.Traits, c.Reactions)
+	}
+	if l := c.LegendaryActions; len(l) != 1 || l[0].Name != "Rally" || l[0].Cost != 2 {
+		t.Errorf("Unexpected legendary actions: %v", l)
+	}
+}
+
+func TestRoll20Creature_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"Empty", ""},
+		{"Not JSON", "goblin"},
+		{"No name", `{"attribs": []}`},
+		{"Invalid challenge rating", `{"name": "Goblin", "attribs": [{"name": "npc", "current": "1"}, {"name": "npc_challenge", "current": "1/3"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Roll20Creature([]byte(tt.data)); err == nil {
+				t.Errorf("Roll20Creature() expected an error")
+			}
+		})
+	}
+}
+
+func TestRoll20Character_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"Character", "roll20_character.json"},
+		{"NPC", "roll20_npc.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := Roll20Creature(sample(t, tt.file))
+			if err != nil {
+				t.Fatalf("Roll20Creature() error = %v", err)
+			}
+			data, err := Roll20Character(want)
+			if err != nil {
+				t.Fatalf("Roll20Character() error = %v", err)
+			}
+			got, err := Roll20Creature(data)
+			if err != nil {
+				t.Fatalf("Roll20Creature() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Roll20Creature() = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	// Roll20 keeps no hit dice per class, so the wizard loses them.
+	want := wizard()
+	want.HitDice = nil
+	data, err := Roll20Character(want)
+	if err != nil {
+		t.Fatalf("Roll20Character() error = %v", err)
+	}
+	got, err := Roll20Creature(data)
+	if err != nil {
+		t.Fatalf("Roll20Creature() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Roll20Creature() = %+v, want %+v", got, want)
+	}
+}
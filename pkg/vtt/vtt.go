@@ -0,0 +1,230 @@
+package vtt
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/conditions"
+	"github.com/aakordas/creature_manager/pkg/creature"
+	"github.com/aakordas/creature_manager/pkg/damage"
+	"github.com/aakordas/creature_manager/pkg/spells"
+)
+
+// Error is the error that gets returned when a character of a virtual tabletop
+// cannot be converted.
+type Error struct {
+	Message string
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
+
+var (
+	errInvalidJSON = Error{"The character is not valid JSON."}
+	errMissingName = Error{"The character has no name."}
+	errInvalidCR   = Error{"The character has an invalid challenge rating."}
+)
+
+// challengeRatings maps the fractional challenge ratings to the numbers the
+// virtual tabletops store them as.
+var challengeRatings = map[string]float64{
+	"0":   0,
+	"1/8": 0.125,
+	"1/4": 0.25,
+	"1/2": 0.5,
+}
+
+// challengeRating returns the challenge rating of the provided number, like
+// 1/4 for 0.25.
+func challengeRating(n float64) string {
+	for cr, v := range challengeRatings {
+		if v == n {
+			return cr
+		}
+	}
+
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// challengeRatingNumber returns the number of the provided challenge rating,
+// like 0.25 for 1/4.
+func challengeRatingNumber(cr string) float64 {
+	if v, ok := challengeRatings[cr]; ok {
+		return v
+	}
+	n, _ := strconv.Atoi(cr)
+
+	return float64(n)
+}
+
+// spellLevel returns the level of the SRD spell with the provided name. Spells
+// outside of the SRD are taken as 1st level spells.
+func spellLevel(name string) int {
+	for _, s := range spells.SRD {
+		if strings.EqualFold(s.Name, name) {
+			return s.Level
+		}
+	}
+
+	return 1
+}
+
+// tag matches the tags of an HTML description.
+var tag = regexp.MustCompile(`<[^>]*>`)
+
+// plainText returns the text of the provided HTML description, with a line
+// for each paragraph.
+func plainText(description string) string {
+	description = strings.ReplaceAll(description, "</p>", "\n")
+	description = strings.ReplaceAll(description, "<br>", "\n")
+	description = html.UnescapeString(tag.ReplaceAllString(description, ""))
+
+	lines := strings.Split(description, "\n")
+	text := lines[:0]
+	for _, l := range lines {
+		if l = strings.TrimSpace(l); l != "" {
+			text = append(text, l)
+		}
+	}
+
+	return strings.Join(text, "\n")
+}
+
+// htmlText returns the provided text as an HTML description, with a paragraph
+// for each line.
+func htmlText(text string) string {
+	if text == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, l := range strings.Split(text, "\n") {
+		b.WriteString("<p>" + html.EscapeString(l) + "</p>")
+	}
+
+	return b.String()
+}
+
+// speedModes are the movement modes of the virtual tabletops, with walking
+// first.
+var speedModes = []string{"walk", "burrow", "climb", "fly", "swim"}
+
+// formatSpeed returns the provided speeds like "30 ft., fly 60 ft.", with
+// walking first and the rest sorted by name.
+func formatSpeed(speed map[string]int) string {
+	modes := make([]string, 0, len(speed))
+	for mode := range speed {
+		if mode != "walk" {
+			modes = append(modes, mode)
+		}
+	}
+	sort.Strings(modes)
+
+	var s []string
+	if walk, ok := speed["walk"]; ok {
+		s = append(s, strconv.Itoa(walk)+" ft.")
+	}
+	for _, mode := range modes {
+		s = append(s, mode+" "+strconv.Itoa(speed[mode])+" ft.")
+	}
+
+	return strings.Join(s, ", ")
+}
+
+// speedPart matches a single speed, like "30 ft." or "fly 60 ft.".
+var speedPart = regexp.MustCompile(`^(?:([a-z]+) )?(\d+)`)
+
+// parseSpeed returns the speeds of the provided text, like "30 ft., fly 60
+// ft.". A speed without a mode is the walking one.
+func parseSpeed(s string) map[string]int {
+	speed := map[string]int{}
+	for _, part := range strings.Split(s, ",") {
+		m := speedPart.FindStringSubmatch(strings.ToLower(strings.TrimSpace(part)))
+		if m == nil {
+			continue
+		}
+		mode := m[1]
+		if mode == "" {
+			mode = "walk"
+		}
+		speed[mode], _ = strconv.Atoi(m[2])
+	}
+	if len(speed) == 0 {
+		return nil
+	}
+
+	return speed
+}
+
+// damageTypes returns the valid damage types among the provided values.
+func damageTypes(values []string) []string {
+	var types []string
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if damage.ValidType(v) {
+			types = append(types, v)
+		}
+	}
+
+	return types
+}
+
+// conditionNames returns the valid conditions among the provided values.
+func conditionNames(values []string) []string {
+	var names []string
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if conditions.Valid(v) {
+			names = append(names, v)
+		}
+	}
+
+	return names
+}
+
+// splitList returns the values of a comma separated list.
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// resourceName returns the name of a resource from its label, like
+// arcane_recovery for Arcane Recovery.
+func resourceName(label string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(label)), " ", "_")
+}
+
+// resourceLabel returns the label of a resource from its name, like Arcane
+// Recovery for arcane_recovery.
+func resourceLabel(name string) string {
+	words := strings.Fields(strings.ReplaceAll(name, "_", " "))
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+
+	return strings.Join(words, " ")
+}
+
+// resourceNames returns the names of the resources of the provided creature,
+// sorted.
+func resourceNames(c creature.Creature) []string {
+	names := make([]string, 0, len(c.Resources))
+	for name, r := range c.Resources {
+		if r != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
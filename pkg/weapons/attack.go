@@ -0,0 +1,78 @@
+package weapons
+
+import (
+	"github.com/aakordas/creature_manager/pkg/dice"
+)
+
+// Attack holds everything needed to resolve a weapon attack.
+type Attack struct {
+	Weapon         Weapon
+	TwoHanded      bool
+	AttackBonus    int       // The ability modifier plus the proficiency bonus, if proficient.
+	DamageModifier int       // The ability modifier added to the damage.
+	TargetAC       int       // The armor class of the target.
+	Roll           dice.Dice // The d20 roll, with advantage or disadvantage if needed.
+}
+
+// Result is the outcome of an attack.
+type Result struct {
+	Roll        int    `json:"roll" bson:"roll"` // The natural d20 roll.
+	AttackBonus int    `json:"attack_bonus" bson:"attack_bonus"`
+	Total       int    `json:"total" bson:"total"`
+	TargetAC    int    `json:"target_ac,omitempty" bson:"target_ac,omitempty"`
+	Hit         bool   `json:"hit" bson:"hit"`
+	Critical    bool   `json:"critical" bson:"critical"`
+	Damage      int    `json:"damage" bson:"damage"`
+	DamageRolls []int  `json:"damage_rolls,omitempty" bson:"damage_rolls,omitempty"`
+	DamageType  string `json:"damage_type,omitempty" bson:"damage_type,omitempty"`
+}
+
+const (
+	criticalHit  = 20
+	criticalMiss = 1
+)
+
+// Resolve rolls the attack and, if it hits, its damage. A natural 20 always
+// hits and doubles the damage dice, while a natural 1 always misses.
+func (a Attack) Resolve() (Result, error) {
+	damage, err := a.Weapon.DamageExpression(a.TwoHanded)
+	if err != nil {
+		return Result{}, err
+	}
+
+	roll := a.Roll
+	if roll == nil {
+		roll = dice.D20
+	}
+
+	r := Result{
+		Roll:        roll(),
+		AttackBonus: a.AttackBonus,
+		TargetAC:    a.TargetAC,
+		DamageType:  a.Weapon.DamageType,
+	}
+	r.Total = r.Roll + r.AttackBonus
+
+	switch r.Roll {
+	case criticalHit:
+		r.Hit, r.Critical = true, true
+	case criticalMiss:
+		r.Hit = false
+	default:
+		r.Hit = r.Total >= r.TargetAC
+	}
+	if !r.Hit {
+		return r, nil
+	}
+
+	if r.Critical {
+		damage = damage.Critical()
+	}
+	damage.Modifier += a.DamageModifier
+	r.Damage, r.DamageRolls = damage.Roll()
+	if r.Damage < 0 {
+		r.Damage = 0
+	}
+
+	return r, nil
+}
@@ -0,0 +1,138 @@
+package weapons
+
+import (
+	"strings"
+
+	"github.com/aakordas/creature_manager/pkg/abilities"
+	"github.com/aakordas/creature_manager/pkg/damage"
+	"github.com/aakordas/creature_manager/pkg/dice"
+)
+
+// Weapon models the weapon part of an item.
+type Weapon struct {
+	Damage          string   `json:"damage" bson:"damage"`                                         // The damage dice, like 1d8.
+	VersatileDamage string   `json:"versatile_damage,omitempty" bson:"versatile_damage,omitempty"` // The damage dice when wielded with two hands.
+	DamageType      string   `json:"damage_type" bson:"damage_type"`
+	Category        string   `json:"category" bson:"category"`
+	Properties      []string `json:"properties,omitempty" bson:"properties,omitempty"`
+	Range           string   `json:"range,omitempty" bson:"range,omitempty"` // The normal and long range, like 80/320.
+}
+
+const (
+	// Simple means a simple weapon.
+	Simple = "simple"
+	// Martial means a martial weapon.
+	Martial = "martial"
+)
+
+const (
+	// Finesse means the weapon can use either Strength or Dexterity.
+	Finesse = "finesse"
+	// Versatile means the weapon can be wielded with one or two hands.
+	Versatile = "versatile"
+	// Ranged means the weapon is a ranged weapon and uses Dexterity.
+	Ranged = "ranged"
+	// Thrown means the weapon can be thrown.
+	Thrown = "thrown"
+	// Light means the weapon is light enough for two-weapon fighting.
+	Light = "light"
+	// Heavy means the weapon is too heavy for small creatures.
+	Heavy = "heavy"
+	// TwoHanded means the weapon requires two hands.
+	TwoHanded = "two_handed"
+	// Reach means the weapon adds 5 feet to the reach of the creature.
+	Reach = "reach"
+	// Loading means the weapon can fire only once per action.
+	Loading = "loading"
+)
+
+// Error is the error that gets returned when a weapon is invalid.
+type Error struct {
+	Message string
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
+
+var (
+	errInvalidDamage   = Error{"The weapon has an invalid damage expression."}
+	errInvalidCategory = Error{"A weapon is either simple or martial."}
+	errInvalidType     = Error{"The weapon has an invalid damage type."}
+	errNotVersatile    = Error{"The weapon is not versatile."}
+)
+
+// Validate checks that the weapon has valid damage dice, damage type and
+// category.
+func (w Weapon) Validate() error {
+	if _, err := dice.Parse(w.Damage); err != nil {
+		return errInvalidDamage
+	}
+	if w.VersatileDamage != "" {
+		if _, err := dice.Parse(w.VersatileDamage); err != nil {
+			return errInvalidDamage
+		}
+	}
+	if w.Category != "" && w.Category != Simple && w.Category != Martial {
+		return errInvalidCategory
+	}
+	if w.DamageType != "" && !damage.ValidType(w.DamageType) {
+		return errInvalidType
+	}
+
+	return nil
+}
+
+// Has checks whether the weapon has the provided property.
+func (w Weapon) Has(property string) bool {
+	for _, p := range w.Properties {
+		if strings.EqualFold(p, property) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Ability returns the ability the weapon attacks with. Finesse weapons use the
+// better of Strength and Dexterity and ranged weapons use Dexterity.
+func (w Weapon) Ability(a abilities.Abilities) string {
+	switch {
+	case w.Has(Finesse):
+		if a.DexterityModifier > a.StrengthModifier {
+			return abilities.Dexterity
+		}
+		return abilities.Strength
+	case w.Has(Ranged):
+		return abilities.Dexterity
+	default:
+		return abilities.Strength
+	}
+}
+
+// Proficient checks whether a creature with the provided proficiencies is
+// proficient with the weapon of the provided name. A proficiency is either a
+// weapon category or the name of a weapon.
+func (w Weapon) Proficient(name string, proficiencies []string) bool {
+	for _, p := range proficiencies {
+		if (w.Category != "" && strings.EqualFold(p, w.Category)) || strings.EqualFold(p, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DamageExpression returns the damage dice of the weapon, using the versatile
+// damage when it is wielded with two hands.
+func (w Weapon) DamageExpression(twoHanded bool) (dice.Expression, error) {
+	damage := w.Damage
+	if twoHanded {
+		if !w.Has(Versatile) || w.VersatileDamage == "" {
+			return dice.Expression{}, errNotVersatile
+		}
+		damage = w.VersatileDamage
+	}
+
+	return dice.Parse(damage)
+}
@@ -0,0 +1,90 @@
+package weapons
+
+import (
+	"testing"
+
+	"github.com/aakordas/creature_manager/pkg/abilities"
+)
+
+var (
+	rapier    = Weapon{Damage: "1d8", DamageType: "piercing", Category: Martial, Properties: []string{Finesse}}
+	longbow   = Weapon{Damage: "1d8", DamageType: "piercing", Category: Martial, Properties: []string{Ranged, Heavy}}
+	longsword = Weapon{Damage: "1d8", VersatileDamage: "1d10", DamageType: "slashing", Category: Martial, Properties: []string{Versatile}}
+)
+
+func TestWeapon_Ability(t *testing.T) {
+	var (
+		strong = abilities.Abilities{StrengthModifier: 3, DexterityModifier: 1}
+		nimble = abilities.Abilities{StrengthModifier: 0, DexterityModifier: 4}
+	)
+
+	tests := []struct {
+		name      string
+		weapon    Weapon
+		abilities abilities.Abilities
+		want      string
+	}{
+		{"Finesse strong", rapier, strong, abilities.Strength},
+		{"Finesse nimble", rapier, nimble, abilities.Dexterity},
+		{"Ranged", longbow, strong, abilities.Dexterity},
+		{"Melee", longsword, nimble, abilities.Strength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.weapon.Ability(tt.abilities); got != tt.want {
+				t.Errorf("Weapon.Ability() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeapon_Proficient(t *testing.T) {
+	if !rapier.Proficient("Rapier", []string{Martial}) {
+		t.Errorf("Not proficient through the weapon category.")
+	}
+	if !rapier.Proficient("Rapier", []string{"rapier"}) {
+		t.Errorf("Not proficient through the weapon name.")
+	}
+	if rapier.Proficient("Rapier", []string{Simple, "dagger"}) {
+		t.Errorf("Proficient without a matching proficiency.")
+	}
+}
+
+// fixed returns a d20 that always rolls v.
+func fixed(v int) func() int {
+	return func() int {
+		return v
+	}
+}
+
+func TestAttack_Resolve(t *testing.T) {
+	tests := []struct {
+		name         string
+		attack       Attack
+		wantHit      bool
+		wantCritical bool
+		wantDice     int
+		wantErr      bool
+	}{
+		{"Hit", Attack{Weapon: rapier, AttackBonus: 5, TargetAC: 15, Roll: fixed(10)}, true, false, 1, false},
+		{"Miss", Attack{Weapon: rapier, AttackBonus: 5, TargetAC: 16, Roll: fixed(10)}, false, false, 0, false},
+		{"Natural 1", Attack{Weapon: rapier, AttackBonus: 20, TargetAC: 10, Roll: fixed(1)}, false, false, 0, false},
+		{"Critical", Attack{Weapon: rapier, AttackBonus: 0, TargetAC: 30, Roll: fixed(20)}, true, true, 2, false},
+		{"Versatile", Attack{Weapon: longsword, TwoHanded: true, TargetAC: 5, Roll: fixed(10)}, true, false, 1, false},
+		{"Not versatile", Attack{Weapon: rapier, TwoHanded: true, TargetAC: 5, Roll: fixed(10)}, false, false, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.attack.Resolve()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Attack.Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Hit != tt.wantHit || got.Critical != tt.wantCritical {
+				t.Errorf("Attack.Resolve() hit = %v, critical = %v, want %v and %v", got.Hit, got.Critical, tt.wantHit, tt.wantCritical)
+			}
+			if len(got.DamageRolls) != tt.wantDice {
+				t.Errorf("Attack.Resolve() rolled %v damage dice, want %v", len(got.DamageRolls), tt.wantDice)
+			}
+		})
+	}
+}